@@ -0,0 +1,90 @@
+package timer
+
+/*
+AccountingMode selects how the time of nested anchors is attributed.
+
+AccountingExclusive, the default, pauses the enclosing anchor on every Start
+and resumes it on the matching Stop, so that each anchor accumulates its own
+time only. It is exact for recursion and for anchors started under different
+parents, and keeps time series per anchor, but every Start and Stop updates
+two anchors and all the bookkeeping happens on the measured path.
+
+AccountingInclusive never pauses anything: each anchor records the time
+between its outermost Start and Stop, plus the sum of that time for the
+anchors started directly in it, and its own time is the difference of both.
+It is simpler to verify and cheaper per call, at the cost of:
+  - time series not being recorded;
+  - an anchor re-entered through another one (A, B, then A again) having
+    the inner call counted in the intermediate anchor B;
+  - an anchor still open showing no time of its own until it is stopped,
+    its finished children being subtracted already.
+*/
+type AccountingMode int
+
+const (
+	AccountingExclusive AccountingMode = iota
+	AccountingInclusive
+)
+
+/*
+SetAccountingMode changes how nested anchors are attributed time, see
+AccountingMode. It must be set before anchors are started: the change is
+ignored with a warning while an anchor is open, and counters recorded before
+it are not converted.
+*/
+func (p *Profiler) SetAccountingMode(mode AccountingMode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.currentTiming != nil {
+		warn("timer: accounting mode not changed while anchor %q is open",
+			p.currentTiming.anchor.name)
+		return
+	}
+
+	p.accounting = mode
+}
+
+// settleInclusive records the inclusive time of the closing timing, then
+// derives the own time of its anchor and of the enclosing one.
+func (p *Profiler) settleInclusive(closing *timing, end int64) {
+	if closing.outer != nil {
+		// Already covered by the outermost call
+		return
+	}
+
+	var tscount = end - closing.start
+	if tscount < 0 {
+		p.clockAnomalies = p.clockAnomalies + 1
+		tscount = 0
+	}
+
+	var anchor = closing.anchor
+	if !closing.warmup {
+		anchor.inclusive = anchor.inclusive + tscount
+		anchor.variation.add(float64(tscount))
+		p.addHit(anchor, tscount)
+		p.settleOwn(anchor)
+	}
+
+	if closing.previous != nil {
+		var enclosing = closing.previous.anchor
+		enclosing.childInclusive = enclosing.childInclusive + tscount
+		p.settleOwn(enclosing)
+	}
+}
+
+func (p *Profiler) settleOwn(anchor *anchor) {
+	anchor.tscount = anchor.inclusive - anchor.childInclusive
+	if anchor.tscount < 0 {
+		// Still open, its own inclusive time is not known yet
+		anchor.tscount = 0
+	}
+
+	anchor.elapsed = ticksToMilliseconds(anchor.tscount)
+}
+
+// SetAccountingMode changes how the default profiler attributes nested time.
+func SetAccountingMode(mode AccountingMode) {
+	defaultProfiler.SetAccountingMode(mode)
+}
@@ -0,0 +1,87 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAccountingModes(t *testing.T) {
+	var tests = []struct {
+		name string
+		mode AccountingMode
+	}{
+		{"exclusive", AccountingExclusive},
+		{"inclusive", AccountingInclusive},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			p.SetAccountingMode(test.mode)
+
+			for i := 0; i < 2; i++ {
+				p.Start("parent")
+				clock.advance(10)
+				for _, child := range []string{"a", "b"} {
+					p.Start(child)
+					clock.advance(100)
+					p.Stop(child)
+				}
+				clock.advance(10)
+				p.Stop("parent")
+			}
+
+			// Alike in both modes for anchors nested once
+			var report = p.Snapshot()
+			var want = map[string]int64{"parent": 40, "a": 200, "b": 200}
+			for name, tscount := range want {
+				if result := resultOf(t, report, name); result.TSCount != tscount || result.Hits != 2 {
+					t.Errorf("%s: %d hits of %d units, want 2 of %d", name, result.Hits, result.TSCount, tscount)
+				}
+			}
+			if report.Total.TSCount != 440 {
+				t.Errorf("total %d units, want 440", report.Total.TSCount)
+			}
+		})
+	}
+}
+
+func TestInclusiveAccountingOpenAnchor(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.SetAccountingMode(AccountingInclusive)
+
+	p.Start("parent")
+	clock.advance(10)
+	p.Start("child")
+	clock.advance(100)
+	p.Stop("child")
+
+	// No time of its own until stopped, its child subtracted already
+	if parent := resultOf(t, p.Snapshot(), "parent"); parent.TSCount != 0 {
+		t.Errorf("open parent at %d units, want 0", parent.TSCount)
+	}
+	clock.advance(10)
+	p.Stop("parent")
+	if parent := resultOf(t, p.Snapshot(), "parent"); parent.TSCount != 20 {
+		t.Errorf("parent at %d units, want 20", parent.TSCount)
+	}
+}
+
+func TestSetAccountingModeWhileOpen(t *testing.T) {
+	useFakeClock(t)
+	var captured = captureWarnings(t)
+	var p = New()
+
+	p.Start("a")
+	p.SetAccountingMode(AccountingInclusive)
+	p.Stop("a")
+
+	if p.accounting != AccountingExclusive {
+		t.Errorf("accounting mode changed while a was open")
+	}
+	if warning := captured.String(); !strings.Contains(warning, `while anchor "a" is open`) {
+		t.Errorf("warning %q doesn't name the open anchor", warning)
+	}
+}
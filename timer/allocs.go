@@ -0,0 +1,39 @@
+package timer
+
+import "runtime"
+
+// allocCounters are the cumulative heap allocation counters of the process.
+type allocCounters struct {
+	mallocs    uint64
+	allocBytes uint64
+}
+
+func readAllocCounters() allocCounters {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	return allocCounters{
+		mallocs:    memStats.Mallocs,
+		allocBytes: memStats.TotalAlloc,
+	}
+}
+
+/*
+SetAllocStats enables counting the heap allocations made between Start and
+Stop of each anchor, reported as allocs columns in Output. Counts include the
+allocations of children anchors and of any other goroutine running meanwhile.
+
+This is expensive: runtime.ReadMemStats briefly stops the world on each Start
+and Stop, which inflates the measured times. Disabled by default.
+*/
+func (p *Profiler) SetAllocStats(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.allocStats = enabled
+}
+
+// SetAllocStats enables allocation counting on the default profiler.
+func SetAllocStats(enabled bool) {
+	defaultProfiler.SetAllocStats(enabled)
+}
@@ -0,0 +1,52 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+var allocSink [][]byte
+
+func TestAllocStats(t *testing.T) {
+	var tests = []struct {
+		name    string
+		enabled bool
+	}{
+		{"disabled", false},
+		{"enabled", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			p.SetAllocStats(test.enabled)
+
+			p.Start("parent")
+			p.Start("allocating")
+			for i := 0; i < 100; i++ {
+				allocSink = append(allocSink, make([]byte, 1024))
+			}
+			p.Stop("allocating")
+			p.Stop("parent")
+			allocSink = nil
+
+			var report = p.Snapshot()
+			for _, name := range []string{"allocating", "parent"} {
+				// Inclusive of the children
+				var result = resultOf(t, report, name)
+				if test.enabled && (result.Allocs < 100 || result.AllocBytes < 100*1024) {
+					t.Errorf("%s: %d allocs of %d bytes, want at least 100 of 100KiB", name, result.Allocs,
+						result.AllocBytes)
+				}
+				if !test.enabled && (result.Allocs != 0 || result.AllocBytes != 0) {
+					t.Errorf("%s: %d allocs counted while disabled", name, result.Allocs)
+				}
+			}
+			if text := output(p); strings.Contains(text, "allocs:") != test.enabled {
+				t.Errorf("allocs column printed = %v, want %v:\n%s", !test.enabled, test.enabled, text)
+			}
+		})
+	}
+}
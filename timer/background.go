@@ -0,0 +1,64 @@
+package timer
+
+import "sync/atomic"
+
+// Rough CPU timer frequency used until the background calibration completes
+const provisionalCPUFrequency = 3000000000
+
+var (
+	// Non-zero when SetBackgroundCalibration is enabled
+	backgroundCalibration int32
+
+	// Non-zero while cpuFrequency holds provisionalCPUFrequency
+	provisionalCalibration int32
+
+	// Incremented by InvalidateCalibration, so that a background estimation
+	// started before is discarded
+	calibrationGeneration int64
+)
+
+/*
+SetBackgroundCalibration makes the first Start, or Calibrate, return at once
+instead of busy-waiting for the frequency estimation: the estimation runs in a
+background goroutine, the times meanwhile being converted with a rough
+frequency of 3GHz. Reported times switch to the estimated frequency as soon
+as it is known, recorded CPU timer units being kept as is, so reports taken
+during the first 50ms or so are approximate, which the calibration header
+line of Output tells. It only affects calibrations to come.
+*/
+func SetBackgroundCalibration(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&backgroundCalibration, 1)
+	} else {
+		atomic.StoreInt32(&backgroundCalibration, 0)
+	}
+}
+
+// calibrateInBackground sets the provisional frequency and starts the
+// estimation, with calibrationMutex held.
+func calibrateInBackground() {
+	atomic.StoreInt64(&cpuFrequency, provisionalCPUFrequency)
+	atomic.StoreInt32(&provisionalCalibration, 1)
+	var generation = calibrationGeneration
+
+	go func() {
+		var frequency = estimateCPUTimerFreq()
+
+		calibrationMutex.Lock()
+		defer calibrationMutex.Unlock()
+
+		if generation != calibrationGeneration || frequency == 0 {
+			// Invalidated meanwhile, or nothing measured
+			return
+		}
+
+		atomic.StoreInt64(&cpuFrequency, frequency)
+		atomic.StoreInt32(&provisionalCalibration, 0)
+	}()
+}
+
+// calibrationIsProvisional reports whether the background estimation is
+// still running.
+func calibrationIsProvisional() bool {
+	return atomic.LoadInt32(&provisionalCalibration) != 0
+}
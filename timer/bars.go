@@ -0,0 +1,96 @@
+package timer
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Partial blocks, by eighths of a character
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// Bars take a quarter of the terminal, up to a maximum, and are left out of
+// terminals narrower than the minimum
+const (
+	maxBarWidth = 40
+	minBarWidth = 8
+)
+
+/*
+SetProportionBars makes Output draw a bar next to the percentage of each
+anchor, proportional to its share of the total, making the hot anchors stand
+out at a glance. Bars take a quarter of the terminal width. They are only
+drawn when writing to a terminal whose width is known, from the COLUMNS
+environment variable or queried from the terminal itself, the report falling
+back to plain percentages otherwise, e.g. when redirected to a file.
+*/
+func (p *Profiler) SetProportionBars(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.proportionBars = enabled
+}
+
+// barWidth returns the width of the bars to draw when writing to w, zero for
+// none.
+func (p *Profiler) barWidth(w io.Writer) int {
+	if !p.proportionBars {
+		return 0
+	}
+
+	var file *os.File
+	switch destination := w.(type) {
+	case *os.File:
+		file = destination
+	case *reportBuffer:
+		return p.barWidth(destination.destination)
+	default:
+		return 0
+	}
+
+	if info, err := file.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		// Redirected to a file or a pipe
+		return 0
+	}
+
+	var columns, err = strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil || columns <= 0 {
+		columns = ttyColumns(file.Fd())
+	}
+
+	var width = columns / 4
+	if width > maxBarWidth {
+		width = maxBarWidth
+	}
+	if width < minBarWidth {
+		return 0
+	}
+
+	return width
+}
+
+// formatBar draws part of whole as a bar padded to width characters, with an
+// eighth of a character resolution.
+func formatBar(part int64, whole int64, width int) string {
+	var eighths int
+	if whole > 0 && part > 0 {
+		eighths = int(float64(part) / float64(whole) * float64(width*8))
+	}
+	if eighths > width*8 {
+		eighths = width * 8
+	}
+
+	var bar = strings.Repeat("█", eighths/8) + barEighths[eighths%8]
+	var length = eighths / 8
+	if eighths%8 != 0 {
+		length = length + 1
+	}
+
+	return " " + bar + strings.Repeat(" ", width-length)
+}
+
+// SetProportionBars makes the default profiler Output draw proportion bars.
+func SetProportionBars(enabled bool) {
+	defaultProfiler.SetProportionBars(enabled)
+}
@@ -0,0 +1,90 @@
+package timer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatBar(t *testing.T) {
+	var tests = []struct {
+		part, whole int64
+		width       int
+		want        string
+	}{
+		{0, 100, 8, "         "},
+		{100, 100, 8, " ████████"},
+		{50, 100, 8, " ████    "},
+		{1, 64, 8, " ▏       "},
+		{42, 100, 10, " ████▏     "},
+		{200, 100, 8, " ████████"},
+		{10, 0, 8, "         "},
+	}
+
+	for _, test := range tests {
+		if got := formatBar(test.part, test.whole, test.width); got != test.want {
+			t.Errorf("formatBar(%d, %d, %d) = %q, want %q", test.part, test.whole, test.width, got, test.want)
+		}
+	}
+}
+
+func TestBarWidth(t *testing.T) {
+	// A character device, like a terminal
+	var device, err = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer device.Close()
+
+	var file *os.File
+	file, err = os.Create(filepath.Join(t.TempDir(), "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var tests = []struct {
+		name    string
+		enabled bool
+		w       io.Writer
+		columns string
+		want    int
+	}{
+		{"disabled", false, device, "80", 0},
+		{"terminal", true, device, "80", 20},
+		{"wide terminal", true, device, "400", maxBarWidth},
+		{"narrow terminal", true, device, "20", 0},
+		{"regular file", true, file, "80", 0},
+		{"buffer", true, &bytes.Buffer{}, "80", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("COLUMNS", test.columns)
+			var p = New()
+			p.SetProportionBars(test.enabled)
+
+			if got := p.barWidth(test.w); got != test.want {
+				t.Errorf("bar width %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestProportionBarsFallback(t *testing.T) {
+	var clock = useFakeClock(t)
+	t.Setenv("COLUMNS", "80")
+	var p = New()
+	p.Start("a")
+	clock.advance(1000)
+	p.Stop("a")
+
+	var plain = output(p)
+	p.SetProportionBars(true)
+	if text := output(p); text != plain || strings.Contains(text, "█") {
+		t.Errorf("bars drawn outside of a terminal:\n%s", text)
+	}
+}
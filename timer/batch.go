@@ -0,0 +1,46 @@
+package timer
+
+/*
+StartAll starts the named anchors in order, as many Start calls would, each
+anchor being nested in the previous one. The environment check and the lock
+are done once for the whole batch.
+*/
+func (p *Profiler) StartAll(anchorNames []string) {
+	if profilingDisabled() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, anchorName := range anchorNames {
+		warnError(p.start(anchorName, 0))
+	}
+}
+
+/*
+StopAll stops the named anchors in order, as many Stop calls would: anchors
+opened by StartAll are stopped by passing their names in reverse order.
+*/
+func (p *Profiler) StopAll(anchorNames []string) {
+	if profilingDisabled() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, anchorName := range anchorNames {
+		warnError(p.countUnmatched(p.stop(anchorName, readCPUTimer())))
+	}
+}
+
+// StartAll starts several anchors of the default profiler in order.
+func StartAll(anchorNames []string) {
+	defaultProfiler.StartAll(anchorNames)
+}
+
+// StopAll stops several anchors of the default profiler in order.
+func StopAll(anchorNames []string) {
+	defaultProfiler.StopAll(anchorNames)
+}
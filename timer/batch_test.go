@@ -0,0 +1,51 @@
+package timer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStartStopAll(t *testing.T) {
+	var names = []string{"a", "b", "c"}
+	var reversed = []string{"c", "b", "a", "unknown"}
+
+	var run = func(batched bool) (Report, string) {
+		var clock = useFakeClock(t)
+		var captured = captureWarnings(t)
+		var p = New()
+
+		if batched {
+			p.StartAll(names)
+		} else {
+			for _, name := range names {
+				p.Start(name)
+			}
+		}
+		clock.advance(100)
+		if batched {
+			p.StopAll(reversed)
+		} else {
+			for _, name := range reversed {
+				p.Stop(name)
+			}
+		}
+
+		var report = p.Snapshot()
+		report.Meta.Generated = time.Time{}
+		return report, captured.String()
+	}
+
+	// Same as issuing the calls one by one
+	var report, warning = run(true)
+	var want, wantWarning = run(false)
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("got %+v\nwant %+v", report, want)
+	}
+	if warning != wantWarning {
+		t.Errorf("warning %q, want %q", warning, wantWarning)
+	}
+	if result := resultOf(t, report, "c"); result.Depth != 2 || result.TSCount != 100 {
+		t.Errorf("c at depth %d with %d units, want 2 and 100", result.Depth, result.TSCount)
+	}
+}
@@ -0,0 +1,49 @@
+package timer
+
+import "bytes"
+
+// BenchmarkTB is the part of testing.TB used by BenchmarkReport.
+type BenchmarkTB interface {
+	Helper()
+	Log(args ...interface{})
+	Cleanup(func())
+}
+
+/*
+BenchmarkReport profiles a benchmark: it resets the profiler and calibrates
+the CPU timer, so call it before b.ResetTimer, and logs the report with tb.Log
+once the benchmark function returns, the anchors accumulating over the b.N
+iterations in between. The benchmark function being run once per tried b.N,
+a report is logged for each.
+
+For the throughput of Output to match the MB/s of the benchmark, start the
+anchor covering an iteration with StartThroughput and the bytes given to
+b.SetBytes, and select DecimalUnits: the total throughput line then divides
+the same bytes by the same time, in the 10^6 bytes per MB of the benchmark.
+*/
+func (p *Profiler) BenchmarkReport(tb BenchmarkTB) {
+	tb.Helper()
+
+	p.Reset()
+	Calibrate()
+
+	tb.Cleanup(func() {
+		tb.Helper()
+		if profilingDisabled() {
+			return
+		}
+
+		var report bytes.Buffer
+		p.mu.Lock()
+		p.write(&report, writeOptions{})
+		p.mu.Unlock()
+
+		tb.Log(report.String())
+	})
+}
+
+// BenchmarkReport profiles a benchmark with the default profiler.
+func BenchmarkReport(tb BenchmarkTB) {
+	tb.Helper()
+	defaultProfiler.BenchmarkReport(tb)
+}
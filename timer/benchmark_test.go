@@ -0,0 +1,89 @@
+package timer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeBenchmark records what BenchmarkReport logs.
+type fakeBenchmark struct {
+	logs     []string
+	cleanups []func()
+}
+
+func (b *fakeBenchmark) Helper() {}
+
+func (b *fakeBenchmark) Log(args ...interface{}) {
+	b.logs = append(b.logs, fmt.Sprint(args...))
+}
+
+func (b *fakeBenchmark) Cleanup(f func()) {
+	b.cleanups = append(b.cleanups, f)
+}
+
+// finish runs the cleanups, as testing does once the benchmark returns.
+func (b *fakeBenchmark) finish() {
+	for i := len(b.cleanups) - 1; i >= 0; i-- {
+		b.cleanups[i]()
+	}
+}
+
+func TestBenchmarkReport(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.SetByteUnits(DecimalUnits)
+
+	// Left over from before the benchmark
+	p.Start("setup")
+	clock.advance(1000)
+	p.Stop("setup")
+
+	var b fakeBenchmark
+	p.BenchmarkReport(&b)
+	// b.N iterations of 1ms each processing the 2MB of b.SetBytes
+	const iterations, bytesPerIteration = 5, 2000000
+	for i := 0; i < iterations; i++ {
+		p.StartThroughput("iteration", bytesPerIteration)
+		clock.advance(testFrequency / 1000)
+		p.Stop("iteration")
+	}
+
+	if len(b.logs) != 0 {
+		t.Fatalf("logged %q before the benchmark returned", b.logs)
+	}
+	b.finish()
+	if len(b.logs) != 1 {
+		t.Fatalf("logged %d reports, want 1", len(b.logs))
+	}
+
+	var report = b.logs[0]
+	if strings.Contains(report, "setup") {
+		t.Errorf("the report kept the anchors from before the benchmark:\n%s", report)
+	}
+	if !strings.Contains(report, "calls: 5") {
+		t.Errorf("the report doesn't count the %d iterations:\n%s", iterations, report)
+	}
+	// 2000 MB/s for the benchmark
+	if !strings.Contains(report, "2.000GB/s") {
+		t.Errorf("the report doesn't tell the 2000MB/s of the benchmark:\n%s", report)
+	}
+}
+
+func BenchmarkProfiledLoop(b *testing.B) {
+	var p = New()
+	p.SetByteUnits(DecimalUnits)
+	p.BenchmarkReport(b)
+	b.SetBytes(1024)
+	b.ResetTimer()
+
+	var sum int
+	for i := 0; i < b.N; i++ {
+		p.StartThroughput("iteration", 1024)
+		for j := 0; j < 1024; j++ {
+			sum = sum + j
+		}
+		p.Stop("iteration")
+	}
+	_ = sum
+}
@@ -0,0 +1,475 @@
+package timer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// Leading bytes of the binary format, followed by its version.
+const binaryMagic = "GTP"
+
+// Version 2 added the parent names and the fields reported since then, version
+// 3 the run metadata, version 4 the generation time of the report and version
+// 5 every remaining field of the report, in a new layout
+const binaryVersion = 5
+
+// First version of the current layout, the earlier ones being decoded by
+// legacyReport
+const binaryLayoutVersion = 5
+
+// Longest string ReadBinary accepts, far beyond any anchor name or metadata
+// value, so that a corrupted length can't make it allocate gigabytes
+const binaryMaxStringLength = 1 << 20
+
+// ErrInvalidFormat is returned by ReadBinary when the data isn't a profile
+// or uses an unsupported version of the format.
+var ErrInvalidFormat = errors.New("invalid profile format")
+
+type binaryWriter struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (bw *binaryWriter) varint(value int64) {
+	var n = binary.PutVarint(bw.buf[:], value)
+	bw.w.Write(bw.buf[:n])
+}
+
+func (bw *binaryWriter) float(value float64) {
+	binary.LittleEndian.PutUint64(bw.buf[:8], math.Float64bits(value))
+	bw.w.Write(bw.buf[:8])
+}
+
+func (bw *binaryWriter) flag(value bool) {
+	if value {
+		bw.w.WriteByte(1)
+	} else {
+		bw.w.WriteByte(0)
+	}
+}
+
+func (bw *binaryWriter) string(value string) {
+	bw.varint(int64(len(value)))
+	bw.w.WriteString(value)
+}
+
+func (bw *binaryWriter) result(result AnchorResult) {
+	bw.string(result.Name)
+	bw.varint(result.Depth)
+	bw.string(result.ParentName)
+	bw.string(result.Category)
+	bw.flag(result.ExcludedFromTotal)
+
+	bw.varint(result.Hits)
+	bw.varint(result.TSCount)
+	bw.varint(result.Bytes)
+	bw.varint(result.Counted)
+	bw.varint(result.SampleRate)
+
+	bw.float(result.BytesPerHit)
+	bw.varint(result.MinBytes)
+	bw.varint(result.MaxBytes)
+	bw.float(result.HitThroughput)
+	bw.varint(result.Ops)
+	bw.float(result.OpsPerSecond)
+
+	bw.float(result.Elapsed)
+	bw.float(result.Percent)
+	bw.float(result.PercentOfParent)
+	bw.float(result.PercentOfReference)
+
+	bw.float(result.Mean)
+	bw.float(result.MinHit)
+	bw.float(result.MaxHit)
+	bw.float(result.CyclesPerHit)
+
+	bw.varint(result.Allocs)
+	bw.varint(result.AllocBytes)
+	bw.varint(result.OverBudget)
+	bw.varint(int64(result.Budget))
+	bw.flag(result.Open)
+	bw.flag(result.BelowResolution)
+
+	bw.float(result.StdDev)
+	bw.float(result.CV)
+	bw.float(result.P50)
+	bw.float(result.P90)
+	bw.float(result.P99)
+
+	bw.varint(int64(result.Wall))
+	bw.varint(result.Samples)
+	bw.float(result.Blocked)
+	bw.varint(int64(result.Goroutines))
+	bw.varint(result.MaxRecursion)
+}
+
+func (bw *binaryWriter) results(results []AnchorResult) {
+	bw.varint(int64(len(results)))
+	for _, result := range results {
+		bw.result(result)
+	}
+}
+
+/*
+WriteBinary encodes the report in a compact binary format, smaller and faster
+to parse than JSON, for instance to ship profiles to a collector merging them.
+Every field of the report is kept, so that ReadBinary returns the same report.
+The format starts with a magic string and a version byte, checked by
+ReadBinary so that future versions stay distinguishable; ReadBinary still
+decodes the reports of earlier versions, which lack some of the fields.
+*/
+func WriteBinary(w io.Writer, report Report) error {
+	var bw = binaryWriter{w: bufio.NewWriter(w)}
+
+	bw.w.WriteString(binaryMagic)
+	bw.w.WriteByte(binaryVersion)
+
+	bw.string(report.Name)
+	bw.varint(report.CPUFrequency)
+	bw.varint(report.NormalizedFrequency)
+
+	bw.string(report.Runtime.GoVersion)
+	bw.string(report.Runtime.GOOS)
+	bw.string(report.Runtime.GOARCH)
+	bw.varint(int64(report.Runtime.GOMAXPROCS))
+	bw.varint(int64(report.Runtime.NumCPU))
+
+	bw.varint(int64(len(report.Metadata)))
+	for _, key := range sortedKeys(report.Metadata) {
+		bw.string(key)
+		bw.string(report.Metadata[key])
+	}
+
+	var generated int64
+	if !report.Meta.Generated.IsZero() {
+		generated = report.Meta.Generated.UnixNano()
+	}
+	bw.varint(generated)
+
+	bw.result(report.Total)
+	bw.results(report.Anchors)
+
+	bw.varint(report.Hits)
+	bw.varint(report.Bytes)
+	bw.float(report.Throughput)
+	bw.varint(report.Ops)
+	bw.float(report.OpsPerSecond)
+	bw.varint(report.GCCount)
+	bw.varint(int64(report.GCPause))
+	bw.varint(report.ClockAnomalies)
+	bw.varint(report.UnmatchedStops)
+	bw.varint(report.MaxDepth)
+	bw.flag(report.LimitReached)
+
+	bw.varint(int64(len(report.Phases)))
+	for _, phase := range report.Phases {
+		bw.string(phase.Name)
+		bw.varint(phase.TSCount)
+		bw.float(phase.Elapsed)
+		bw.float(phase.Percent)
+		bw.results(phase.Anchors)
+	}
+
+	return bw.w.Flush()
+}
+
+type binaryReader struct {
+	r       *bufio.Reader
+	version byte
+	err     error
+}
+
+func (br *binaryReader) varint() int64 {
+	if br.err != nil {
+		return 0
+	}
+
+	var value int64
+	value, br.err = binary.ReadVarint(br.r)
+	return value
+}
+
+func (br *binaryReader) float() float64 {
+	if br.err != nil {
+		return 0
+	}
+
+	var buf [8]byte
+	_, br.err = io.ReadFull(br.r, buf[:])
+	return math.Float64frombits(binary.LittleEndian.Uint64(buf[:]))
+}
+
+func (br *binaryReader) flag() bool {
+	if br.err != nil {
+		return false
+	}
+
+	var value byte
+	value, br.err = br.r.ReadByte()
+	return value != 0
+}
+
+func (br *binaryReader) string() string {
+	var length = br.varint()
+	if br.err != nil {
+		return ""
+	}
+
+	if length < 0 || length > binaryMaxStringLength {
+		br.err = ErrInvalidFormat
+		return ""
+	}
+
+	// Grows with the data actually read rather than the announced length
+	var builder strings.Builder
+	_, br.err = io.CopyN(&builder, br.r, length)
+	return builder.String()
+}
+
+// count reads the number of elements of a list.
+func (br *binaryReader) count() int64 {
+	var count = br.varint()
+	if br.err == nil && (count < 0 || count > math.MaxInt32) {
+		br.err = ErrInvalidFormat
+	}
+
+	return count
+}
+
+func (br *binaryReader) result() AnchorResult {
+	return AnchorResult{
+		Name:              br.string(),
+		Depth:             br.varint(),
+		ParentName:        br.string(),
+		Category:          br.string(),
+		ExcludedFromTotal: br.flag(),
+
+		Hits:       br.varint(),
+		TSCount:    br.varint(),
+		Bytes:      br.varint(),
+		Counted:    br.varint(),
+		SampleRate: br.varint(),
+
+		BytesPerHit:   br.float(),
+		MinBytes:      br.varint(),
+		MaxBytes:      br.varint(),
+		HitThroughput: br.float(),
+		Ops:           br.varint(),
+		OpsPerSecond:  br.float(),
+
+		Elapsed:            br.float(),
+		Percent:            br.float(),
+		PercentOfParent:    br.float(),
+		PercentOfReference: br.float(),
+
+		Mean:         br.float(),
+		MinHit:       br.float(),
+		MaxHit:       br.float(),
+		CyclesPerHit: br.float(),
+
+		Allocs:          br.varint(),
+		AllocBytes:      br.varint(),
+		OverBudget:      br.varint(),
+		Budget:          time.Duration(br.varint()),
+		Open:            br.flag(),
+		BelowResolution: br.flag(),
+
+		StdDev: br.float(),
+		CV:     br.float(),
+		P50:    br.float(),
+		P90:    br.float(),
+		P99:    br.float(),
+
+		Wall:         time.Duration(br.varint()),
+		Samples:      br.varint(),
+		Blocked:      br.float(),
+		Goroutines:   int(br.varint()),
+		MaxRecursion: br.varint(),
+	}
+}
+
+func (br *binaryReader) results() []AnchorResult {
+	var results []AnchorResult
+	var count = br.count()
+	for i := int64(0); i < count && br.err == nil; i++ {
+		results = append(results, br.result())
+	}
+
+	return results
+}
+
+// legacyResult decodes an anchor result of the layout of the versions before
+// binaryLayoutVersion.
+func (br *binaryReader) legacyResult() AnchorResult {
+	var result = AnchorResult{
+		Name:         br.string(),
+		Depth:        br.varint(),
+		Hits:         br.varint(),
+		TSCount:      br.varint(),
+		Bytes:        br.varint(),
+		Elapsed:      br.float(),
+		Percent:      br.float(),
+		CyclesPerHit: br.float(),
+		Allocs:       br.varint(),
+		AllocBytes:   br.varint(),
+		Open:         br.flag(),
+		MaxRecursion: br.varint(),
+	}
+
+	if br.version >= 2 {
+		result.ParentName = br.string()
+		result.Category = br.string()
+		result.StdDev = br.float()
+		result.CV = br.float()
+		result.Wall = time.Duration(br.varint())
+	}
+
+	return result
+}
+
+// legacyReport decodes a report of the layout of the versions before
+// binaryLayoutVersion.
+func (br *binaryReader) legacyReport() Report {
+	var report Report
+	report.Name = br.string()
+	report.CPUFrequency = br.varint()
+	report.GCCount = br.varint()
+	report.GCPause = time.Duration(br.varint())
+	report.LimitReached = br.flag()
+	if br.version >= 2 {
+		report.ClockAnomalies = br.varint()
+		report.Runtime = RuntimeInfo{
+			GoVersion:  br.string(),
+			GOOS:       br.string(),
+			GOARCH:     br.string(),
+			GOMAXPROCS: int(br.varint()),
+			NumCPU:     int(br.varint()),
+		}
+	}
+
+	if br.version >= 3 {
+		var entries = br.count()
+		for i := int64(0); i < entries && br.err == nil; i++ {
+			if report.Metadata == nil {
+				report.Metadata = make(map[string]string)
+			}
+			var key = br.string()
+			report.Metadata[key] = br.string()
+		}
+	}
+
+	report.Total = br.legacyResult()
+	var count = br.count()
+	for i := int64(0); i < count && br.err == nil; i++ {
+		report.Anchors = append(report.Anchors, br.legacyResult())
+	}
+
+	if br.version >= 4 {
+		if generated := br.varint(); generated != 0 {
+			report.Meta.Generated = time.Unix(0, generated)
+		}
+	}
+
+	return report
+}
+
+func (br *binaryReader) report() Report {
+	var report Report
+	report.Name = br.string()
+	report.CPUFrequency = br.varint()
+	report.NormalizedFrequency = br.varint()
+	report.Runtime = RuntimeInfo{
+		GoVersion:  br.string(),
+		GOOS:       br.string(),
+		GOARCH:     br.string(),
+		GOMAXPROCS: int(br.varint()),
+		NumCPU:     int(br.varint()),
+	}
+
+	var entries = br.count()
+	for i := int64(0); i < entries && br.err == nil; i++ {
+		if report.Metadata == nil {
+			report.Metadata = make(map[string]string)
+		}
+		var key = br.string()
+		report.Metadata[key] = br.string()
+	}
+
+	if generated := br.varint(); generated != 0 {
+		report.Meta.Generated = time.Unix(0, generated)
+	}
+
+	report.Total = br.result()
+	report.Anchors = br.results()
+
+	report.Hits = br.varint()
+	report.Bytes = br.varint()
+	report.Throughput = br.float()
+	report.Ops = br.varint()
+	report.OpsPerSecond = br.float()
+	report.GCCount = br.varint()
+	report.GCPause = time.Duration(br.varint())
+	report.ClockAnomalies = br.varint()
+	report.UnmatchedStops = br.varint()
+	report.MaxDepth = br.varint()
+	report.LimitReached = br.flag()
+
+	var phases = br.count()
+	for i := int64(0); i < phases && br.err == nil; i++ {
+		report.Phases = append(report.Phases, PhaseResult{
+			Name:    br.string(),
+			TSCount: br.varint(),
+			Elapsed: br.float(),
+			Percent: br.float(),
+			Anchors: br.results(),
+		})
+	}
+
+	return report
+}
+
+/*
+ReadBinary decodes a report written by WriteBinary. The reader is buffered,
+so it may consume data past the end of the report.
+*/
+func ReadBinary(r io.Reader) (Report, error) {
+	var br = binaryReader{r: bufio.NewReader(r)}
+
+	var header = make([]byte, len(binaryMagic)+1)
+	if _, err := io.ReadFull(br.r, header); err != nil {
+		return Report{}, err
+	}
+
+	if string(header[:len(binaryMagic)]) != binaryMagic {
+		return Report{}, ErrInvalidFormat
+	}
+
+	br.version = header[len(binaryMagic)]
+	if br.version < 1 || br.version > binaryVersion {
+		return Report{}, fmt.Errorf("%w: version %d", ErrInvalidFormat, br.version)
+	}
+
+	var report Report
+	if br.version < binaryLayoutVersion {
+		report = br.legacyReport()
+	} else {
+		report = br.report()
+	}
+	report.updateMeta()
+
+	if br.err == io.EOF {
+		br.err = io.ErrUnexpectedEOF
+	}
+
+	if br.err != nil {
+		return Report{}, br.err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,225 @@
+package timer
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fill sets every field of v to a distinct non-zero value, from *seed on, so
+// that a format dropping a field shows in a comparison.
+func fill(v reflect.Value, seed *int64) {
+	*seed = *seed + 1
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("s" + time.Duration(*seed).String())
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int64:
+		v.SetInt(*seed)
+	case reflect.Float64:
+		v.SetFloat(float64(*seed) + 0.5)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Unix(1700000000, *seed)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			fill(v.Field(i), seed)
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 2, 2))
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i), seed)
+		}
+	case reflect.Map:
+		var key, value = reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+		fill(key, seed)
+		fill(value, seed)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(key, value)
+	default:
+		panic("fill: unhandled kind " + v.Kind().String())
+	}
+}
+
+// filledReport returns a report with every field set.
+func filledReport() Report {
+	var report Report
+	var seed int64
+	fill(reflect.ValueOf(&report).Elem(), &seed)
+	// Derived from the rest of the report when decoded
+	report.updateMeta()
+
+	return report
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	var clock = useFakeClock(t)
+	var profiled = profileWith(clock, map[string]int64{"a": 1000, "b": 2000}, []string{"a", "b"}).Snapshot()
+
+	var tests = []struct {
+		name   string
+		report Report
+	}{
+		{"empty", Report{}},
+		{"every field set", filledReport()},
+		{"profiled", profiled},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := WriteBinary(&buffer, test.report); err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded, err = ReadBinary(&buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want = test.report
+			want.updateMeta()
+			if !decoded.Meta.Generated.Equal(want.Meta.Generated) {
+				t.Errorf("generated %v, want %v", decoded.Meta.Generated, want.Meta.Generated)
+			}
+			decoded.Meta.Generated, want.Meta.Generated = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(decoded, want) {
+				t.Errorf("decoded\n%+v\nwant\n%+v", decoded, want)
+			}
+		})
+	}
+}
+
+func TestReadBinaryEarlierVersions(t *testing.T) {
+	var anchors = []AnchorResult{
+		{Name: "parent", Hits: 2, TSCount: 1000, Elapsed: 0.001, Percent: 33.3, CyclesPerHit: 500},
+		{Name: "child", Depth: 1, Hits: 4, TSCount: 2000, Elapsed: 0.002, Percent: 66.6, CyclesPerHit: 500,
+			MaxRecursion: 2},
+	}
+	var v4Anchors = append([]AnchorResult(nil), anchors...)
+	v4Anchors[1].ParentName = "parent"
+	v4Anchors[1].Category = "io"
+
+	var tests = []struct {
+		file      string
+		anchors   []AnchorResult
+		metadata  map[string]string
+		generated time.Time
+	}{
+		{"testdata/report-v1.bin", anchors, nil, time.Time{}},
+		{"testdata/report-v4.bin", v4Anchors, map[string]string{"commit": "abc123"}, time.Unix(1700000000, 0)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			var data, err = os.ReadFile(test.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := ReadBinary(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Name != "legacy" || report.CPUFrequency != 1000000000 || report.GCCount != 3 {
+				t.Errorf("header %q, %dHz, %d collections", report.Name, report.CPUFrequency, report.GCCount)
+			}
+			if report.Total.TSCount != 3000 {
+				t.Errorf("total tscount = %d, want 3000", report.Total.TSCount)
+			}
+			if !reflect.DeepEqual(report.Anchors, test.anchors) {
+				t.Errorf("anchors\n%+v\nwant\n%+v", report.Anchors, test.anchors)
+			}
+			if !reflect.DeepEqual(report.Metadata, test.metadata) {
+				t.Errorf("metadata %v, want %v", report.Metadata, test.metadata)
+			}
+			if !report.Meta.Generated.Equal(test.generated) {
+				t.Errorf("generated %v, want %v", report.Meta.Generated, test.generated)
+			}
+		})
+	}
+}
+
+func TestReadBinaryInvalid(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteBinary(&buffer, filledReport()); err != nil {
+		t.Fatal(err)
+	}
+	var valid = buffer.Bytes()
+
+	var tests = []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"wrong magic", []byte("GTX\x05"), ErrInvalidFormat},
+		{"version zero", []byte(binaryMagic + "\x00"), ErrInvalidFormat},
+		{"future version", []byte{'G', 'T', 'P', binaryVersion + 1}, ErrInvalidFormat},
+		// A name of 2^62 bytes
+		{"huge string", append([]byte{'G', 'T', 'P', binaryVersion}, 0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+			0xff, 0x7f), ErrInvalidFormat},
+		// Empty header fields followed by -1 metadata entries
+		{"negative count", append([]byte{'G', 'T', 'P', binaryVersion}, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x01), ErrInvalidFormat},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadBinary(bytes.NewReader(test.data)); !errors.Is(err, test.want) {
+				t.Errorf("ReadBinary = %v, want %v", err, test.want)
+			}
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		for length := 0; length < len(valid); length++ {
+			if _, err := ReadBinary(bytes.NewReader(valid[:length])); err == nil {
+				t.Fatalf("ReadBinary succeeded on the first %d of %d bytes", length, len(valid))
+			}
+		}
+	})
+}
+
+func TestParentNames(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	for _, path := range [][]string{{"a", "b", "c"}, {"d", "b"}, {"a", "e"}} {
+		for _, name := range path {
+			p.Start(name)
+			clock.advance(10)
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			p.Stop(path[i])
+		}
+	}
+
+	// b keeps the parent it was first started in
+	var want = map[string]string{"a": "", "b": "a", "c": "b", "d": "", "e": "a"}
+	var report = p.Snapshot()
+
+	var buffer bytes.Buffer
+	if err := WriteBinary(&buffer, report); err != nil {
+		t.Fatal(err)
+	}
+	var decoded, err = ReadBinary(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []Report{report, decoded} {
+		var parents = make(map[string]string)
+		for _, result := range r.Anchors {
+			parents[result.Name] = result.ParentName
+		}
+		if !reflect.DeepEqual(parents, want) {
+			t.Errorf("parents %v, want %v", parents, want)
+		}
+	}
+}
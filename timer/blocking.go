@@ -0,0 +1,99 @@
+package timer
+
+/*
+BlockStart marks the innermost open anchor as blocked, e.g. right before
+waiting on a channel or a lock, until BlockEnd. The time in between is kept
+apart as the blocked time of the anchor, shown next to its CPU time by
+Output, instead of being counted as its own time: a stage contending on a
+lock then shows little CPU time and a large blocked time, where a compute
+bound stage shows none. Like the time outside of any anchor, blocked time is
+part of the unaccounted line of the total.
+
+Starting or stopping an anchor while blocked ends the block first. BlockStart
+with no anchor open, or while already blocked, is ignored with a warning.
+*/
+func (p *Profiler) BlockStart() {
+	if profilingDisabled() {
+		return
+	}
+
+	var now = readCPUTimer()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var blocking = p.currentTiming
+	if blocking == nil {
+		warn("timer: BlockStart with no anchor open")
+		return
+	}
+
+	if blocking.blockStart != 0 {
+		warn("timer: BlockStart while %q is already blocked", blocking.anchor.name)
+		return
+	}
+
+	if p.accounting == AccountingExclusive && !blocking.warmup {
+		// Settle the time run so far, as when a child is started
+		var running = p.repaySampled(blocking, now-blocking.start)
+		p.accumulate(blocking.anchor, running, now)
+		blocking.own = blocking.own + nonNegative(running)
+	}
+
+	blocking.blockStart = now
+}
+
+// BlockEnd ends the block of the innermost open anchor started by BlockStart,
+// resuming its own time. It is ignored with a warning if it isn't blocked.
+func (p *Profiler) BlockEnd() {
+	if profilingDisabled() {
+		return
+	}
+
+	var now = readCPUTimer()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.currentTiming == nil || p.currentTiming.blockStart == 0 {
+		warn("timer: BlockEnd without BlockStart")
+		return
+	}
+
+	p.endBlock(p.currentTiming, now)
+}
+
+// endBlock records the blocked time of a timing marked by BlockStart.
+func (p *Profiler) endBlock(blocked *timing, now int64) {
+	var tscount = nonNegative(now - blocked.blockStart)
+	blocked.blockStart = 0
+
+	if p.accounting == AccountingExclusive {
+		blocked.start = now
+	}
+
+	if blocked.warmup {
+		return
+	}
+
+	var anchor = blocked.anchor
+	anchor.blocked = anchor.blocked + tscount
+
+	if p.accounting == AccountingInclusive {
+		// Subtracted from the own time like the time of a child
+		anchor.childInclusive = anchor.childInclusive + tscount
+		p.settleOwn(anchor)
+	}
+}
+
+// BlockStart marks the innermost open anchor of the default profiler as
+// blocked.
+func BlockStart() {
+	defaultProfiler.BlockStart()
+}
+
+// BlockEnd ends the block of the innermost open anchor of the default
+// profiler.
+func BlockEnd() {
+	defaultProfiler.BlockEnd()
+}
@@ -0,0 +1,85 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBlockStartEnd(t *testing.T) {
+	const ms = testFrequency / 1000
+
+	var tests = []struct {
+		name string
+		mode AccountingMode
+		end  func(p *Profiler)
+	}{
+		{"exclusive", AccountingExclusive, func(p *Profiler) { p.BlockEnd() }},
+		{"inclusive", AccountingInclusive, func(p *Profiler) { p.BlockEnd() }},
+		// Stopping while blocked ends the block first
+		{"stopped while blocked", AccountingExclusive, func(p *Profiler) {}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var captured = captureWarnings(t)
+			var p = New()
+			p.SetAccountingMode(test.mode)
+
+			p.Start("stage")
+			clock.advance(2 * ms)
+			p.BlockStart()
+			clock.advance(5 * ms)
+			test.end(p)
+			p.Stop("stage")
+
+			var report = p.Snapshot()
+			var stage = resultOf(t, report, "stage")
+			if stage.Elapsed != 2 || stage.Blocked != 5 {
+				t.Errorf("%vms of CPU time, %vms blocked, want 2ms and 5ms", stage.Elapsed, stage.Blocked)
+			}
+			// Part of the unaccounted time
+			if unaccounted := p.Unaccounted(); unaccounted != 5*time.Millisecond {
+				t.Errorf("unaccounted %v, want 5ms", unaccounted)
+			}
+			if text := output(p); !strings.Contains(text, ", blocked: 5.000ms") {
+				t.Errorf("report doesn't show the blocked time:\n%s", text)
+			}
+			if warning := captured.String(); warning != "" {
+				t.Errorf("unexpected warning %q", warning)
+			}
+		})
+	}
+}
+
+func TestBlockMisuses(t *testing.T) {
+	var tests = []struct {
+		name string
+		run  func(p *Profiler)
+		want string
+	}{
+		{"no anchor open", func(p *Profiler) { p.BlockStart() }, "BlockStart with no anchor open"},
+		{"already blocked", func(p *Profiler) {
+			p.Start("a")
+			p.BlockStart()
+			p.BlockStart()
+		}, `BlockStart while "a" is already blocked`},
+		{"not blocked", func(p *Profiler) {
+			p.Start("a")
+			p.BlockEnd()
+		}, "BlockEnd without BlockStart"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useFakeClock(t)
+			var captured = captureWarnings(t)
+			test.run(New())
+
+			if warning := captured.String(); !strings.Contains(warning, test.want) {
+				t.Errorf("warning %q, want %q", warning, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package timer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+SetBudget sets the longest time a single hit of the named anchor should take,
+the same per-hit time as MinHit and MaxHit rather than the accumulated total.
+Every hit exceeding it is counted, and Output flags the anchor with
+"[over budget]" and the count of such hits, turning the profiler into a
+lightweight latency assertion during development. A zero budget removes it;
+it can be set before the anchor is first started.
+*/
+func (p *Profiler) SetBudget(anchorName string, max time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return
+	}
+
+	if max <= 0 {
+		delete(p.budgets, key)
+		return
+	}
+
+	if p.budgets == nil {
+		p.budgets = make(map[string]time.Duration)
+	}
+
+	p.budgets[key] = max
+}
+
+// checkBudget counts a hit of tscount CPU timer units exceeding the budget
+// of anchor.
+func (p *Profiler) checkBudget(anchor *anchor, tscount int64) {
+	var budget, exists = p.budgets[anchor.name]
+	if exists && tscount > durationToTicks(budget) {
+		anchor.overBudget = anchor.overBudget + 1
+	}
+}
+
+// formatBudget formats the hits of anchor over its budget for Output, empty
+// when none exceeded it.
+func (p *Profiler) formatBudget(anchor *anchor) string {
+	if anchor.overBudget == 0 {
+		return ""
+	}
+
+	var budget = p.formatElapsed(float64(p.budgets[anchor.name]) / float64(time.Millisecond))
+	return fmt.Sprintf(", over budget: %d of %d calls (budget: %s) [over budget]", anchor.overBudget,
+		anchor.hits, strings.TrimSpace(budget))
+}
+
+// SetBudget sets the per-hit budget of an anchor of the default profiler.
+func SetBudget(anchorName string, max time.Duration) {
+	defaultProfiler.SetBudget(anchorName, max)
+}
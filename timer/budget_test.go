@@ -0,0 +1,49 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	var tests = []struct {
+		name   string
+		budget time.Duration
+		over   int64
+		flag   string
+	}{
+		{"unset", 0, 0, ""},
+		{"exceeded", 2 * time.Millisecond, 2, ", over budget: 2 of 4 calls (budget: 2.000ms) [over budget]"},
+		{"met", 10 * time.Millisecond, 0, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			p.SetBudget("query", test.budget)
+
+			// At the budget isn't over it
+			for _, ticks := range []int64{1000000, 2000000, 3000000, 5000000} {
+				p.Start("query")
+				clock.advance(ticks)
+				p.Stop("query")
+			}
+
+			var result = resultOf(t, p.Snapshot(), "query")
+			if result.OverBudget != test.over || result.Budget != test.budget {
+				t.Errorf("%d hits over a budget of %v, want %d over %v", result.OverBudget, result.Budget,
+					test.over, test.budget)
+			}
+
+			var text = output(p)
+			if test.flag == "" && strings.Contains(text, "over budget") {
+				t.Errorf("unexpected budget flag:\n%s", text)
+			}
+			if !strings.Contains(text, test.flag) {
+				t.Errorf("report doesn't contain %q:\n%s", test.flag, text)
+			}
+		})
+	}
+}
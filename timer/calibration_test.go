@@ -0,0 +1,154 @@
+package timer
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// estimations replaces the frequency estimation with one returning the next
+// of frequencies, the last one repeating, and returns the number of
+// estimations made.
+func estimations(frequencies ...int64) *int32 {
+	var count int32
+	freqFn = func() int64 {
+		var n = int(atomic.AddInt32(&count, 1)) - 1
+		if n >= len(frequencies) {
+			n = len(frequencies) - 1
+		}
+		return frequencies[n]
+	}
+
+	return &count
+}
+
+func TestInvalidateCalibration(t *testing.T) {
+	var clock = useFakeClock(t)
+	var estimated = estimations(testFrequency, 2*testFrequency)
+	var p = New()
+
+	p.Start("a")
+	clock.advance(1000)
+	p.Stop("a")
+	p.Start("a")
+	p.Stop("a")
+	if *estimated != 1 || GetCPUFrequency() != testFrequency {
+		t.Fatalf("%d estimations at %dHz, want a single one at %dHz", *estimated, GetCPUFrequency(),
+			testFrequency)
+	}
+
+	InvalidateCalibration()
+	if frequency := GetCPUFrequency(); frequency != 0 {
+		t.Fatalf("frequency = %d after InvalidateCalibration, want 0", frequency)
+	}
+
+	p.Start("a")
+	p.Stop("a")
+	if *estimated != 2 || GetCPUFrequency() != 2*testFrequency {
+		t.Fatalf("%d estimations at %dHz, want a second one at %dHz", *estimated, GetCPUFrequency(),
+			2*testFrequency)
+	}
+
+	// Recorded units are kept and converted with the new frequency
+	var result = resultOf(t, p.Snapshot(), "a")
+	if result.TSCount != 1000 || result.Elapsed != 0.0005 {
+		t.Errorf("a: tscount %d, elapsed %vms, want 1000 and 0.0005ms", result.TSCount, result.Elapsed)
+	}
+}
+
+func TestBackgroundCalibration(t *testing.T) {
+	var clock = useFakeClock(t)
+	SetBackgroundCalibration(true)
+	t.Cleanup(func() { SetBackgroundCalibration(false) })
+
+	var release = make(chan struct{})
+	freqFn = func() int64 {
+		<-release
+		return testFrequency
+	}
+
+	// Doesn't wait for the estimation
+	var p = New()
+	p.Start("a")
+	clock.advance(3000000)
+	p.Stop("a")
+
+	if text := output(p); !strings.Contains(text, "provisional, estimating in the background") {
+		t.Errorf("report doesn't tell the frequency is provisional:\n%s", text)
+	}
+	if result := resultOf(t, p.Snapshot(), "a"); result.Elapsed != 1 {
+		t.Errorf("%vms with the provisional frequency, want 1ms", result.Elapsed)
+	}
+
+	close(release)
+	for calibrationIsProvisional() {
+		time.Sleep(time.Millisecond)
+	}
+	if result := resultOf(t, p.Snapshot(), "a"); result.TSCount != 3000000 || result.Elapsed != 3 {
+		t.Errorf("%vms of %d ticks once estimated, want 3ms of 3000000", result.Elapsed, result.TSCount)
+	}
+}
+
+func TestInvalidateCalibrationDiscardsBackgroundEstimation(t *testing.T) {
+	useFakeClock(t)
+	SetBackgroundCalibration(true)
+	t.Cleanup(func() { SetBackgroundCalibration(false) })
+
+	var release = make(chan struct{})
+	var returned = make(chan struct{})
+	freqFn = func() int64 {
+		<-release
+		defer close(returned)
+		return testFrequency
+	}
+
+	if frequency := Calibrate(); frequency != provisionalCPUFrequency {
+		t.Fatalf("Calibrate = %d, want the provisional %d", frequency, provisionalCPUFrequency)
+	}
+
+	InvalidateCalibration()
+	close(release)
+	<-returned
+
+	// Leaves the estimation time to store its outdated result
+	for i := 0; i < 20; i++ {
+		if frequency := GetCPUFrequency(); frequency != 0 {
+			t.Fatalf("frequency = %d, the estimation started before InvalidateCalibration was kept",
+				frequency)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCalibrateConcurrently(t *testing.T) {
+	useFakeClock(t)
+	var estimated = estimations(testFrequency, 2*testFrequency)
+
+	var frequencies = make(chan int64, 16)
+	var calibrating sync.WaitGroup
+	for g := 0; g < cap(frequencies); g++ {
+		calibrating.Add(1)
+		go func() {
+			defer calibrating.Done()
+			frequencies <- Calibrate()
+		}()
+	}
+	calibrating.Wait()
+	close(frequencies)
+
+	for frequency := range frequencies {
+		if frequency != testFrequency {
+			t.Errorf("Calibrate = %d, want %d", frequency, testFrequency)
+		}
+	}
+
+	// Reused by the next Start
+	var p = New()
+	p.Start("a")
+	p.Stop("a")
+	if *estimated != 1 {
+		t.Errorf("%d estimations, want a single one", *estimated)
+	}
+}
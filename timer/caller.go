@@ -0,0 +1,85 @@
+package timer
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Anchor names of the call sites resolved by StartMethod, by program counter
+var callerNames sync.Map
+
+/*
+StartMethod starts an anchor named after the calling function, "Type.Method"
+for a method whatever its receiver, pointer or not, and "Function" for a plain
+function, and returns that name for the matching Stop:
+
+	func (s *Server) Handle() {
+		defer timer.Stop(timer.StartMethod())
+		...
+	}
+
+The name is resolved from the call stack on the first call from each call
+site, then cached, so later calls only cost a stack walk of one frame. Closures
+are named after their enclosing function with a ".funcN" suffix. Long names go
+through the policy set by SetNameTooLongPolicy like any other.
+*/
+func (p *Profiler) StartMethod() string {
+	var name = callerName(3)
+	p.Start(name)
+	return name
+}
+
+// callerName returns the anchor name of the function skip frames up the
+// stack, runtime.Callers itself being frame 0.
+func callerName(skip int) string {
+	var pcs [1]uintptr
+	if runtime.Callers(skip, pcs[:]) == 0 {
+		return "unknown"
+	}
+
+	if name, exists := callerNames.Load(pcs[0]); exists {
+		return name.(string)
+	}
+
+	// Frames rather than FuncForPC, which names the outer function of an
+	// inlined call
+	var frame, _ = runtime.CallersFrames(pcs[:]).Next()
+	var name = methodName(frame.Function)
+	callerNames.Store(pcs[0], name)
+
+	return name
+}
+
+/*
+methodName converts a fully qualified function name as reported by the
+runtime, e.g. "github.com/user/pkg.(*Type[...]).Method", to "Type.Method".
+*/
+func methodName(function string) string {
+	if slash := strings.LastIndexByte(function, '/'); slash >= 0 {
+		function = function[slash+1:]
+	}
+
+	// Package name
+	if dot := strings.IndexByte(function, '.'); dot >= 0 {
+		function = function[dot+1:]
+	}
+
+	function = strings.Replace(function, "(*", "", 1)
+	function = strings.Replace(function, ")", "", 1)
+	function = strings.Replace(function, "[...]", "", 1)
+
+	if function == "" {
+		return "unknown"
+	}
+
+	return function
+}
+
+// StartMethod starts an anchor named after the calling function on the
+// default profiler, and returns its name.
+func StartMethod() string {
+	var name = callerName(3)
+	defaultProfiler.Start(name)
+	return name
+}
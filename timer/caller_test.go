@@ -0,0 +1,87 @@
+package timer
+
+import "testing"
+
+type methodServer struct{ p *Profiler }
+
+func (s *methodServer) Handle() string {
+	var name = s.p.StartMethod()
+	s.p.Stop(name)
+	return name
+}
+
+func (s methodServer) Value() string {
+	var name = s.p.StartMethod()
+	s.p.Stop(name)
+	return name
+}
+
+type methodBox[T any] struct{ p *Profiler }
+
+func (b *methodBox[T]) Put() string {
+	var name = b.p.StartMethod()
+	b.p.Stop(name)
+	return name
+}
+
+func plainFunction(p *Profiler) string {
+	var name = p.StartMethod()
+	p.Stop(name)
+	return name
+}
+
+func TestStartMethod(t *testing.T) {
+	useFakeClock(t)
+	var p = New()
+	// Room for the closure names
+	p.SetMaxNameLength(32)
+	var server = &methodServer{p: p}
+
+	var tests = []struct {
+		call func() string
+		want string
+	}{
+		{server.Handle, "methodServer.Handle"},
+		{server.Value, "methodServer.Value"},
+		{(&methodBox[int]{p: p}).Put, "methodBox.Put"},
+		{func() string { return plainFunction(p) }, "plainFunction"},
+		{func() string {
+			var name = p.StartMethod()
+			p.Stop(name)
+			return name
+		}, "TestStartMethod.func2"},
+	}
+
+	for _, test := range tests {
+		// Resolved then cached
+		for i := 0; i < 2; i++ {
+			if name := test.call(); name != test.want {
+				t.Errorf("anchor %q, want %q", name, test.want)
+			}
+		}
+		if result := resultOf(t, p.Snapshot(), test.want); result.Hits != 2 || result.Open {
+			t.Errorf("%s: %+v, want 2 stopped hits", test.want, result)
+		}
+	}
+}
+
+func TestMethodName(t *testing.T) {
+	var tests = []struct {
+		function string
+		want     string
+	}{
+		{"github.com/user/pkg.(*Type).Method", "Type.Method"},
+		{"github.com/user/pkg.Type.Method", "Type.Method"},
+		{"github.com/user/pkg.(*Type[...]).Method", "Type.Method"},
+		{"github.com/user/pkg.Function", "Function"},
+		{"github.com/user/pkg.Function.func1", "Function.func1"},
+		{"main.main", "main"},
+		{"", "unknown"},
+	}
+
+	for _, test := range tests {
+		if got := methodName(test.function); got != test.want {
+			t.Errorf("methodName(%q) = %q, want %q", test.function, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package timer
+
+/*
+SetMaxAnchors bounds the number of distinct anchors, maxHandledAnchors by
+default: once reached, new anchors are dropped with a warning, see
+ErrTooManyAnchors. The anchor slots grow on demand, so a large limit costs
+nothing until used. A limit below the anchors already registered applies to
+the next ones only; zero or a negative limit restores the default.
+*/
+func (p *Profiler) SetMaxAnchors(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if n <= 0 {
+		n = 0
+	}
+
+	p.maxAnchors = n
+}
+
+/*
+SetMaxNameLength sets the length beyond which anchor names are handled by the
+NameTooLongPolicy, anchorNameMaxLength by default, and the width of the name
+column of Output. Call it before the first Start, or Reset afterwards, so
+that a name isn't recorded under two anchors; zero or a negative length
+restores the default.
+*/
+func (p *Profiler) SetMaxNameLength(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if n <= 0 {
+		n = 0
+	}
+
+	p.maxNameLength = n
+}
+
+// anchorLimit returns the number of anchor slots, the total included.
+func (p *Profiler) anchorLimit() int {
+	if p.maxAnchors == 0 {
+		return maxHandledAnchors
+	}
+
+	// The total takes the first slot
+	return p.maxAnchors + 1
+}
+
+// nameLength returns the length beyond which names are too long, which is
+// also the width of the Output name column.
+func (p *Profiler) nameLength() int {
+	if p.maxNameLength == 0 {
+		return anchorNameMaxLength
+	}
+
+	return p.maxNameLength
+}
+
+// SetMaxAnchors bounds the number of anchors of the default profiler.
+func SetMaxAnchors(n int) {
+	defaultProfiler.SetMaxAnchors(n)
+}
+
+// SetMaxNameLength sets the maximum anchor name length of the default
+// profiler.
+func SetMaxNameLength(n int) {
+	defaultProfiler.SetMaxNameLength(n)
+}
@@ -0,0 +1,99 @@
+package timer
+
+import (
+	"hash/fnv"
+	"io"
+	"os"
+)
+
+// ANSI foreground colors given to categories, picked from a hash of their
+// name so that a category keeps its color from one report to the next
+var categoryColors = []string{
+	"\x1b[36m", // cyan
+	"\x1b[33m", // yellow
+	"\x1b[35m", // magenta
+	"\x1b[32m", // green
+	"\x1b[34m", // blue
+	"\x1b[31m", // red
+}
+
+// Colors of the usual categories, kept distinct from each other
+var wellKnownCategoryColors = map[string]string{
+	"cpu":     "\x1b[32m",
+	"io":      "\x1b[36m",
+	"lock":    "\x1b[31m",
+	"network": "\x1b[35m",
+}
+
+const colorReset = "\x1b[0m"
+
+/*
+SetCategory labels the named anchor with a category such as "io", "cpu" or
+"lock", used by WriteColored and reported in AnchorResult. It can be set
+before the anchor is first started; an empty category removes the label.
+*/
+func (p *Profiler) SetCategory(anchorName string, category string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return
+	}
+
+	if category == "" {
+		delete(p.categories, key)
+		return
+	}
+
+	if p.categories == nil {
+		p.categories = make(map[string]string)
+	}
+
+	p.categories[key] = category
+}
+
+/*
+WriteColored writes the same report as Output to w, the anchor names being
+colored according to their category. Colors are left out when the NO_COLOR
+environment variable is set to a non-empty value.
+*/
+func (p *Profiler) WriteColored(w io.Writer) {
+	if profilingDisabled() {
+		return
+	}
+
+	var noColor = os.Getenv("NO_COLOR") != ""
+	var report = reportBuffer{destination: w}
+
+	p.mu.Lock()
+	p.write(&report, writeOptions{colored: !noColor})
+	p.mu.Unlock()
+
+	w.Write(report.Bytes())
+}
+
+func colorize(text string, category string) string {
+	if category == "" {
+		return text
+	}
+
+	if color, exists := wellKnownCategoryColors[category]; exists {
+		return color + text + colorReset
+	}
+
+	var hash = fnv.New32a()
+	hash.Write([]byte(category))
+
+	return categoryColors[hash.Sum32()%uint32(len(categoryColors))] + text + colorReset
+}
+
+// SetCategory labels an anchor of the default profiler with a category.
+func SetCategory(anchorName string, category string) {
+	defaultProfiler.SetCategory(anchorName, category)
+}
+
+// WriteColored writes the default profiler report with category colors.
+func WriteColored(w io.Writer) {
+	defaultProfiler.WriteColored(w)
+}
@@ -0,0 +1,78 @@
+package timer
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var escapeCodes = regexp.MustCompile("\x1b\\[[0-9]+m")
+
+// Changing from one second to the next
+var generatedLine = regexp.MustCompile("(?m)^ *report: generated .*$")
+
+func TestWriteColored(t *testing.T) {
+	var tests = []struct {
+		name    string
+		noColor string
+		colored bool
+	}{
+		{"colored", "", true},
+		{"NO_COLOR", "1", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			t.Setenv("NO_COLOR", test.noColor)
+			var p = New()
+			p.SetCategory("read", "io")
+			p.SetCategory("parse", "parsing")
+			p.SetCategory("parse", "")
+
+			for _, name := range []string{"read", "parse"} {
+				p.Start(name)
+				clock.advance(100)
+				p.Stop(name)
+			}
+
+			var buf bytes.Buffer
+			p.WriteColored(&buf)
+			var text = buf.String()
+
+			var colored = regexp.MustCompile("\x1b\\[36m *read\x1b\\[0m:")
+			if got := colored.MatchString(text); got != test.colored {
+				t.Errorf("io anchor colored = %v, want %v:\n%q", got, test.colored, text)
+			}
+			if strings.Contains(text, "parse\x1b[0m") {
+				t.Errorf("uncategorized anchor colored:\n%q", text)
+			}
+			// Same report as Output, colors aside
+			var plain = generatedLine.ReplaceAllString(escapeCodes.ReplaceAllString(text, ""), "")
+			if want := generatedLine.ReplaceAllString(output(p), ""); plain != want {
+				t.Errorf("got\n%s\nwant\n%s", plain, want)
+			}
+			if result := resultOf(t, p.Snapshot(), "read"); result.Category != "io" {
+				t.Errorf("category %q, want io", result.Category)
+			}
+		})
+	}
+}
+
+func TestCategoryColors(t *testing.T) {
+	// Stable from one report to the next
+	if colorize("a", "x") != colorize("a", "x") {
+		t.Errorf("category x changed color")
+	}
+	var seen = make(map[string]string)
+	for category, color := range wellKnownCategoryColors {
+		if other, exists := seen[color]; exists {
+			t.Errorf("%s and %s share a color", category, other)
+		}
+		seen[color] = category
+	}
+	if got := colorize("a", ""); got != "a" {
+		t.Errorf("uncategorized text colored %q", got)
+	}
+}
@@ -0,0 +1,45 @@
+package timer
+
+import "fmt"
+
+/*
+The CPU timer is read through clockFn, its frequency obtained through freqFn
+and its stability reported by invariantFn, all set by the platform specific
+file selected by the build tags along with calibrationWindow, so that the rest
+of the package doesn't depend on how the timer is implemented.
+*/
+
+func readCPUTimer() int64 {
+	return clockFn()
+}
+
+func estimateCPUTimerFreq() int64 {
+	return freqFn()
+}
+
+// calibrationHeader describes the CPU timer frequency of the report and how
+// much it can be trusted, for the report header.
+func calibrationHeader(frequency int64) string {
+	if frequency == 0 {
+		return "uncalibrated"
+	}
+
+	var source = "reported by the system"
+	if calibrationWindow > 0 {
+		source = fmt.Sprintf("estimated over %v", calibrationWindow)
+	}
+	if calibrationIsSupplied() {
+		return fmt.Sprintf("%.3fGHz supplied (confidence: not measured)", float64(frequency)/1e9)
+	}
+	if calibrationIsProvisional() {
+		return fmt.Sprintf("%.3fGHz provisional, estimating in the background (confidence: low)",
+			float64(frequency)/1e9)
+	}
+
+	var confidence = "high, invariant timer"
+	if !invariantFn() {
+		confidence = "low, timer rate varies with the CPU power state"
+	}
+
+	return fmt.Sprintf("%.3fGHz %s (confidence: %s)", float64(frequency)/1e9, source, confidence)
+}
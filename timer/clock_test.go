@@ -0,0 +1,141 @@
+package timer
+
+import (
+	"math"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPlatformClock runs on the timer selected by the build tags, RDTSC with
+// cgo on x86, the performance counter on Windows and the monotonic clock
+// otherwise.
+func TestPlatformClock(t *testing.T) {
+	InvalidateCalibration()
+	t.Cleanup(InvalidateCalibration)
+
+	var before = readCPUTimer()
+	time.Sleep(10 * time.Millisecond)
+	var after = readCPUTimer()
+	if after <= before {
+		t.Fatalf("timer went from %d to %d over 10ms", before, after)
+	}
+
+	var frequency = Calibrate()
+	// From the 10MHz performance counter to a fast TSC, the monotonic clock
+	// ticking at 1GHz
+	if frequency < 1000000 || frequency > 10000000000 {
+		t.Fatalf("frequency = %dHz, want between 1MHz and 10GHz", frequency)
+	}
+
+	var p = New()
+	p.Start("sleep")
+	time.Sleep(20 * time.Millisecond)
+	p.Stop("sleep")
+
+	var report = p.Snapshot()
+	if elapsed := resultOf(t, report, "sleep").Elapsed; elapsed < 20 || elapsed > 2000 {
+		t.Errorf("a 20ms sleep measured %vms", elapsed)
+	}
+
+	var header = calibrationHeader(frequency)
+	if calibrationWindow > 0 && !strings.Contains(header, "estimated over "+time.Duration(calibrationWindow).String()) {
+		t.Errorf("header %q doesn't tell the calibration window", header)
+	}
+	if calibrationWindow == 0 && !strings.Contains(header, "reported by the system") {
+		t.Errorf("header %q doesn't tell the frequency is reported", header)
+	}
+}
+
+func TestInjectedClock(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.Start("a")
+	clock.advance(1234)
+	p.Stop("a")
+
+	if result := resultOf(t, p.Snapshot(), "a"); result.TSCount != 1234 {
+		t.Errorf("a: tscount = %d, want the 1234 units of the injected clock", result.TSCount)
+	}
+	if frequency := GetCPUFrequency(); frequency != testFrequency {
+		t.Errorf("frequency = %d, want the injected %d", frequency, testFrequency)
+	}
+}
+
+func TestCalibrationHeader(t *testing.T) {
+	var tests = []struct {
+		name      string
+		frequency int64
+		setup     func()
+		want      string
+	}{
+		{"uncalibrated", 0, func() {}, "uncalibrated"},
+		{"supplied", 2100000000, func() { SetCPUFrequency(2100000000) },
+			"2.100GHz supplied (confidence: not measured)"},
+		{"provisional", provisionalCPUFrequency, func() { atomic.StoreInt32(&provisionalCalibration, 1) },
+			"3.000GHz provisional, estimating in the background (confidence: low)"},
+		{"invariant", 2000000000, func() { invariantFn = func() bool { return true } },
+			"(confidence: high, invariant timer)"},
+		{"varying", 2000000000, func() { invariantFn = func() bool { return false } },
+			"(confidence: low, timer rate varies with the CPU power state)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useFakeClock(t)
+			var savedInvariant = invariantFn
+			t.Cleanup(func() {
+				invariantFn = savedInvariant
+				InvalidateCalibration()
+			})
+			test.setup()
+
+			if header := calibrationHeader(test.frequency); !strings.HasSuffix(header, test.want) {
+				t.Errorf("header %q, want %q", header, test.want)
+			}
+		})
+	}
+}
+
+func TestOSTimer(t *testing.T) {
+	if frequency := getOSTimerFreq(); frequency != int64(time.Second) {
+		t.Errorf("OS timer frequency %d, want nanoseconds", frequency)
+	}
+
+	var before = readOSTimer()
+	time.Sleep(time.Millisecond)
+	var elapsed = readOSTimer() - before
+	if elapsed < int64(time.Millisecond) {
+		t.Errorf("a 1ms sleep measured %dns", elapsed)
+	}
+	// Finer than microseconds
+	var distinct = make(map[int64]bool)
+	for i := 0; i < 1000; i++ {
+		distinct[readOSTimer()%1000] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("OS timer readings all multiples of a microsecond")
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	var tests = []struct {
+		a, b, c int64
+		want    int64
+	}{
+		{6, 7, 3, 14},
+		{-6, 7, 3, -14},
+		{1000000000, 40000000000, 10000000000, 4000000000},
+		{math.MaxInt64, math.MaxInt64, math.MaxInt64, math.MaxInt64},
+		{math.MaxInt64, 4, 2, math.MaxInt64},
+		{math.MinInt64 + 1, 4, 2, math.MinInt64},
+	}
+
+	for _, test := range tests {
+		if got := mulDiv(test.a, test.b, test.c); got != test.want {
+			t.Errorf("mulDiv(%d, %d, %d) = %d, want %d", test.a, test.b, test.c, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+)
+
+/*
+CombinedOutput writes the report of each profiler to w, one after the other
+under their names, followed by a combined section summing their totals, with
+the share of each profiler. Totals are summed in milliseconds, through the
+frequency each profiler converts its CPU timer units with, so that profilers
+normalized to different frequencies still add up. Profilers running
+concurrently overlap in time: their combined total is the time spent in all
+of them, not the wall time.
+
+Each profiler is locked in turn while its section is formatted, so each
+section is consistent, though not taken at the same instant as the others,
+and the section is written once the lock is released.
+*/
+func CombinedOutput(w io.Writer, profilers ...*Profiler) {
+	if profilingDisabled() || len(profilers) == 0 {
+		return
+	}
+
+	var names = make([]string, len(profilers))
+	var totals = make([]float64, len(profilers))
+	var combined float64
+	var hits int64
+
+	var section = reportBuffer{destination: w}
+	for i, p := range profilers {
+		section.Reset()
+
+		p.mu.Lock()
+		p.write(&section, writeOptions{})
+		names[i] = p.name
+		totals[i] = p.milliseconds(p.totalAnchor.tscount)
+		hits = hits + p.totalHits()
+		p.mu.Unlock()
+
+		w.Write(section.Bytes())
+		combined = combined + totals[i]
+	}
+
+	var summary = &Profiler{precision: defaultPrecision}
+	var padding = anchorNameMaxLength
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%*s: %s -- profilers: %d, calls: %d\n", padding, "combined",
+		summary.formatElapsed(combined), len(profilers), hits)
+
+	for i, total := range totals {
+		var name = names[i]
+		if name == "" {
+			name = "(unnamed)"
+		}
+
+		var percent = fmt.Sprintf("%6s", "n/a")
+		if combined > 0 {
+			percent = fmt.Sprintf("%5.2f%%", 100*total/combined)
+		}
+
+		fmt.Fprintf(w, "%*s: %s (%s)\n", padding+2, name, summary.formatElapsed(total), percent)
+	}
+}
@@ -0,0 +1,53 @@
+package timer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCombinedOutput(t *testing.T) {
+	var clock = useFakeClock(t)
+	var parse, render = NewProfiler("parse"), New()
+	parse.Start("a")
+	clock.advance(1000000)
+	parse.Stop("a")
+	render.Start("b")
+	clock.advance(3000000)
+	render.Stop("b")
+
+	var buffer bytes.Buffer
+	CombinedOutput(&buffer, parse, render)
+	var text = generatedLine.ReplaceAllString(buffer.String(), "")
+
+	// Each section as written by Output
+	for _, p := range []*Profiler{parse, render} {
+		if section := generatedLine.ReplaceAllString(output(p), ""); !strings.Contains(text, section) {
+			t.Errorf("combined output doesn't contain the section\n%s\nin\n%s", section, text)
+		}
+	}
+
+	const want = `
+          combined:      4.000ms -- profilers: 2, calls: 2
+               parse:      1.000ms (25.00%)
+           (unnamed):      3.000ms (75.00%)
+`
+	if !strings.HasSuffix(text, want) {
+		t.Errorf("combined output\n%s\nwant it to end with\n%s", text, want)
+	}
+}
+
+func TestCombinedOutputEmpty(t *testing.T) {
+	useFakeClock(t)
+
+	var buffer bytes.Buffer
+	CombinedOutput(&buffer)
+	if buffer.Len() != 0 {
+		t.Errorf("wrote %q without profilers", buffer.String())
+	}
+
+	CombinedOutput(&buffer, New())
+	if text := buffer.String(); !strings.Contains(text, "(unnamed):      0.000ms (   n/a)") {
+		t.Errorf("combined output of an empty profiler:\n%s", text)
+	}
+}
@@ -0,0 +1,55 @@
+package timer
+
+/*
+Compare returns the anchors of current whose elapsed time exceeds that of the
+same anchor in baseline by more than threshold, a fractional increase: 0.1
+allows 10% slower. Unlike CompareToGolden it compares the accumulated times,
+for runs of the same workload, e.g. to fail a CI build when the returned slice
+isn't empty. Anchors of current missing from baseline are not regressions,
+see AddedAnchors, and anchors that took no time in baseline are not compared.
+*/
+func Compare(baseline Report, current Report, threshold float64) []Regression {
+	var baselineElapsed = make(map[string]float64, len(baseline.Anchors))
+	for _, result := range baseline.Anchors {
+		baselineElapsed[result.Name] = result.Elapsed
+	}
+
+	var regressions []Regression
+	for _, result := range current.Anchors {
+		var elapsed, exists = baselineElapsed[result.Name]
+		if !exists || elapsed <= 0 {
+			continue
+		}
+
+		if result.Elapsed > elapsed*(1+threshold) {
+			var ratio = result.Elapsed / elapsed
+			regressions = append(regressions, Regression{
+				Name:    result.Name,
+				Golden:  elapsed,
+				Current: result.Elapsed,
+				Ratio:   ratio,
+				Change:  100 * (ratio - 1),
+			})
+		}
+	}
+
+	return regressions
+}
+
+// AddedAnchors returns the names of the anchors of current missing from
+// baseline, in the order of current.
+func AddedAnchors(baseline Report, current Report) []string {
+	var known = make(map[string]bool, len(baseline.Anchors))
+	for _, result := range baseline.Anchors {
+		known[result.Name] = true
+	}
+
+	var added []string
+	for _, result := range current.Anchors {
+		if !known[result.Name] {
+			added = append(added, result.Name)
+		}
+	}
+
+	return added
+}
@@ -0,0 +1,53 @@
+package timer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	var baseline = Report{Anchors: []AnchorResult{
+		{Name: "steady", Elapsed: 10},
+		{Name: "slower", Elapsed: 10},
+		{Name: "faster", Elapsed: 10},
+		{Name: "idle", Elapsed: 0},
+		{Name: "removed", Elapsed: 10},
+	}}
+	var current = Report{Anchors: []AnchorResult{
+		{Name: "steady", Elapsed: 10.5},
+		{Name: "slower", Elapsed: 15},
+		{Name: "faster", Elapsed: 5},
+		{Name: "idle", Elapsed: 3},
+		{Name: "added", Elapsed: 10},
+	}}
+
+	var tests = []struct {
+		threshold float64
+		want      []string
+	}{
+		{0, []string{"steady", "slower"}},
+		{0.1, []string{"slower"}},
+		{0.5, nil},
+	}
+
+	for _, test := range tests {
+		var regressions = Compare(baseline, current, test.threshold)
+
+		var names []string
+		for _, regression := range regressions {
+			names = append(names, regression.Name)
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("threshold %v: regressions %v, want %v", test.threshold, names, test.want)
+		}
+	}
+
+	var slower = Compare(baseline, current, 0.1)[0]
+	if slower != (Regression{Name: "slower", Golden: 10, Current: 15, Ratio: 1.5, Change: 50}) {
+		t.Errorf("regression %+v, want 10ms to 15ms, 50%% slower", slower)
+	}
+
+	if added := AddedAnchors(baseline, current); !reflect.DeepEqual(added, []string{"added"}) {
+		t.Errorf("AddedAnchors = %v, want [added]", added)
+	}
+}
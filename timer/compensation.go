@@ -0,0 +1,104 @@
+package timer
+
+import "sync"
+
+const startStopOverheadRounds = 5
+
+var (
+	startStopOverheadOnce sync.Once
+	startStopOverhead     int64
+)
+
+// measureStartStopOverhead returns the CPU timer units an empty Start/Stop
+// pair records into its own anchor, the least average over a few rounds of
+// iterations pairs on a throwaway profiler, so that an interrupted round
+// doesn't inflate it.
+func measureStartStopOverhead(iterations int) int64 {
+	var smallest int64 = -1
+	for round := 0; round < startStopOverheadRounds; round++ {
+		var scratch = NewProfiler("")
+		// Pay the calibration and the registration before measuring
+		scratch.Start("overhead")
+		scratch.Stop("overhead")
+		scratch.ResetAnchor("overhead")
+
+		for i := 0; i < iterations; i++ {
+			scratch.Start("overhead")
+			scratch.Stop("overhead")
+		}
+
+		var measured, exists = scratch.anchorsByName["overhead"]
+		if !exists || measured.hits == 0 {
+			// Profiling disabled
+			return 0
+		}
+
+		var average = measured.tscount / measured.hits
+		if smallest < 0 || average < smallest {
+			smallest = average
+		}
+	}
+
+	return smallest
+}
+
+// StartStopOverhead returns the CPU timer units an empty Start/Stop pair
+// records, measured on first use and cached afterwards.
+func StartStopOverhead() int64 {
+	startStopOverheadOnce.Do(func() {
+		startStopOverhead = measureStartStopOverhead(timerOverheadIterations)
+		verbosef("Start/Stop: %v (CPU timer units)\n", startStopOverhead)
+	})
+
+	return startStopOverhead
+}
+
+/*
+SetOverheadCompensation subtracts the cost of the profiler itself from every
+hit, as measured by StartStopOverhead on first use: the part of Start and Stop
+between their clock readings, otherwise counted as time of the anchor. It
+makes tiny sections, lasting a few times the overhead, trustworthy. A hit is
+never made shorter than zero, and the deducted time shows as unaccounted.
+
+Only the default exclusive accounting is compensated, and the cost of the
+Start and Stop calls of the children still counts in their parent.
+*/
+func (p *Profiler) SetOverheadCompensation(enabled bool) {
+	var overhead int64
+	if enabled {
+		overhead = StartStopOverhead()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.overheadCompensation = overhead
+}
+
+// compensate deducts the Start/Stop overhead from a hit of tscount CPU timer
+// units, already counted in anchor, and returns the compensated hit.
+func (p *Profiler) compensate(anchor *anchor, tscount int64) int64 {
+	var overhead = p.overheadCompensation
+	if overhead > tscount {
+		overhead = tscount
+	}
+
+	if overhead <= 0 {
+		return tscount
+	}
+
+	var deducted = overhead
+	if deducted > anchor.tscount {
+		deducted = anchor.tscount
+	}
+	anchor.tscount = anchor.tscount - deducted
+	anchor.elapsed = ticksToMilliseconds(anchor.tscount)
+
+	return tscount - overhead
+}
+
+// SetOverheadCompensation subtracts the profiler overhead from the default
+// profiler hits.
+func SetOverheadCompensation(enabled bool) {
+	defaultProfiler.SetOverheadCompensation(enabled)
+}
@@ -0,0 +1,49 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMeasureStartStopOverhead(t *testing.T) {
+	tickingClock(t, 7)
+	if overhead := measureStartStopOverhead(100); overhead != 7 {
+		t.Errorf("overhead = %d, want a single clock step of 7", overhead)
+	}
+
+	Disable()
+	t.Cleanup(Enable)
+	if overhead := measureStartStopOverhead(100); overhead != 0 {
+		t.Errorf("overhead = %d while disabled, want 0", overhead)
+	}
+}
+
+func TestOverheadCompensation(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.overheadCompensation = 30
+
+	p.Start("a")
+	clock.advance(100)
+	p.Stop("a")
+	p.Start("tiny")
+	clock.advance(10)
+	p.Stop("tiny")
+
+	var report = p.Snapshot()
+	if a := resultOf(t, report, "a"); a.TSCount != 70 || a.MinHit != 0.00007 {
+		t.Errorf("a: %d ticks, min hit %vms, want 70 and 0.00007ms", a.TSCount, a.MinHit)
+	}
+	// Never below zero
+	if tiny := resultOf(t, report, "tiny"); tiny.TSCount != 0 {
+		t.Errorf("tiny: %d ticks, want 0", tiny.TSCount)
+	}
+
+	// The 40 deducted ticks of the 110 show as unaccounted
+	var text = output(p)
+	for _, line := range []string{"overhead: 30 CPU timer units subtracted per hit", "unaccounted:      0.000ms (36.36%)"} {
+		if !strings.Contains(text, line) {
+			t.Errorf("report doesn't contain %q:\n%s", line, text)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package timer
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+StartContext starts the named anchor and returns a function stopping it, meant
+to be deferred. If ctx is done before that function is called, a watcher
+goroutine stops the anchor at that time, so that an abandoned operation still
+records its partial time instead of leaving the anchor open forever. The
+returned function only stops the anchor once, whichever happens first.
+
+Each call costs a goroutine parked on ctx.Done() and a channel, both released
+as soon as the anchor is stopped.
+*/
+func (p *Profiler) StartContext(ctx context.Context, anchorName string) func() {
+	p.Start(anchorName)
+
+	var once sync.Once
+	var done = make(chan struct{})
+	var stop = func() {
+		once.Do(func() {
+			close(done)
+			p.Stop(anchorName)
+		})
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop()
+		case <-done:
+		}
+	}()
+
+	return stop
+}
+
+// StartContext starts an anchor of the default profiler, stopped at the
+// latest when ctx is done.
+func StartContext(ctx context.Context, anchorName string) func() {
+	return defaultProfiler.StartContext(ctx, anchorName)
+}
+
+type contextKey struct{}
+
+/*
+NewContext returns a copy of ctx carrying a new profiler, along with that
+profiler, e.g. to give each request of a server its own independent anchor
+chain instead of sharing the default profiler between concurrent requests.
+Handlers retrieve it with FromContext and, once done, its report is taken
+with Report or displayed with Output.
+*/
+func NewContext(ctx context.Context) (context.Context, *Profiler) {
+	var p = New()
+	return WithProfiler(ctx, p), p
+}
+
+// WithProfiler returns a copy of ctx carrying the profiler p.
+func WithProfiler(ctx context.Context, p *Profiler) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the profiler carried by ctx, nil if none.
+func FromContext(ctx context.Context) *Profiler {
+	var p, _ = ctx.Value(contextKey{}).(*Profiler)
+	return p
+}
@@ -0,0 +1,116 @@
+package timer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestContextProfilersAreIndependent(t *testing.T) {
+	var clock = useFakeClock(t)
+	captureWarnings(t)
+
+	if p := FromContext(context.Background()); p != nil {
+		t.Fatalf("FromContext of a bare context = %p, want nil", p)
+	}
+
+	const requests = 8
+	var reports = make([]Report, requests)
+	var handling sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		handling.Add(1)
+		go func(i int) {
+			defer handling.Done()
+
+			var ctx, p = NewContext(context.Background())
+			if got := FromContext(ctx); got != p {
+				t.Errorf("FromContext = %p, want the profiler of NewContext %p", got, p)
+				return
+			}
+
+			var name = fmt.Sprint("request", i)
+			for hit := 0; hit <= i; hit++ {
+				FromContext(ctx).Start(name)
+				clock.advance(10)
+				FromContext(ctx).Stop(name)
+			}
+			reports[i] = p.Report()
+		}(i)
+	}
+	handling.Wait()
+
+	for i, report := range reports {
+		if len(report.Anchors) != 1 {
+			t.Errorf("request %d recorded %d anchors, want only its own", i, len(report.Anchors))
+			continue
+		}
+		if result := report.Anchors[0]; result.Name != fmt.Sprint("request", i) || result.Hits != int64(i+1) {
+			t.Errorf("request %d recorded %q with %d hits, want %d", i, result.Name, result.Hits, i+1)
+		}
+	}
+
+	if report := defaultProfiler.Report(); len(report.Anchors) != 0 {
+		t.Errorf("the default profiler recorded %d anchors", len(report.Anchors))
+	}
+}
+
+func TestReportMatchesSnapshot(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = profileWith(clock, map[string]int64{"a": 1000, "b": 2000}, []string{"a", "b"})
+
+	var report, snapshot = p.Report(), p.Snapshot()
+	report.Meta.Generated, snapshot.Meta.Generated = time.Time{}, time.Time{}
+	if !reflect.DeepEqual(report, snapshot) {
+		t.Errorf("Report\n%+v\nSnapshot\n%+v", report, snapshot)
+	}
+}
+
+func TestStartContext(t *testing.T) {
+	var tests = []struct {
+		name string
+		run  func(stop func(), cancel context.CancelFunc)
+	}{
+		{"stopped", func(stop func(), cancel context.CancelFunc) {
+			stop()
+			stop()
+			cancel()
+		}},
+		{"cancelled", func(stop func(), cancel context.CancelFunc) {
+			cancel()
+		}},
+		{"cancelled then stopped", func(stop func(), cancel context.CancelFunc) {
+			cancel()
+			stop()
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useFakeClock(t)
+			var captured = captureWarnings(t)
+			var p = New()
+
+			var ctx, cancel = context.WithCancel(context.Background())
+			defer cancel()
+			var stop = p.StartContext(ctx, "request")
+			test.run(stop, cancel)
+
+			// The watcher stops the anchor asynchronously
+			var deadline = time.Now().Add(5 * time.Second)
+			for p.IsActive("request") && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+
+			var report = p.Snapshot()
+			if result := resultOf(t, report, "request"); result.Open || result.Hits != 1 {
+				t.Errorf("request %+v, want a single stopped hit", result)
+			}
+			if report.UnmatchedStops != 0 || captured.String() != "" {
+				t.Errorf("stopped more than once: %q", captured.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.Start("parse")
+	clock.advance(100)
+	var readings int
+	clockFn = func() int64 {
+		readings = readings + 1
+		return clock.read()
+	}
+	p.Count("token")
+	p.CountN("token", 4, 1024)
+	p.CountN("token", 0, 1024)
+	if readings != 0 {
+		t.Errorf("counting read the CPU timer %d times", readings)
+	}
+	clock.advance(100)
+	p.Stop("parse")
+
+	var report = p.Snapshot()
+	var token = resultOf(t, report, "token")
+	if token.Hits != 5 || token.Counted != 5 || token.Bytes != 1024 || token.TSCount != 0 {
+		t.Errorf("token: %d hits, %d counted, %d bytes, %d ticks, want 5, 5, 1024 and 0",
+			token.Hits, token.Counted, token.Bytes, token.TSCount)
+	}
+	if token.ParentName != "parse" || token.Open {
+		t.Errorf("token: parent %q, open %v, want under parse and closed", token.ParentName, token.Open)
+	}
+	// Not paused by its counted child
+	if parse := resultOf(t, report, "parse"); parse.TSCount != 200 {
+		t.Errorf("parse: %d ticks, want 200", parse.TSCount)
+	}
+	if text := output(p); !strings.Contains(text, "  token:") || !strings.Contains(text, "calls: 5, avg: n/a") {
+		t.Errorf("report doesn't show the counted anchor:\n%s", text)
+	}
+}
@@ -0,0 +1,38 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+)
+
+/*
+WriteCyclesReport writes the profile to w in raw CPU timer units, alongside
+the average cycles per call of each anchor. Unlike Output it doesn't depend on
+the estimated CPU frequency.
+*/
+func (p *Profiler) WriteCyclesReport(w io.Writer) {
+	if profilingDisabled() {
+		return
+	}
+
+	var report = p.Snapshot()
+	p.mu.Lock()
+	var nameLength = p.nameLength()
+	p.mu.Unlock()
+
+	fmt.Fprintln(w)
+
+	var padding = nameLength
+	fmt.Fprintf(w, "%*s: %14d cycles\n", padding, report.Total.Name, report.Total.TSCount)
+
+	for _, result := range report.Anchors {
+		var padding = nameLength + 2*int(result.Depth)
+		fmt.Fprintf(w, "%*s: %14d cycles (%5.2f%%) -- calls: %d, %.1f cycles/call\n",
+			padding, result.Name, result.TSCount, result.Percent, result.Hits, result.CyclesPerHit)
+	}
+}
+
+// WriteCyclesReport writes the default profiler report in CPU timer units.
+func WriteCyclesReport(w io.Writer) {
+	defaultProfiler.WriteCyclesReport(w)
+}
@@ -0,0 +1,42 @@
+package timer
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func TestWriteCyclesReport(t *testing.T) {
+	const want = "\n" +
+		"             total:            600 cycles\n" +
+		"              loop:              0 cycles ( 0.00%) -- calls: 1, 0.0 cycles/call\n" +
+		"                body:            600 cycles (100.00%) -- calls: 3, 200.0 cycles/call\n"
+
+	// Alike whatever the estimated frequency, even uncalibrated
+	for _, frequency := range []int64{testFrequency, 3 * testFrequency, 0} {
+		t.Run(strconv.FormatInt(frequency, 10), func(t *testing.T) {
+			var clock = useFakeClock(t)
+			freqFn = func() int64 { return frequency }
+			captureWarnings(t)
+			var p = New()
+
+			p.Start("loop")
+			for i := int64(1); i <= 3; i++ {
+				p.Start("body")
+				clock.advance(100 * i)
+				p.Stop("body")
+			}
+			p.Stop("loop")
+
+			if result := resultOf(t, p.Snapshot(), "body"); result.CyclesPerHit != 200 {
+				t.Errorf("%v cycles per hit, want 200", result.CyclesPerHit)
+			}
+
+			var buf bytes.Buffer
+			p.WriteCyclesReport(&buf)
+			if buf.String() != want {
+				t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+			}
+		})
+	}
+}
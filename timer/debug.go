@@ -0,0 +1,64 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+)
+
+/*
+DebugDump writes the internal state of the profiler to w: every anchor with
+its parent, flags and raw counters, the chain of its latest timing through
+recursive calls, and the stack of open timings. It is a maintenance aid to
+understand a surprising report, safe to call at any point of a session.
+*/
+func (p *Profiler) DebugDump(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintf(w, "profile %q: %d anchors, %d saved sessions, CPU freq %d\n",
+		p.name, p.index, len(p.sessions), GetCPUFrequency())
+	fmt.Fprintf(w, "total: start=%d tscount=%d\n", p.totalTiming.start, p.totalAnchor.tscount)
+
+	var current = "<none>"
+	if p.currentAnchor != nil {
+		current = p.currentAnchor.name
+	}
+	fmt.Fprintf(w, "current anchor: %s\n", current)
+
+	for index, anchor := range p.anchors[1 : p.index+1] {
+		var parent = "<none>"
+		if anchor.parent != nil {
+			parent = anchor.parent.name
+		}
+
+		fmt.Fprintf(w, "[%d] %q %p parent=%s depth=%d active=%t open=%d hits=%d tscount=%d bytes=%d\n",
+			index+1, anchor.name, anchor, parent, anchor.depth, anchor.active, anchor.open,
+			anchor.hits, anchor.tscount, anchor.bytes)
+
+		fmt.Fprintf(w, "    latest:")
+		for latest := anchor.latest; latest != nil; latest = latest.outer {
+			fmt.Fprintf(w, " %s", debugTiming(latest))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "open stack:")
+	for open := p.currentTiming; open != nil; open = open.previous {
+		fmt.Fprintf(w, " %s", debugTiming(open))
+	}
+	fmt.Fprintln(w)
+}
+
+func debugTiming(t *timing) string {
+	var name = "<nil>"
+	if t.anchor != nil {
+		name = t.anchor.name
+	}
+
+	return fmt.Sprintf("%s@%d(%p)", name, t.start, t)
+}
+
+// DebugDump writes the internal state of the default profiler to w.
+func DebugDump(w io.Writer) {
+	defaultProfiler.DebugDump(w)
+}
@@ -0,0 +1,96 @@
+package timer
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDebugDump(t *testing.T) {
+	var clock = useFakeClock(t)
+	captureWarnings(t)
+	var p = NewProfiler("debug")
+
+	var dump = func() string {
+		var buffer bytes.Buffer
+		p.DebugDump(&buffer)
+		return buffer.String()
+	}
+
+	if text := dump(); !strings.Contains(text, "current anchor: <none>") {
+		t.Errorf("empty profiler:\n%s", text)
+	}
+
+	// Mid-session: parent holding a recursive child
+	p.Start("parent")
+	clock.advance(100)
+	p.Start("child")
+	clock.advance(100)
+	p.Start("child")
+	clock.advance(100)
+
+	var text = dump()
+	var tests = []struct {
+		name string
+		want string
+	}{
+		{"header", `profile "debug": 2 anchors`},
+		{"current anchor", "current anchor: child"},
+		// Paused while its child runs
+		{"parent", `\[1\] "parent" 0x[0-9a-f]+ parent=<none> depth=0 active=false open=1 hits=1 tscount=100`},
+		{"child", `\[2\] "child" 0x[0-9a-f]+ parent=parent depth=1 active=\w+ open=2`},
+		{"recursive chain", `latest: child@1200\(0x[0-9a-f]+\) child@1100\(0x[0-9a-f]+\)\n`},
+		{"open stack", `open stack: child@1200\(0x[0-9a-f]+\) child@1100\(0x[0-9a-f]+\) parent@1000\(0x[0-9a-f]+\)\n`},
+	}
+	for _, test := range tests {
+		if !regexp.MustCompile(test.want).MatchString(text) {
+			t.Errorf("%s: dump doesn't match %q:\n%s", test.name, test.want, text)
+		}
+	}
+
+	p.Stop("child")
+	p.Stop("child")
+	p.Stop("parent")
+	text = dump()
+	for _, want := range []string{"current anchor: <none>", "parent=parent depth=1 active=true open=0 hits=2 tscount=200",
+		"open stack:\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("stopped profiler: dump doesn't contain %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestDebugDumpDuringRecording(t *testing.T) {
+	var clock = useFakeClock(t)
+	captureWarnings(t)
+	var p = New()
+
+	var recording sync.WaitGroup
+	var done = make(chan struct{})
+	for _, name := range []string{"a", "b", "c"} {
+		recording.Add(1)
+		go func(name string) {
+			defer recording.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				p.Start(name)
+				clock.advance(10)
+				p.Stop(name)
+			}
+		}(name)
+	}
+
+	for i := 0; i < 50; i++ {
+		p.DebugDump(io.Discard)
+	}
+	close(done)
+	recording.Wait()
+}
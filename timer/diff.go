@@ -0,0 +1,91 @@
+package timer
+
+/*
+Diff returns the activity between two snapshots of the same profile, a taken
+before b: the counters of every anchor of b, like Hits, TSCount, Bytes and
+Elapsed, minus those of the same anchor in a, e.g. to see what a server did
+during the latest interval. Per-hit averages and percentages are recomputed
+from the differences, while the statistics that can't be subtracted, like
+MinHit, MaxHit and StdDev, are left out. Anchors only found in b pass through
+unchanged. Counters are not meaningful across a reset between a and b.
+*/
+func Diff(a Report, b Report) Report {
+	var diff = b
+	diff.Anchors = make([]AnchorResult, 0, len(b.Anchors))
+	diff.Phases = nil
+
+	var before = make(map[string]AnchorResult, len(a.Anchors))
+	for _, result := range a.Anchors {
+		before[result.Name] = result
+	}
+
+	diff.Total = diffResult(a.Total, b.Total)
+	for _, result := range b.Anchors {
+		if previous, exists := before[result.Name]; exists {
+			result = diffResult(previous, result)
+		}
+
+		result.Percent = 0
+		if diff.Total.Elapsed != 0 {
+			result.Percent = 100 * result.Elapsed / diff.Total.Elapsed
+		}
+
+		diff.Anchors = append(diff.Anchors, result)
+	}
+	diff.Total.Percent = 100
+
+	diff.Hits = b.Hits - a.Hits
+	diff.Bytes = b.Bytes - a.Bytes
+	diff.Ops = b.Ops - a.Ops
+	diff.GCCount = b.GCCount - a.GCCount
+	diff.GCPause = b.GCPause - a.GCPause
+	diff.ClockAnomalies = b.ClockAnomalies - a.ClockAnomalies
+	diff.UnmatchedStops = b.UnmatchedStops - a.UnmatchedStops
+
+	diff.Throughput = 0
+	if diff.Total.Elapsed > 0 {
+		diff.Throughput = float64(diff.Bytes) / (diff.Total.Elapsed / 1000)
+	}
+	diff.OpsPerSecond = opsPerSecond(diff.Ops, diff.Total.Elapsed)
+	diff.updateMeta()
+
+	return diff
+}
+
+// diffResult subtracts the counters of a from b.
+func diffResult(a AnchorResult, b AnchorResult) AnchorResult {
+	var diff = b
+
+	diff.Hits = b.Hits - a.Hits
+	diff.Counted = b.Counted - a.Counted
+	diff.TSCount = b.TSCount - a.TSCount
+	diff.Bytes = b.Bytes - a.Bytes
+	diff.Ops = b.Ops - a.Ops
+	diff.Elapsed = b.Elapsed - a.Elapsed
+	diff.Allocs = b.Allocs - a.Allocs
+	diff.AllocBytes = b.AllocBytes - a.AllocBytes
+	diff.Wall = b.Wall - a.Wall
+	diff.Samples = b.Samples - a.Samples
+	diff.Blocked = b.Blocked - a.Blocked
+	diff.OverBudget = b.OverBudget - a.OverBudget
+
+	diff.OpsPerSecond = opsPerSecond(diff.Ops, diff.Elapsed)
+
+	diff.Mean, diff.CyclesPerHit, diff.BytesPerHit = 0, 0, 0
+	if diff.Hits > 0 {
+		diff.Mean = diff.Elapsed / float64(diff.Hits)
+		diff.CyclesPerHit = float64(diff.TSCount) / float64(diff.Hits)
+	}
+	if payloadHits := payloadHits(b) - payloadHits(a); payloadHits > 0 {
+		diff.BytesPerHit = float64(diff.Bytes) / payloadHits
+	}
+
+	diff.MinHit, diff.MaxHit = 0, 0
+	diff.MinBytes, diff.MaxBytes = 0, 0
+	diff.HitThroughput = 0
+	diff.StdDev, diff.CV = 0, 0
+	diff.P50, diff.P90, diff.P99 = 0, 0, 0
+	diff.PercentOfParent, diff.PercentOfReference = 0, 0
+
+	return diff
+}
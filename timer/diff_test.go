@@ -0,0 +1,69 @@
+package timer
+
+import "testing"
+
+func TestSnapshotDoesNotReset(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.Start("a")
+	clock.advance(100)
+	p.Stop("a")
+
+	var first, second = p.Snapshot(), p.Snapshot()
+	if resultOf(t, first, "a").Hits != 1 || resultOf(t, second, "a").Hits != 1 {
+		t.Errorf("hits %d then %d, want the same single hit", resultOf(t, first, "a").Hits,
+			resultOf(t, second, "a").Hits)
+	}
+
+	p.Start("a")
+	clock.advance(100)
+	p.Stop("a")
+	if result := resultOf(t, p.Snapshot(), "a"); result.Hits != 2 || result.TSCount != 200 {
+		t.Errorf("a: %d hits, tscount %d after the snapshots, want 2 and 200", result.Hits, result.TSCount)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.StartThroughput("a", 1000)
+	clock.advance(100)
+	p.Stop("a")
+	var before = p.Snapshot()
+
+	for i := 0; i < 3; i++ {
+		p.StartThroughput("a", 1000)
+		clock.advance(200)
+		p.Stop("a")
+	}
+	p.Start("b")
+	clock.advance(400)
+	p.Stop("b")
+	var after = p.Snapshot()
+
+	var diff = Diff(before, after)
+
+	var a = resultOf(t, diff, "a")
+	if a.Hits != 3 || a.TSCount != 600 || a.Bytes != 3000 || a.CyclesPerHit != 200 {
+		t.Errorf("a: %d hits, tscount %d, %d bytes, %v cycles per hit, want 3, 600, 3000 and 200", a.Hits,
+			a.TSCount, a.Bytes, a.CyclesPerHit)
+	}
+	if a.MinHit != 0 || a.MaxHit != 0 || a.StdDev != 0 {
+		t.Errorf("a: min %v, max %v, stddev %v, want them left out", a.MinHit, a.MaxHit, a.StdDev)
+	}
+	if a.Percent != 60 {
+		t.Errorf("a: %v%% of the interval, want 60%%", a.Percent)
+	}
+
+	// Only found in the later snapshot
+	if b := resultOf(t, diff, "b"); b.Hits != 1 || b.TSCount != 400 || b.Percent != 40 {
+		t.Errorf("b: %+v, want its single hit of 400 units at 40%%", b)
+	}
+
+	if diff.Total.TSCount != 1000 || diff.Hits != 4 || diff.Bytes != 3000 {
+		t.Errorf("total tscount %d, %d hits, %d bytes, want 1000, 4 and 3000", diff.Total.TSCount, diff.Hits,
+			diff.Bytes)
+	}
+}
@@ -0,0 +1,159 @@
+package timer
+
+import (
+	"math/bits"
+	"time"
+)
+
+// Each power of two of CPU timer units is split into distributionSubBuckets
+// buckets, bounding the error of a percentile to 1/32 of its value.
+const (
+	distributionSubBits    = 4
+	distributionSubBuckets = 1 << distributionSubBits
+	distributionBuckets    = distributionSubBuckets * (64 - distributionSubBits)
+)
+
+// distribution counts the hits of an anchor per range of CPU timer units, in
+// a fixed number of log-linear buckets whatever the number of hits.
+type distribution struct {
+	count   int64
+	buckets [distributionBuckets]int64
+}
+
+func distributionBucket(tscount int64) int {
+	var value = uint64(nonNegative(tscount))
+	if value < distributionSubBuckets {
+		return int(value)
+	}
+
+	var shift = bits.Len64(value) - distributionSubBits - 1
+	return distributionSubBuckets*(shift+1) + int(value>>uint(shift)) - distributionSubBuckets
+}
+
+// distributionBucketMiddle returns the middle of the range of CPU timer units
+// counted by the bucket.
+func distributionBucketMiddle(bucket int) float64 {
+	if bucket < distributionSubBuckets {
+		return float64(bucket)
+	}
+
+	var shift = bucket/distributionSubBuckets - 1
+	var low = uint64(distributionSubBuckets+bucket%distributionSubBuckets) << uint(shift)
+	return float64(low) + float64(uint64(1)<<uint(shift))/2
+}
+
+func (d *distribution) add(tscount int64) {
+	d.count = d.count + 1
+	d.buckets[distributionBucket(tscount)]++
+}
+
+// percentile returns the CPU timer units below which q of the hits fall, q
+// being between 0 and 1.
+func (d *distribution) percentile(q float64) float64 {
+	if d.count == 0 {
+		return 0
+	}
+
+	var rank = int64(q*float64(d.count) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen int64
+	for bucket, count := range d.buckets {
+		seen = seen + count
+		if seen >= rank {
+			return distributionBucketMiddle(bucket)
+		}
+	}
+
+	return distributionBucketMiddle(distributionBuckets - 1)
+}
+
+/*
+TrackDistribution records the time of every hit of the named anchor into a
+histogram, for Output and AnchorResult to report its median, 90th and 99th
+percentiles, which an average hides for bimodal timings. The histogram takes a
+few kilobytes per tracked anchor whatever its hits, and percentiles are
+within 1/32 of the actual value. Untracked anchors record no distribution; it
+can be set before the anchor is first started. The hdr subpackage records
+finer, configurable histograms of the time including children.
+*/
+func (p *Profiler) TrackDistribution(anchorName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return
+	}
+
+	if p.distributions == nil {
+		p.distributions = make(map[string]bool)
+	}
+
+	p.distributions[key] = true
+}
+
+// addHit records the CPU timer units of a single stopped hit of anchor.
+func (p *Profiler) addHit(anchor *anchor, tscount int64) {
+	anchor.hitRange.add(tscount)
+	p.checkBudget(anchor, tscount)
+
+	if !p.distributions[anchor.name] {
+		return
+	}
+
+	if anchor.distribution == nil {
+		anchor.distribution = &distribution{}
+	}
+
+	anchor.distribution.add(tscount)
+}
+
+// percentiles returns the median, 90th and 99th percentiles of the time per
+// hit of anchor, in milliseconds, zero when its distribution is not tracked.
+func (p *Profiler) percentiles(anchor *anchor) (p50 float64, p90 float64, p99 float64) {
+	if anchor.distribution == nil {
+		return 0, 0, 0
+	}
+
+	var tick = p.milliseconds(1)
+	return tick * anchor.distribution.percentile(0.5), tick * anchor.distribution.percentile(0.9),
+		tick * anchor.distribution.percentile(0.99)
+}
+
+/*
+Percentile returns the time below which q of the hits of the named anchor
+fall, q being between 0 and 1, e.g. 0.99 for the 99th percentile. It is false
+when the anchor is unknown or its distribution was never tracked with
+TrackDistribution.
+*/
+func (p *Profiler) Percentile(anchorName string, q float64) (time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return 0, false
+	}
+
+	var anchor, exists = p.anchorsByName[key]
+	if !exists || anchor.distribution == nil {
+		return 0, false
+	}
+
+	return ticksToDuration(int64(anchor.distribution.percentile(q))), true
+}
+
+// TrackDistribution records the time per hit distribution of an anchor of
+// the default profiler.
+func TrackDistribution(anchorName string) {
+	defaultProfiler.TrackDistribution(anchorName)
+}
+
+// Percentile returns the time below which q of the hits of the named anchor
+// of the default profiler fall, see Profiler.Percentile.
+func Percentile(anchorName string, q float64) (time.Duration, bool) {
+	return defaultProfiler.Percentile(anchorName, q)
+}
@@ -0,0 +1,60 @@
+package timer
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDistributionBucketError(t *testing.T) {
+	for _, tscount := range []int64{0, 1, 15, 16, 17, 100, 1000, 123456, 1 << 40, math.MaxInt64} {
+		var middle = distributionBucketMiddle(distributionBucket(tscount))
+		if math.Abs(middle-float64(tscount)) > float64(tscount)/32 {
+			t.Errorf("%d ticks counted in a bucket centered on %v", tscount, middle)
+		}
+	}
+}
+
+func TestTrackDistribution(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.TrackDistribution("query")
+
+	// Bimodal, hidden by the average
+	for i := 0; i < 1000; i++ {
+		var ticks int64 = 1000000
+		if i%10 == 0 {
+			ticks = 100000000
+		}
+		for _, name := range []string{"query", "other"} {
+			p.Start(name)
+			clock.advance(ticks)
+			p.Stop(name)
+		}
+	}
+
+	var query = resultOf(t, p.Snapshot(), "query")
+	for _, percentile := range []struct {
+		name      string
+		got, want float64
+	}{{"p50", query.P50, 1}, {"p90", query.P90, 1}, {"p99", query.P99, 100}} {
+		if math.Abs(percentile.got-percentile.want) > percentile.want/32 {
+			t.Errorf("%s = %vms, want %vms", percentile.name, percentile.got, percentile.want)
+		}
+	}
+	if d, ok := p.Percentile("query", 0.99); !ok || d < 96*time.Millisecond || d > 104*time.Millisecond {
+		t.Errorf("Percentile(0.99) = %v, %v, want about 100ms", d, ok)
+	}
+
+	// Opt-in
+	if other := resultOf(t, p.Snapshot(), "other"); other.P50 != 0 || other.P99 != 0 {
+		t.Errorf("untracked anchor has percentiles %v and %v", other.P50, other.P99)
+	}
+	if _, ok := p.Percentile("other", 0.5); ok {
+		t.Error("untracked anchor has a percentile")
+	}
+	if text := output(p); strings.Count(text, "p50:") != 1 {
+		t.Errorf("want percentiles for the tracked anchor only:\n%s", text)
+	}
+}
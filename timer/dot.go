@@ -0,0 +1,65 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+WriteDot writes the anchor hierarchy to w as a Graphviz DOT directed graph,
+to be rendered with e.g. `dot -Tsvg`. Each node is an anchor labeled with its
+elapsed time and percentage of the total, filled redder the larger that
+percentage. Edges go from each anchor to the anchors first started in it,
+labeled with the calls of the child; top-level anchors hang from the total.
+*/
+func (p *Profiler) WriteDot(w io.Writer) error {
+	if profilingDisabled() {
+		return nil
+	}
+
+	var report = p.Snapshot()
+
+	var ids = make(map[string]string, len(report.Anchors))
+	fmt.Fprintln(w, "digraph timer {")
+	fmt.Fprintln(w, "\tnode [shape=box, style=filled];")
+	fmt.Fprintf(w, "\ttotal [label=\"%s\\n%.3fms\", fillcolor=\"0.000 0.000 1.000\"];\n",
+		dotEscape(report.Total.Name), report.Total.Elapsed)
+
+	for i, result := range report.Anchors {
+		var id = fmt.Sprintf("a%d", i)
+		ids[result.Name] = id
+
+		var saturation = result.Percent / 100
+		if saturation > 1 {
+			saturation = 1
+		} else if saturation < 0 {
+			saturation = 0
+		}
+
+		fmt.Fprintf(w, "\t%s [label=\"%s\\n%.3fms (%.2f%%)\", fillcolor=\"0.000 %.3f 1.000\"];\n",
+			id, dotEscape(result.Name), result.Elapsed, result.Percent, saturation)
+	}
+
+	for _, result := range report.Anchors {
+		var parent, exists = ids[result.ParentName]
+		if !exists || result.ParentName == "" {
+			parent = "total"
+		}
+
+		fmt.Fprintf(w, "\t%s -> %s [label=\"calls: %d\"];\n", parent, ids[result.Name], result.Hits)
+	}
+
+	var _, err = fmt.Fprintln(w, "}")
+	return err
+}
+
+// dotEscape escapes name for a double-quoted DOT string
+func dotEscape(name string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(name)
+}
+
+// WriteDot writes the default profiler hierarchy as a Graphviz DOT graph.
+func WriteDot(w io.Writer) error {
+	return defaultProfiler.WriteDot(w)
+}
@@ -0,0 +1,21 @@
+package timer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteDotGolden(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	recordFormats(p, clock)
+	p.Start(`say "hi" \ bye`)
+	clock.advance(1000000)
+	p.Stop(`say "hi" \ bye`)
+
+	var buffer bytes.Buffer
+	if err := p.WriteDot(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	checkGoldenFile(t, "testdata/report.dot", buffer.Bytes())
+}
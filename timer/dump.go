@@ -0,0 +1,41 @@
+package timer
+
+import "sync/atomic"
+
+// Latest Report captured by CaptureForDump or StartAutoFlush
+var lastSnapshot atomic.Value
+
+/*
+CaptureForDump copies the current state of the profile where LastSnapshot
+finds it, e.g. from a crash handler that can't rely on the profiler lock
+anymore. StartAutoFlush captures the profile this way before each reset, so
+that the latest interval is always available.
+*/
+func (p *Profiler) CaptureForDump() {
+	lastSnapshot.Store(p.Snapshot())
+}
+
+/*
+LastSnapshot returns the latest report captured by CaptureForDump or
+StartAutoFlush, of whichever profiler, and false if none was captured. It
+doesn't lock any profiler, so it is safe to call from a panic handler:
+
+	defer func() {
+		if r := recover(); r != nil {
+			if report, captured := timer.LastSnapshot(); captured {
+				json.NewEncoder(os.Stderr).Encode(report)
+			}
+			panic(r)
+		}
+	}()
+*/
+func LastSnapshot() (Report, bool) {
+	var report, captured = lastSnapshot.Load().(Report)
+	return report, captured
+}
+
+// CaptureForDump copies the current state of the default profiler for
+// LastSnapshot.
+func CaptureForDump() {
+	defaultProfiler.CaptureForDump()
+}
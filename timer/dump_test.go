@@ -0,0 +1,56 @@
+package timer
+
+import (
+	"io"
+	"testing"
+)
+
+func TestCaptureForDump(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = NewProfiler("dump")
+
+	p.Start("a")
+	clock.advance(100)
+	p.Stop("a")
+	p.CaptureForDump()
+
+	// Later hits don't show in the captured copy
+	p.Start("a")
+	clock.advance(100)
+	p.Stop("a")
+	p.Start("b")
+
+	// Doesn't wait for the lock a crash may leave held
+	p.mu.Lock()
+	var report, captured = LastSnapshot()
+	p.mu.Unlock()
+
+	if !captured || report.Name != "dump" {
+		t.Fatalf("LastSnapshot = %q, %v, want the report of dump", report.Name, captured)
+	}
+	if len(report.Anchors) != 1 || report.Anchors[0].Hits != 1 || report.Anchors[0].TSCount != 100 {
+		t.Errorf("captured anchors %+v, want a single hit of a", report.Anchors)
+	}
+}
+
+func TestFlushCapturesForDump(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = NewProfiler("flushed")
+
+	p.Start("a")
+	clock.advance(100)
+	p.Stop("a")
+	p.FlushTo(io.Discard)
+
+	var report, captured = LastSnapshot()
+	if !captured || report.Name != "flushed" {
+		t.Fatalf("LastSnapshot = %q, %v, want the report of flushed", report.Name, captured)
+	}
+	// Taken before the counters were reset
+	if result := resultOf(t, report, "a"); result.Hits != 1 || result.TSCount != 100 {
+		t.Errorf("captured a %+v, want a single hit of 100 units", result)
+	}
+	if result := resultOf(t, p.Snapshot(), "a"); result.Hits != 0 {
+		t.Errorf("a kept %d hits after the flush", result.Hits)
+	}
+}
@@ -0,0 +1,97 @@
+package timer
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Non-zero when profiling is disabled, read from TIMER_ENV_VAR at startup
+var disabled int32
+
+func init() {
+	var enabled, verboseCalibration = parseTimerEnv(os.Getenv(TIMER_ENV_VAR))
+	if !enabled {
+		disabled = 1
+	}
+	if verboseCalibration {
+		SetVerbose(true)
+	}
+}
+
+/*
+parseTimerEnv interprets the value of TIMER_ENV_VAR: "0", "false", "off" and
+"no" disable profiling, "1", "true", "on" and "yes" enable it, as an empty
+value does, and "verbose" enables it with the calibration diagnostics. Case
+and surrounding spaces are ignored; other values warn and enable profiling.
+*/
+func parseTimerEnv(value string) (enabled bool, verboseCalibration bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "0", "false", "off", "no":
+		return false, false
+	case "", "1", "true", "on", "yes":
+		return true, false
+	case "verbose":
+		return true, true
+	default:
+		warn("timer: unknown %s value %q, profiling enabled", TIMER_ENV_VAR, value)
+		return true, false
+	}
+}
+
+// profilingDisabled reports whether every profiling function must be a no-op.
+func profilingDisabled() bool {
+	return atomic.LoadInt32(&disabled) != 0
+}
+
+/*
+Disable turns every profiling function of every profiler into a no-op, as
+setting the TIMER environment variable to "0", "false", "off" or "no" does.
+The variable is read once at startup rather than on every call, which took a
+lookup in the environment on the measured path: changing it afterwards has no
+effect, use Disable and Enable instead.
+
+Anchors open when profiling is disabled or enabled again get unmatched Start
+or Stop calls; toggle it between profiled phases.
+*/
+func Disable() {
+	atomic.StoreInt32(&disabled, 1)
+}
+
+// Enable turns profiling back on after Disable, or when the TIMER environment
+// variable disabled it at startup.
+func Enable() {
+	atomic.StoreInt32(&disabled, 0)
+}
+
+/*
+SetEnabled calls Enable or Disable, e.g. from an admin endpoint turning
+profiling on for a while in a long-running server. The TIMER environment
+variable only sets the state at startup.
+*/
+func SetEnabled(enabled bool) {
+	if enabled {
+		Enable()
+	} else {
+		Disable()
+	}
+}
+
+// Enabled reports whether profiling is enabled, as set by the TIMER
+// environment variable or later by Enable, Disable and SetEnabled.
+func Enabled() bool {
+	return !profilingDisabled()
+}
+
+/*
+WithoutProfiling calls fn with profiling disabled, e.g. around a library whose
+own anchors would clutter the report, then restores the previous state, even
+if fn panics. Like Disable, it applies to every goroutine while fn runs.
+Anchors must not be opened inside fn and closed outside, or the reverse.
+*/
+func WithoutProfiling(fn func()) {
+	var previous = atomic.SwapInt32(&disabled, 1)
+	defer atomic.StoreInt32(&disabled, previous)
+
+	fn()
+}
@@ -0,0 +1,187 @@
+package timer
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func BenchmarkStartStop(b *testing.B) {
+	var benchmarks = []struct {
+		name    string
+		enabled bool
+	}{
+		{"enabled", true},
+		{"disabled", false},
+	}
+
+	for _, benchmark := range benchmarks {
+		b.Run(benchmark.name, func(b *testing.B) {
+			var p = New()
+			p.Start("hot")
+			p.Stop("hot")
+
+			SetEnabled(benchmark.enabled)
+			b.Cleanup(Enable)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p.Start("hot")
+				p.Stop("hot")
+			}
+		})
+	}
+}
+
+// BenchmarkDisabledCheck compares the check done by every Start and Stop to
+// the environment lookup it replaced.
+func BenchmarkDisabledCheck(b *testing.B) {
+	b.Run("atomic", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			profilingDisabled()
+		}
+	})
+
+	b.Run("getenv", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			os.Getenv(TIMER_ENV_VAR)
+		}
+	})
+}
+
+func TestDisabledStartStopIsNoOp(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	Disable()
+	t.Cleanup(Enable)
+	p.Start("a")
+	clock.advance(10)
+	p.Stop("a")
+
+	if count := p.AnchorCount(); count != 0 {
+		t.Errorf("%d anchors recorded while disabled", count)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		p.Start("a")
+		p.Stop("a")
+	}); allocs != 0 {
+		t.Errorf("%v allocations per disabled Start and Stop", allocs)
+	}
+}
+
+func TestWithoutProfiling(t *testing.T) {
+	var tests = []struct {
+		name    string
+		enabled bool
+		panics  bool
+	}{
+		{"enabled", true, false},
+		{"disabled", false, false},
+		{"panicking", true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			SetEnabled(test.enabled)
+			t.Cleanup(Enable)
+
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != test.panics {
+						t.Errorf("recovered %v", r)
+					}
+				}()
+				WithoutProfiling(func() {
+					if Enabled() {
+						t.Error("enabled inside WithoutProfiling")
+					}
+					p.Start("library")
+					clock.advance(10)
+					p.Stop("library")
+					if test.panics {
+						panic("library")
+					}
+				})
+			}()
+
+			if Enabled() != test.enabled {
+				t.Errorf("enabled = %v after WithoutProfiling, want %v", Enabled(), test.enabled)
+			}
+			if count := p.AnchorCount(); count != 0 {
+				t.Errorf("%d anchors recorded inside WithoutProfiling", count)
+			}
+		})
+	}
+}
+
+func TestParseTimerEnv(t *testing.T) {
+	var tests = []struct {
+		value   string
+		enabled bool
+		verbose bool
+		warned  bool
+	}{
+		{"", true, false, false},
+		{"0", false, false, false},
+		{"false", false, false, false},
+		{" OFF ", false, false, false},
+		{"no", false, false, false},
+		{"1", true, false, false},
+		{"True", true, false, false},
+		{"on", true, false, false},
+		{"yes", true, false, false},
+		{"verbose", true, true, false},
+		{"disabled", true, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			var captured = captureWarnings(t)
+
+			var enabled, verbose = parseTimerEnv(test.value)
+			if enabled != test.enabled || verbose != test.verbose {
+				t.Errorf("enabled %v, verbose %v, want %v and %v", enabled, verbose, test.enabled, test.verbose)
+			}
+			if warned := strings.Contains(captured.String(), "unknown TIMER value"); warned != test.warned {
+				t.Errorf("warned %v, want %v: %q", warned, test.warned, captured.String())
+			}
+		})
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	var clock = useFakeClock(t)
+	t.Cleanup(Enable)
+	var p = New()
+
+	p.Start("a")
+	clock.advance(10)
+	SetEnabled(false)
+	if Enabled() {
+		t.Error("enabled after SetEnabled(false)")
+	}
+	// Every entry point is a no-op
+	p.Stop("a")
+	p.Start("b")
+	if text := output(p); text != "" {
+		t.Errorf("disabled Output wrote:\n%s", text)
+	}
+
+	SetEnabled(true)
+	if !Enabled() {
+		t.Error("disabled after SetEnabled(true)")
+	}
+	p.Stop("a")
+
+	var report = p.Snapshot()
+	if result := resultOf(t, report, "a"); result.Hits != 1 || result.Open {
+		t.Errorf("a: %d hits, open %v, want 1 and closed", result.Hits, result.Open)
+	}
+	if count := p.AnchorCount(); count != 1 {
+		t.Errorf("%d anchors, want b left out", count)
+	}
+}
@@ -0,0 +1,57 @@
+package timer
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrUnknownAnchor is returned by StopE when the anchor name was never
+	// started.
+	ErrUnknownAnchor = errors.New("unknown anchor")
+
+	// ErrStackUnderflow is returned by StopE when the anchor is known but has
+	// no open Start left to close, e.g. when it is stopped twice.
+	ErrStackUnderflow = errors.New("stack underflow")
+
+	// ErrTooManyAnchors is returned by StartE when registering the anchor
+	// would exceed maxHandledAnchors, or the limit set by SetMaxAnchors. The
+	// anchor is not recorded. Once the limit is reached, StopE returns it
+	// instead of ErrUnknownAnchor since the anchor was probably dropped.
+	ErrTooManyAnchors = errors.New("too many anchors")
+
+	// ErrNameTooLong is returned by StartE and StopE when the anchor name
+	// exceeds the maximum length under the RejectLongNames policy.
+	ErrNameTooLong = errors.New("anchor name too long")
+
+	// ErrParentMismatch is returned by StartE under SetStrictNames when the
+	// anchor is started under a different parent than the first time. The
+	// hit is recorded nonetheless.
+	ErrParentMismatch = errors.New("anchor started under a different parent")
+
+	// ErrInconsistentState is returned by Validate when the internal state of
+	// the profiler violates one of its invariants, which is a bug.
+	ErrInconsistentState = errors.New("inconsistent profiler state")
+
+	// ErrUnbalanced is returned by Validate when anchors are still open, a
+	// Start missing its Stop.
+	ErrUnbalanced = errors.New("anchors left open")
+)
+
+/*
+AnchorError describes a profiler misuse on a given anchor. Err is one of the
+package sentinel errors, use errors.Is to branch on it.
+*/
+type AnchorError struct {
+	Op     string
+	Anchor string
+	Err    error
+}
+
+func (e *AnchorError) Error() string {
+	return "timer: " + e.Op + " " + strconv.Quote(e.Anchor) + ": " + e.Err.Error()
+}
+
+func (e *AnchorError) Unwrap() error {
+	return e.Err
+}
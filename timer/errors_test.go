@@ -0,0 +1,85 @@
+package timer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAnchorErrors(t *testing.T) {
+	var tests = []struct {
+		name    string
+		setup   func(p *Profiler)
+		run     func(p *Profiler) error
+		op      string
+		anchor  string
+		want    error
+		message string
+	}{
+		{"unknown", func(p *Profiler) {}, func(p *Profiler) error {
+			return p.StopE("a")
+		}, "stop", "a", ErrUnknownAnchor, `timer: stop "a": unknown anchor`},
+		{"stopped twice", func(p *Profiler) {}, func(p *Profiler) error {
+			p.Start("a")
+			p.Stop("a")
+			return p.StopE("a")
+		}, "stop", "a", ErrStackUnderflow, `timer: stop "a": stack underflow`},
+		{"too many anchors", func(p *Profiler) {
+			p.SetMaxAnchors(1)
+		}, func(p *Profiler) error {
+			if err := p.StartE("a"); err != nil {
+				return err
+			}
+			return p.StartE("b")
+		}, "start", "b", ErrTooManyAnchors, `timer: start "b": too many anchors`},
+		{"dropped anchor", func(p *Profiler) {
+			p.SetMaxAnchors(1)
+		}, func(p *Profiler) error {
+			p.Start("a")
+			p.Start("b")
+			return p.StopE("b")
+		}, "stop", "b", ErrTooManyAnchors, `timer: stop "b": too many anchors`},
+		{"name too long", func(p *Profiler) {
+			p.SetMaxNameLength(4)
+			p.SetNameTooLongPolicy(RejectLongNames)
+		}, func(p *Profiler) error {
+			return p.StartE("toolong")
+		}, "start", "toolong", ErrNameTooLong, `timer: start "toolong": anchor name too long`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			test.setup(p)
+
+			var err = test.run(p)
+			if !errors.Is(err, test.want) {
+				t.Fatalf("err = %v, want %v", err, test.want)
+			}
+			var anchorErr *AnchorError
+			if !errors.As(err, &anchorErr) || anchorErr.Op != test.op || anchorErr.Anchor != test.anchor {
+				t.Errorf("err = %#v, want an *AnchorError on %s %q", err, test.op, test.anchor)
+			}
+			if err.Error() != test.message {
+				t.Errorf("message %q, want %q", err.Error(), test.message)
+			}
+		})
+	}
+}
+
+func TestStartStopE(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	if err := p.StartE("a"); err != nil {
+		t.Errorf("StartE: %v", err)
+	}
+	clock.advance(100)
+	if err := p.StopE("a"); err != nil {
+		t.Errorf("StopE: %v", err)
+	}
+	if result := resultOf(t, p.Snapshot(), "a"); result.Hits != 1 || result.TSCount != 100 {
+		t.Errorf("%d hits of %d ticks, want 1 of 100", result.Hits, result.TSCount)
+	}
+}
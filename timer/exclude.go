@@ -0,0 +1,63 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+SetExcludedFromTotal leaves the named anchor out of the percentages summing to
+the total: Output lists it separately below the other anchors, and its time is
+not deducted from the unaccounted time, which is then computed as if the
+anchor did not exist. This suits cross-cutting anchors, such as a global
+logging timer recorded with RecordDuration, that overlap with everything else
+and would otherwise push the summed percentages over 100%. Anchors are included
+by default; it can be set before the anchor is first started.
+*/
+func (p *Profiler) SetExcludedFromTotal(anchorName string, excluded bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return
+	}
+
+	if !excluded {
+		delete(p.excludedFromTotal, key)
+		return
+	}
+
+	if p.excludedFromTotal == nil {
+		p.excludedFromTotal = make(map[string]bool)
+	}
+
+	p.excludedFromTotal[key] = true
+}
+
+// writeExcluded lists the anchors excluded from the total whose name starts
+// with prefix, in the given order.
+func (p *Profiler) writeExcluded(w io.Writer, prefix string, order OutputOrder) {
+	var header bool
+	for _, anchor := range p.outputAnchors(order) {
+		if !p.excludedFromTotal[anchor.name] || !strings.HasPrefix(anchor.name, prefix) || p.belowMinHits(anchor) {
+			continue
+		}
+
+		if !header {
+			fmt.Fprintf(w, "%*s:\n", p.nameLength(), "excluded from total")
+			header = true
+		}
+
+		fmt.Fprintf(w, "%*s: %s (%s of total) -- calls: %d, avg: %s%s\n", p.nameLength()+2, p.displayName(anchor.name),
+			p.formatElapsed(p.milliseconds(anchor.tscount)), formatPercent(anchor.tscount, p.totalAnchor.tscount),
+			anchor.hits, p.formatMean(anchor), p.details(anchor))
+	}
+}
+
+// SetExcludedFromTotal leaves an anchor of the default profiler out of the
+// percentage base.
+func SetExcludedFromTotal(anchorName string, excluded bool) {
+	defaultProfiler.SetExcludedFromTotal(anchorName, excluded)
+}
@@ -0,0 +1,70 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExcludedFromTotal(t *testing.T) {
+	var tests = []struct {
+		name     string
+		excluded bool
+		lines    []string
+	}{
+		{"included", false, []string{
+			"           logging:      2.000ms (40.00%) -- calls: 1, avg: 2.000ms",
+			"unaccounted:      0.000ms ( 0.00%) -- clamped, anchors exceed the total",
+		}},
+		{"excluded", true, []string{
+			"excluded from total:\n             logging:      2.000ms (40.00% of total) -- calls: 1, avg: 2.000ms\n",
+			"       unaccounted:      1.000ms (20.00%)",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			p.SetExcludedFromTotal("logging", test.excluded)
+			p.Start("work")
+			clock.advance(4000000)
+			p.Stop("work")
+			// Unaccounted
+			clock.advance(1000000)
+			p.Start("flush")
+			p.Stop("flush")
+			p.RecordDuration("logging", 2*time.Millisecond)
+
+			var result = resultOf(t, p.Snapshot(), "logging")
+			if result.ExcludedFromTotal != test.excluded {
+				t.Errorf("excluded %v, want %v", result.ExcludedFromTotal, test.excluded)
+			}
+
+			var text = output(p)
+			for _, line := range test.lines {
+				if !strings.Contains(text, line) {
+					t.Errorf("report doesn't contain %q:\n%s", line, text)
+				}
+			}
+		})
+	}
+}
+
+func TestExcludedFromTotalReverted(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.SetExcludedFromTotal("logging", true)
+	p.SetExcludedFromTotal("logging", false)
+	p.Start("logging")
+	clock.advance(10)
+	p.Stop("logging")
+
+	if resultOf(t, p.Snapshot(), "logging").ExcludedFromTotal {
+		t.Error("anchor still excluded")
+	}
+	if text := output(p); strings.Contains(text, "excluded from total") {
+		t.Errorf("report lists excluded anchors:\n%s", text)
+	}
+}
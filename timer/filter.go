@@ -0,0 +1,54 @@
+package timer
+
+import (
+	"io"
+	"strings"
+)
+
+/*
+WriteFiltered writes the same report as Output to w, only listing the anchors
+whose name starts with prefix, e.g. that of a subsystem. Percentages are still
+relative to the whole total.
+*/
+func (p *Profiler) WriteFiltered(w io.Writer, prefix string) {
+	if profilingDisabled() {
+		return
+	}
+
+	var report = reportBuffer{destination: w}
+
+	p.mu.Lock()
+	p.write(&report, writeOptions{prefix: prefix})
+	p.mu.Unlock()
+
+	w.Write(report.Bytes())
+}
+
+// ResultsFiltered returns the results of the anchors whose name starts with
+// prefix, their percentages being relative to the whole total, leaving out
+// the anchors under SetMinHits.
+func (p *Profiler) ResultsFiltered(prefix string) []AnchorResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sumSubtrees()
+
+	var results []AnchorResult
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		if strings.HasPrefix(anchor.name, prefix) && !p.belowMinHits(anchor) {
+			results = append(results, p.result(anchor))
+		}
+	}
+
+	return results
+}
+
+// WriteFiltered writes the default profiler anchors starting with prefix.
+func WriteFiltered(w io.Writer, prefix string) {
+	defaultProfiler.WriteFiltered(w, prefix)
+}
+
+// ResultsFiltered returns the default profiler anchors starting with prefix.
+func ResultsFiltered(prefix string) []AnchorResult {
+	return defaultProfiler.ResultsFiltered(prefix)
+}
@@ -0,0 +1,53 @@
+package timer
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFiltered(t *testing.T) {
+	var names = []string{"db.query", "http.read", "db.commit"}
+	var tests = []struct {
+		prefix string
+		want   []string
+	}{
+		{"", names},
+		{"db.", []string{"db.query", "db.commit"}},
+		{"grpc.", nil},
+	}
+
+	for _, test := range tests {
+		var clock = useFakeClock(t)
+		var p = New()
+		for _, name := range names {
+			p.Start(name)
+			clock.advance(100)
+			p.Stop(name)
+		}
+
+		var results = p.ResultsFiltered(test.prefix)
+		var listed []string
+		for _, result := range results {
+			listed = append(listed, result.Name)
+			// Relative to the whole total
+			if result.Percent < 33.33 || result.Percent > 33.34 {
+				t.Errorf("%q: %s at %v%%, want a third of the total", test.prefix, result.Name, result.Percent)
+			}
+		}
+		if !reflect.DeepEqual(listed, test.want) {
+			t.Errorf("%q: results %v, want %v", test.prefix, listed, test.want)
+		}
+
+		var buf bytes.Buffer
+		p.WriteFiltered(&buf, test.prefix)
+		var text = buf.String()
+		if rows := anchorRows(text, names...); !reflect.DeepEqual(rows, test.want) {
+			t.Errorf("%q: rows %v, want %v", test.prefix, rows, test.want)
+		}
+		if test.prefix != "" && !strings.Contains(text, "filtered out: ") {
+			t.Errorf("%q: report doesn't tell anchors were left out:\n%s", test.prefix, text)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package timer
+
+import "sort"
+
+/*
+SetFlatOutput makes Output list every anchor at the left margin, ignoring the
+hierarchy, sorted by decreasing time including children, which reads as a
+"top functions" view. Each line shows that inclusive time first, then the
+exclusive time of the anchor itself, both relative to the total. The order
+set by SetOutputOrder is ignored while enabled.
+*/
+func (p *Profiler) SetFlatOutput(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.flatOutput = enabled
+}
+
+// flatAnchors returns the anchors by decreasing subtree time, as of the
+// latest sumSubtrees.
+func (p *Profiler) flatAnchors() []*anchor {
+	var anchors = append([]*anchor(nil), p.anchors[1:p.index+1]...)
+	sort.SliceStable(anchors, func(i, j int) bool {
+		return anchors[i].subtree > anchors[j].subtree
+	})
+
+	return anchors
+}
+
+// SetFlatOutput makes the default profiler Output list the anchors flat.
+func SetFlatOutput(enabled bool) {
+	defaultProfiler.SetFlatOutput(enabled)
+}
@@ -0,0 +1,37 @@
+package timer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlatOutput(t *testing.T) {
+	var names = []string{"a", "b", "c", "d", "x", "y"}
+	var clock = useFakeClock(t)
+	var p = New()
+	runTree(p, clock)
+	p.SetFlatOutput(true)
+	p.SetOutputOrder(OrderName)
+
+	// By inclusive time, whatever the output order
+	var text = output(p)
+	if rows := anchorRows(text, names...); !reflect.DeepEqual(rows, []string{"b", "c", "y", "a", "d", "x"}) {
+		t.Errorf("rows %v, want by decreasing inclusive time:\n%s", rows, text)
+	}
+	if !strings.Contains(text, " c:      0.001ms (47.17%) incl.,      0.001ms (28.30%) excl. -- calls: 1") {
+		t.Errorf("c doesn't show both its inclusive and exclusive times:\n%s", text)
+	}
+
+	// Children at the same margin as their parents
+	var column = -1
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.Contains(line, " incl., ") {
+			continue
+		}
+		if column != -1 && strings.Index(line, ":") != column {
+			t.Errorf("line %q indented", line)
+		}
+		column = strings.Index(line, ":")
+	}
+}
@@ -0,0 +1,137 @@
+package timer
+
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
+/*
+StartAutoFlush starts a goroutine that, every interval, writes the report to w
+in the format selected by TIMER_FORMAT_ENV_VAR, then resets the counters, as
+OutputAndReset does, so that each report covers one interval. The report is
+formatted under the lock and written after releasing it, so a slow writer
+doesn't hold the profiled code, and kept for LastSnapshot. Starting it again
+replaces the previous goroutine.
+*/
+func (p *Profiler) StartAutoFlush(interval time.Duration, w io.Writer) {
+	p.StopAutoFlush()
+
+	if interval <= 0 {
+		return
+	}
+
+	var done = make(chan struct{})
+	var finished = make(chan struct{})
+
+	p.mu.Lock()
+	p.flushDone = done
+	p.flushFinished = finished
+	p.mu.Unlock()
+
+	go func() {
+		defer close(finished)
+
+		var ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var buffer bytes.Buffer
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				buffer.Reset()
+				if p.flush(done, &buffer) {
+					w.Write(buffer.Bytes())
+				}
+			}
+		}
+	}()
+}
+
+// flush formats the report into buffer and resets the counters, unless the
+// flushing was stopped or profiling is disabled.
+func (p *Profiler) flush(done chan struct{}, buffer *bytes.Buffer) bool {
+	if profilingDisabled() {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	select {
+	case <-done:
+		// Stopped while waiting for the lock
+		return false
+	default:
+	}
+
+	p.flushInto(buffer)
+	return true
+}
+
+/*
+FlushTo writes the report to w in the format selected by TIMER_FORMAT_ENV_VAR,
+then resets the counters, as a single locked operation: a Start from another
+goroutine lands either in this report or in the next one, whereas it could be
+lost between Output and ResetCounters. The calibrated CPU frequency is kept,
+so that the next interval doesn't pay for the calibration again. The report
+is kept for LastSnapshot and written once the lock is released, as OutputTo
+does.
+*/
+func (p *Profiler) FlushTo(w io.Writer) {
+	if profilingDisabled() {
+		return
+	}
+
+	var report = reportBuffer{destination: w}
+
+	p.mu.Lock()
+	p.flushInto(&report)
+	p.mu.Unlock()
+
+	w.Write(report.Bytes())
+}
+
+// flushInto formats the report to w, keeps its snapshot and resets the
+// counters.
+func (p *Profiler) flushInto(w io.Writer) {
+	p.outputFormat(w)
+	lastSnapshot.Store(p.snapshot())
+	p.resetCounters()
+}
+
+/*
+StopAutoFlush stops the goroutine started by StartAutoFlush, if any, and waits
+for it to exit: nothing is written to the writer once it returns.
+*/
+func (p *Profiler) StopAutoFlush() {
+	p.mu.Lock()
+	var done, finished = p.flushDone, p.flushFinished
+	p.flushDone = nil
+	p.flushFinished = nil
+	p.mu.Unlock()
+
+	if done == nil {
+		return
+	}
+
+	close(done)
+	<-finished
+}
+
+// StartAutoFlush periodically writes then resets the default profiler report.
+func StartAutoFlush(interval time.Duration, w io.Writer) {
+	defaultProfiler.StartAutoFlush(interval, w)
+}
+
+// FlushTo writes then resets the default profiler report.
+func FlushTo(w io.Writer) {
+	defaultProfiler.FlushTo(w)
+}
+
+// StopAutoFlush stops the periodic reports of the default profiler.
+func StopAutoFlush() {
+	defaultProfiler.StopAutoFlush()
+}
@@ -0,0 +1,121 @@
+package timer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a buffer written by a background goroutine.
+type lockedBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buffer.Write(data)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buffer.String()
+}
+
+func TestFlushTo(t *testing.T) {
+	var clock = useFakeClock(t)
+	var estimated = estimations(testFrequency)
+	var p = New()
+
+	for interval := 1; interval <= 2; interval++ {
+		p.Start("a")
+		clock.advance(100)
+		p.Stop("a")
+
+		var buf bytes.Buffer
+		p.FlushTo(&buf)
+		// Each report covers its own interval
+		if text := buf.String(); !strings.Contains(text, "-- calls: 1, avg") {
+			t.Errorf("interval %d: report doesn't show a single hit:\n%s", interval, text)
+		}
+		if result := resultOf(t, p.Snapshot(), "a"); result.Hits != 0 {
+			t.Errorf("interval %d: %d hits left after the flush", interval, result.Hits)
+		}
+	}
+	if *estimated != 1 {
+		t.Errorf("%d calibrations, want the frequency kept across flushes", *estimated)
+	}
+}
+
+func TestStartAutoFlush(t *testing.T) {
+	var p = New()
+	p.Start("a")
+	p.Stop("a")
+
+	var written lockedBuffer
+	p.StartAutoFlush(time.Millisecond, &written)
+	var deadline = time.Now().Add(5 * time.Second)
+	for !strings.Contains(written.String(), "calls: 1") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	p.StopAutoFlush()
+	p.StopAutoFlush()
+
+	var text = written.String()
+	if !strings.Contains(text, "calls: 1") {
+		t.Fatalf("no report flushed:\n%s", text)
+	}
+	// Nothing written once stopped
+	time.Sleep(10 * time.Millisecond)
+	if after := written.String(); after != text {
+		t.Errorf("written after StopAutoFlush:\n%s", after[len(text):])
+	}
+}
+
+func TestFlushToLosesNoHit(t *testing.T) {
+	var clock = useFakeClock(t)
+	captureWarnings(t)
+	var p = New()
+
+	var recording sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		recording.Add(1)
+		go func(name string) {
+			defer recording.Done()
+			for i := 0; i < 1000; i++ {
+				p.Start(name)
+				clock.advance(10)
+				p.Stop(name)
+			}
+		}(fmt.Sprint("worker", g))
+	}
+
+	// Each hit is counted by exactly one of the reports
+	var hits int64
+	var done = make(chan struct{})
+	go func() {
+		recording.Wait()
+		close(done)
+	}()
+	for flushing := true; flushing; {
+		select {
+		case <-done:
+			flushing = false
+		default:
+		}
+		p.FlushTo(io.Discard)
+		var report, _ = LastSnapshot()
+		hits = hits + report.Hits
+	}
+	if hits != 4000 {
+		t.Errorf("%d hits over the flushed reports, want 4000", hits)
+	}
+}
@@ -0,0 +1,343 @@
+package timer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TIMER_FORMAT_ENV_VAR selects the format of Output: text, json, json-tree,
+// csv, chrome, folded or markdown, text being the default.
+const TIMER_FORMAT_ENV_VAR = "TIMER_FORMAT"
+
+// TIMER_OUTPUT_ENV_VAR names a file Output appends to instead of the
+// standard output, "-" meaning the standard output.
+const TIMER_OUTPUT_ENV_VAR = "TIMER_OUTPUT"
+
+// outputFormat writes the report to w in the format named by
+// TIMER_FORMAT_ENV_VAR, falling back to text with a warning.
+func (p *Profiler) outputFormat(w io.Writer) {
+	var err error
+
+	switch format := os.Getenv(TIMER_FORMAT_ENV_VAR); format {
+	case "", "text":
+		p.write(w, writeOptions{})
+	case "json":
+		err = writeJSON(w, p.snapshot())
+	case "json-tree":
+		err = writeJSONTree(w, p.snapshot())
+	case "csv":
+		err = writeCSV(w, p.snapshot())
+	case "chrome":
+		err = writeChromeTrace(w, p.snapshot())
+	case "folded":
+		err = writeFolded(w, p.snapshot())
+	case "markdown":
+		err = writeMarkdown(w, p.snapshot())
+	default:
+		warn("timer: unknown %s %q, using text", TIMER_FORMAT_ENV_VAR, format)
+		p.write(w, writeOptions{})
+	}
+
+	if err != nil {
+		warn("timer: writing the report: %v", err)
+	}
+}
+
+/*
+WriteJSON writes the report returned by Snapshot to w as indented JSON. Next
+to the computed times, every anchor and the total carry their raw counters,
+tscount_ticks in CPU timer units, hits and bytes, and the report carries the
+cpu_frequency_hz used for the conversion, zero if uncalibrated: consumers can
+recompute the times with a frequency of their own, or compare counts across
+machines. Field names end with their unit where there is one.
+*/
+func (p *Profiler) WriteJSON(w io.Writer) error {
+	if profilingDisabled() {
+		return nil
+	}
+
+	return writeJSON(w, p.Snapshot())
+}
+
+func writeJSON(w io.Writer, report Report) error {
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+/*
+WriteJSONTree writes the anchor hierarchy to w as indented JSON, for viewers:
+the total is the root node, and every node lists the anchors first started in
+it as its children, with the same counters and computed times as WriteJSON.
+Names are written as recorded, i.e. truncated. Unlike that of WriteJSON, the
+output can't be read back as a Report.
+*/
+func (p *Profiler) WriteJSONTree(w io.Writer) error {
+	if profilingDisabled() {
+		return nil
+	}
+
+	return writeJSONTree(w, p.Snapshot())
+}
+
+type jsonTreeNode struct {
+	Name     string          `json:"name"`
+	Depth    int64           `json:"depth"`
+	Hits     int64           `json:"hits"`
+	TSCount  int64           `json:"tscount_ticks"`
+	Bytes    int64           `json:"bytes"`
+	Elapsed  float64         `json:"elapsed_ms"`
+	Percent  float64         `json:"percent"`
+	Children []*jsonTreeNode `json:"children"`
+
+	// Header of the report, on the root node only
+	Meta *ReportMeta `json:"meta,omitempty"`
+}
+
+func newJSONTreeNode(result AnchorResult) *jsonTreeNode {
+	return &jsonTreeNode{
+		Name: result.Name, Depth: result.Depth, Hits: result.Hits, TSCount: result.TSCount,
+		Bytes: result.Bytes, Elapsed: result.Elapsed, Percent: result.Percent,
+		Children: []*jsonTreeNode{},
+	}
+}
+
+func writeJSONTree(w io.Writer, report Report) error {
+	var root = newJSONTreeNode(report.Total)
+	// The total isn't hit itself, it sums the calls as in Output
+	root.Hits = report.Hits
+	root.Meta = &report.Meta
+
+	// Parents come before their children in the report
+	var nodes = make(map[string]*jsonTreeNode, len(report.Anchors))
+	for _, result := range report.Anchors {
+		var node = newJSONTreeNode(result)
+		nodes[result.Name] = node
+
+		var parent, exists = nodes[result.ParentName]
+		if !exists {
+			parent = root
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(root)
+}
+
+/*
+WriteCSV writes one row per anchor to w, in the order they were first
+started, after a header row and followed by a total row, for comparing runs in
+a spreadsheet. The columns are name, depth, hits, elapsed_ms, tscount, bytes
+and percent; names are quoted as needed by encoding/csv. The rows are preceded
+by the ReportMeta header on a comment line starting with "#": readers must
+skip it, e.g. by setting Comment to '#' on a csv.Reader, which otherwise
+fails on its number of fields. Nothing is written while profiling is
+disabled.
+*/
+func (p *Profiler) WriteCSV(w io.Writer) error {
+	if profilingDisabled() {
+		return nil
+	}
+
+	return writeCSV(w, p.Snapshot())
+}
+
+func writeCSV(w io.Writer, report Report) error {
+	fmt.Fprintf(w, "# %s\n", report.Meta)
+
+	var writer = csv.NewWriter(w)
+	writer.Write([]string{"name", "depth", "hits", "elapsed_ms", "tscount", "bytes", "percent"})
+
+	var row = func(result AnchorResult) {
+		writer.Write([]string{
+			result.Name,
+			strconv.FormatInt(result.Depth, 10),
+			strconv.FormatInt(result.Hits, 10),
+			strconv.FormatFloat(result.Elapsed, 'f', -1, 64),
+			strconv.FormatInt(result.TSCount, 10),
+			strconv.FormatInt(result.Bytes, 10),
+			strconv.FormatFloat(result.Percent, 'f', -1, 64),
+		})
+	}
+
+	for _, result := range report.Anchors {
+		row(result)
+	}
+	row(report.Total)
+
+	writer.Flush()
+	return writer.Error()
+}
+
+/*
+WriteChromeTrace writes the anchor hierarchy to w in the Trace Event Format
+read by chrome://tracing and Perfetto. Hits are not kept individually, so each
+anchor is a single event lasting its time including children, the children of
+an anchor being laid out one after the other from its start: the trace shows
+proportions, not the actual timeline.
+*/
+func (p *Profiler) WriteChromeTrace(w io.Writer) error {
+	if profilingDisabled() {
+		return nil
+	}
+
+	return writeChromeTrace(w, p.Snapshot())
+}
+
+type chromeEvent struct {
+	Name      string           `json:"name"`
+	Phase     string           `json:"ph"`
+	Timestamp float64          `json:"ts"`
+	Duration  float64          `json:"dur"`
+	PID       int              `json:"pid"`
+	TID       int              `json:"tid"`
+	Args      map[string]int64 `json:"args"`
+}
+
+func writeChromeTrace(w io.Writer, report Report) error {
+	// Elapsed time including children, in milliseconds
+	var inclusive = make(map[string]float64, len(report.Anchors))
+	for i := len(report.Anchors) - 1; i >= 0; i-- {
+		var result = report.Anchors[i]
+		inclusive[result.Name] = inclusive[result.Name] + result.Elapsed
+		if result.ParentName != "" {
+			inclusive[result.ParentName] = inclusive[result.ParentName] + inclusive[result.Name]
+		}
+	}
+
+	// Start of the next child of each anchor, in microseconds
+	var next = make(map[string]float64, len(report.Anchors))
+	var events = make([]chromeEvent, 0, len(report.Anchors)+1)
+	events = append(events, chromeEvent{
+		Name: report.Total.Name, Phase: "X", Duration: report.Total.Elapsed * 1000,
+		PID: 1, TID: 1, Args: map[string]int64{"tscount": report.Total.TSCount},
+	})
+
+	for _, result := range report.Anchors {
+		var start = next[result.ParentName]
+		next[result.ParentName] = start + inclusive[result.Name]*1000
+		next[result.Name] = start
+
+		events = append(events, chromeEvent{
+			Name: result.Name, Phase: "X", Timestamp: start, Duration: inclusive[result.Name] * 1000,
+			PID: 1, TID: 1, Args: map[string]int64{"hits": result.Hits, "bytes": result.Bytes},
+		})
+	}
+
+	return json.NewEncoder(w).Encode(map[string]interface{}{"traceEvents": events, "otherData": report.Meta})
+}
+
+/*
+WriteFolded writes the anchor hierarchy to w in the folded stack format read
+by flamegraph.pl: one line per anchor, the names of its ancestors and its own
+separated by semicolons below the total, followed by its CPU timer units
+excluding children, so that widths add up to the total. The time outside any
+anchor is counted on the total itself. Anchors without time of their own are
+left out, and semicolons in names are replaced by underscores.
+*/
+func (p *Profiler) WriteFolded(w io.Writer) error {
+	if profilingDisabled() {
+		return nil
+	}
+
+	return writeFolded(w, p.Snapshot())
+}
+
+func writeFolded(w io.Writer, report Report) error {
+	var frame = func(name string) string {
+		return strings.ReplaceAll(name, ";", "_")
+	}
+
+	// Parents come before their children in the report
+	var stacks = make(map[string]string, len(report.Anchors))
+	var unaccounted = report.Total.TSCount
+	for _, result := range report.Anchors {
+		var parent, exists = stacks[result.ParentName]
+		if !exists {
+			parent = frame(report.Total.Name)
+		}
+		stacks[result.Name] = parent + ";" + frame(result.Name)
+		unaccounted = unaccounted - result.TSCount
+	}
+
+	var buffered = bufio.NewWriter(w)
+	if unaccounted > 0 {
+		fmt.Fprintf(buffered, "%s %d\n", frame(report.Total.Name), unaccounted)
+	}
+	for _, result := range report.Anchors {
+		if result.TSCount > 0 {
+			fmt.Fprintf(buffered, "%s %d\n", stacks[result.Name], result.TSCount)
+		}
+	}
+
+	return buffered.Flush()
+}
+
+// WriteMarkdown writes the report to w as a Markdown table, children being
+// indented below their parent.
+func (p *Profiler) WriteMarkdown(w io.Writer) error {
+	if profilingDisabled() {
+		return nil
+	}
+
+	return writeMarkdown(w, p.Snapshot())
+}
+
+func writeMarkdown(w io.Writer, report Report) error {
+	if report.Name != "" {
+		fmt.Fprintf(w, "### %s\n\n", report.Name)
+	}
+	fmt.Fprintf(w, "_%s_\n\n", report.Meta)
+
+	fmt.Fprintln(w, "| Anchor | Elapsed (ms) | % | Calls | Bytes |")
+	fmt.Fprintln(w, "|---|--:|--:|--:|--:|")
+	fmt.Fprintf(w, "| **%s** | %.3f | 100.00 | | |\n", report.Total.Name, report.Total.Elapsed)
+
+	for _, result := range report.Anchors {
+		var name = strings.Repeat("&nbsp;&nbsp;", int(result.Depth)) +
+			strings.ReplaceAll(result.Name, "|", `\|`)
+		fmt.Fprintf(w, "| %s | %.3f | %.2f | %d | %d |\n", name, result.Elapsed, result.Percent,
+			result.Hits, result.Bytes)
+	}
+
+	var _, err = fmt.Fprintln(w)
+	return err
+}
+
+// WriteJSON writes the default profiler report as JSON.
+func WriteJSON(w io.Writer) error {
+	return defaultProfiler.WriteJSON(w)
+}
+
+// WriteJSONTree writes the default profiler anchor hierarchy as JSON.
+func WriteJSONTree(w io.Writer) error {
+	return defaultProfiler.WriteJSONTree(w)
+}
+
+// WriteCSV writes the default profiler report as CSV.
+func WriteCSV(w io.Writer) error {
+	return defaultProfiler.WriteCSV(w)
+}
+
+// WriteChromeTrace writes the default profiler report as a Chrome trace.
+func WriteChromeTrace(w io.Writer) error {
+	return defaultProfiler.WriteChromeTrace(w)
+}
+
+// WriteFolded writes the default profiler anchor hierarchy as folded stacks.
+func WriteFolded(w io.Writer) error {
+	return defaultProfiler.WriteFolded(w)
+}
+
+// WriteMarkdown writes the default profiler report as a Markdown table.
+func WriteMarkdown(w io.Writer) error {
+	return defaultProfiler.WriteMarkdown(w)
+}
@@ -0,0 +1,344 @@
+package timer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+// checkGoldenFile compares got to the golden file at path, rewriting it
+// instead with -update.
+func checkGoldenFile(tb testing.TB, path string, got []byte) {
+	tb.Helper()
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+
+	var want, err = os.ReadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		tb.Errorf("differs from %s, rerun with -update if intended:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// recordFormats records the profile of the format tests: a parent hit twice
+// with a child, and a second root, the names holding the separators of the
+// formats.
+func recordFormats(p *Profiler, clock *fakeClock) {
+	for i := 0; i < 2; i++ {
+		p.StartThroughput("parse", 4096)
+		clock.advance(1000000)
+		p.Start("read, lines")
+		clock.advance(500000)
+		p.Stop("read, lines")
+		p.Stop("parse")
+	}
+
+	p.Start("render|html;v2")
+	clock.advance(2000000)
+	p.Stop("render|html;v2")
+}
+
+// formatsReport returns the report of recordFormats, without the parts
+// depending on the machine and the time of the run.
+func formatsReport(tb testing.TB) Report {
+	tb.Helper()
+
+	var clock = useFakeClock(tb)
+	var p = New()
+	recordFormats(p, clock)
+
+	var report = p.Snapshot()
+	report.Runtime = RuntimeInfo{}
+	report.Meta.Generated = time.Time{}
+	return report
+}
+
+func TestOutputFormats(t *testing.T) {
+	var tests = []struct {
+		format string
+		check  func(output string) error
+	}{
+		{"", checkContains("parse:", "render|html;v2:")},
+		{"text", checkContains("parse:", "render|html;v2:")},
+		{"json", func(output string) error {
+			var report Report
+			return json.Unmarshal([]byte(output), &report)
+		}},
+		{"json-tree", func(output string) error {
+			var root jsonTreeNode
+			return json.Unmarshal([]byte(output), &root)
+		}},
+		{"csv", func(output string) error {
+			var reader = csv.NewReader(strings.NewReader(output))
+			reader.Comment = '#'
+			var _, err = reader.ReadAll()
+			return err
+		}},
+		{"chrome", checkContains(`"traceEvents":[`)},
+		{"folded", checkContains("total;parse 2000000\n")},
+		{"markdown", checkContains("| Anchor | Elapsed (ms) |")},
+		{"yaml", checkContains("parse:", "render|html;v2:")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var captured = captureWarnings(t)
+			t.Setenv(TIMER_FORMAT_ENV_VAR, test.format)
+
+			var p = New()
+			recordFormats(p, clock)
+			if err := test.check(output(p)); err != nil {
+				t.Error(err)
+			}
+
+			var warning = captured.String()
+			if test.format == "yaml" && !strings.Contains(warning, `unknown TIMER_FORMAT "yaml", using text`) {
+				t.Errorf("warning %q, want the unknown format", warning)
+			}
+			if test.format != "yaml" && warning != "" {
+				t.Errorf("unexpected warning %q", warning)
+			}
+		})
+	}
+}
+
+// checkContains returns a check that the output holds every want.
+func checkContains(want ...string) func(output string) error {
+	return func(output string) error {
+		for _, want := range want {
+			if !strings.Contains(output, want) {
+				return fmt.Errorf("output doesn't contain %q:\n%s", want, output)
+			}
+		}
+		return nil
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var tests = []struct {
+		name   string
+		report Report
+	}{
+		{"empty", Report{}},
+		{"every field set", filledReport()},
+		{"profiled", formatsReport(t)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := writeJSON(&buffer, test.report); err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded Report
+			if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+				t.Fatal(err)
+			}
+
+			var want = test.report
+			if !decoded.Meta.Generated.Equal(want.Meta.Generated) {
+				t.Errorf("generated %v, want %v", decoded.Meta.Generated, want.Meta.Generated)
+			}
+			decoded.Meta.Generated, want.Meta.Generated = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(decoded, want) {
+				t.Errorf("decoded\n%+v\nwant\n%+v", decoded, want)
+			}
+		})
+	}
+}
+
+func TestChromeTrace(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := writeChromeTrace(&buffer, formatsReport(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var trace struct {
+		TraceEvents []chromeEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+
+	// Inclusive durations in microseconds, children laid out from the start
+	// of their parent and roots one after the other
+	var want = []chromeEvent{
+		{Name: "total", Phase: "X", Duration: 5000, PID: 1, TID: 1, Args: map[string]int64{"tscount": 5000000}},
+		{Name: "parse", Phase: "X", Duration: 3000, PID: 1, TID: 1, Args: map[string]int64{"hits": 2, "bytes": 8192}},
+		{Name: "read, lines", Phase: "X", Duration: 1000, PID: 1, TID: 1,
+			Args: map[string]int64{"hits": 2, "bytes": 0}},
+		{Name: "render|html;v2", Phase: "X", Timestamp: 3000, Duration: 2000, PID: 1, TID: 1,
+			Args: map[string]int64{"hits": 1, "bytes": 0}},
+	}
+	if !reflect.DeepEqual(trace.TraceEvents, want) {
+		t.Errorf("events\n%+v\nwant\n%+v", trace.TraceEvents, want)
+	}
+}
+
+func TestMarkdownGolden(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := writeMarkdown(&buffer, formatsReport(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	checkGoldenFile(t, "testdata/report.md", buffer.Bytes())
+}
+
+func TestJSONTree(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := writeJSONTree(&buffer, formatsReport(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var root jsonTreeNode
+	if err := json.Unmarshal(buffer.Bytes(), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	// name: hits, tscount, then the children
+	var describe func(node *jsonTreeNode) string
+	describe = func(node *jsonTreeNode) string {
+		var children []string
+		for _, child := range node.Children {
+			children = append(children, describe(child))
+		}
+		return fmt.Sprintf("%s: %d, %d [%s]", node.Name, node.Hits, node.TSCount, strings.Join(children, ", "))
+	}
+
+	var want = "total: 5, 5000000 [parse: 2, 2000000 [read, lines: 2, 1000000 []], render|html;v2: 1, 2000000 []]"
+	if got := describe(&root); got != want {
+		t.Errorf("tree %s, want %s", got, want)
+	}
+	if root.Meta == nil || root.Meta.Anchors != 3 {
+		t.Errorf("root meta %+v, want the header of 3 anchors", root.Meta)
+	}
+	if strings.Contains(buffer.String(), `"children": null`) {
+		t.Errorf("leaves have null children:\n%s", buffer.String())
+	}
+}
+
+func TestCSVGolden(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := writeCSV(&buffer, formatsReport(t)); err != nil {
+		t.Fatal(err)
+	}
+	checkGoldenFile(t, "testdata/report.csv", buffer.Bytes())
+
+	// Readable once the header comment is skipped
+	var reader = csv.NewReader(bytes.NewReader(buffer.Bytes()))
+	reader.Comment = '#'
+	var rows, err = reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 || rows[2][0] != "read, lines" {
+		t.Errorf("rows %q, want the header, 3 anchors and the total", rows)
+	}
+}
+
+func TestFoldedGolden(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := writeFolded(&buffer, formatsReport(t)); err != nil {
+		t.Fatal(err)
+	}
+	checkGoldenFile(t, "testdata/report.folded", buffer.Bytes())
+}
+
+func TestFoldedUnaccountedAndEmpty(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.Start("outer")
+	p.Start("inner")
+	clock.advance(300)
+	p.Stop("inner")
+	p.Stop("outer")
+	clock.advance(200)
+	p.Start("late")
+	p.Stop("late")
+
+	var buffer bytes.Buffer
+	if err := writeFolded(&buffer, p.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Time outside any anchor on the total, anchors without time left out
+	var want = "total 200\ntotal;outer;inner 300\n"
+	if got := buffer.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONRawCounters(t *testing.T) {
+	var tests = []struct {
+		name      string
+		frequency int64
+	}{
+		{"calibrated", testFrequency},
+		{"uncalibrated", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			freqFn = func() int64 { return test.frequency }
+			var p = New()
+			recordFormats(p, clock)
+
+			var buffer bytes.Buffer
+			if err := p.WriteJSON(&buffer); err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded struct {
+				CPUFrequency *int64                       `json:"cpu_frequency_hz"`
+				Total        map[string]json.RawMessage   `json:"total"`
+				Anchors      []map[string]json.RawMessage `json:"anchors"`
+			}
+			if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded.CPUFrequency == nil || *decoded.CPUFrequency != test.frequency {
+				t.Errorf("cpu_frequency_hz = %v, want %d", decoded.CPUFrequency, test.frequency)
+			}
+
+			var counters = append([]map[string]json.RawMessage{decoded.Total}, decoded.Anchors...)
+			for _, fields := range counters {
+				for _, field := range []string{"tscount_ticks", "hits", "bytes"} {
+					if _, ok := fields[field]; !ok {
+						t.Errorf("%s has no %s field", fields["name"], field)
+					}
+				}
+			}
+
+			// Own ticks of parse, the time of its child excluded
+			for _, fields := range decoded.Anchors {
+				if string(fields["name"]) != `"parse"` {
+					continue
+				}
+				var ticks, hits, size = string(fields["tscount_ticks"]), string(fields["hits"]), string(fields["bytes"])
+				if ticks != "2000000" || hits != "2" || size != "8192" {
+					t.Errorf("parse: %s ticks, %s hits, %s bytes, want 2000000, 2 and 8192", ticks, hits, size)
+				}
+			}
+		})
+	}
+}
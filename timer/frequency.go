@@ -0,0 +1,68 @@
+package timer
+
+import (
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+// TIMER_CPU_FREQ_ENV_VAR supplies the CPU timer frequency in Hz, e.g.
+// "2100000000" or "2.1e9", read at the first calibration instead of
+// estimating it.
+const TIMER_CPU_FREQ_ENV_VAR = "TIMER_CPU_FREQ"
+
+// Non-zero while cpuFrequency was supplied rather than estimated
+var suppliedCalibration int32
+
+/*
+SetCPUFrequency sets the CPU timer frequency in Hz instead of estimating it,
+for callers who already know the rate of their timer: it saves the 50ms
+calibration of the first Start and makes the times of repeated short runs
+comparable. It takes precedence over TIMER_CPU_FREQ_ENV_VAR and discards a
+background estimation still running. Zero or a negative frequency goes back to
+the estimation at the next Start, as InvalidateCalibration does.
+*/
+func SetCPUFrequency(hz int64) {
+	if hz <= 0 {
+		InvalidateCalibration()
+		return
+	}
+
+	calibrationMutex.Lock()
+	defer calibrationMutex.Unlock()
+
+	supplyCPUFrequency(hz)
+	// Discard a background estimation
+	calibrationGeneration = calibrationGeneration + 1
+}
+
+// supplyCPUFrequency sets a frequency not to be estimated, with
+// calibrationMutex held.
+func supplyCPUFrequency(hz int64) {
+	atomic.StoreInt64(&cpuFrequency, hz)
+	atomic.StoreInt32(&provisionalCalibration, 0)
+	atomic.StoreInt32(&suppliedCalibration, 1)
+}
+
+// frequencyFromEnv returns the frequency set by TIMER_CPU_FREQ_ENV_VAR, and
+// false when unset or invalid, with a warning in the latter case.
+func frequencyFromEnv() (int64, bool) {
+	var value = os.Getenv(TIMER_CPU_FREQ_ENV_VAR)
+	if value == "" {
+		return 0, false
+	}
+
+	var hz, err = strconv.ParseFloat(value, 64)
+	if err != nil || hz < 1 {
+		warn("timer: invalid %s %q, estimating the frequency", TIMER_CPU_FREQ_ENV_VAR, value)
+		return 0, false
+	}
+
+	return int64(hz), true
+}
+
+// calibrationIsSupplied reports whether the frequency was set by
+// SetCPUFrequency or TIMER_CPU_FREQ_ENV_VAR.
+func calibrationIsSupplied() bool {
+	return atomic.LoadInt32(&suppliedCalibration) != 0
+}
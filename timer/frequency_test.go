@@ -0,0 +1,73 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuppliedFrequencySkipsCalibration(t *testing.T) {
+	var tests = []struct {
+		name     string
+		env      string
+		set      int64
+		want     int64
+		estimate bool
+		warning  string
+	}{
+		{"SetCPUFrequency", "", 2500000000, 2500000000, false, ""},
+		{"environment", "2100000000", 0, 2100000000, false, ""},
+		{"environment in scientific notation", "2.1e9", 0, 2100000000, false, ""},
+		{"SetCPUFrequency over the environment", "2.1e9", 2500000000, 2500000000, false, ""},
+		{"invalid environment", "fast", 0, testFrequency, true, `invalid TIMER_CPU_FREQ "fast"`},
+		{"negative environment", "-1", 0, testFrequency, true, `invalid TIMER_CPU_FREQ "-1"`},
+		{"neither", "", 0, testFrequency, true, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var estimated = estimations(testFrequency)
+			var captured = captureWarnings(t)
+			t.Setenv(TIMER_CPU_FREQ_ENV_VAR, test.env)
+			if test.set != 0 {
+				SetCPUFrequency(test.set)
+			}
+
+			var p = New()
+			p.Start("a")
+			clock.advance(1000)
+			p.Stop("a")
+
+			if frequency := GetCPUFrequency(); frequency != test.want {
+				t.Errorf("frequency = %d, want %d", frequency, test.want)
+			}
+			if got := *estimated != 0; got != test.estimate {
+				t.Errorf("%d estimations, want estimated %v", *estimated, test.estimate)
+			}
+			if warning := captured.String(); !strings.Contains(warning, test.warning) ||
+				(test.warning == "" && warning != "") {
+				t.Errorf("warning %q, want %q", warning, test.warning)
+			}
+
+			var supplied = strings.Contains(output(p), "supplied (confidence: not measured)")
+			if supplied == test.estimate {
+				t.Errorf("header tells supplied %v for an estimation %v:\n%s", supplied, test.estimate, output(p))
+			}
+		})
+	}
+}
+
+func TestSetCPUFrequencyZeroEstimatesAgain(t *testing.T) {
+	useFakeClock(t)
+	var estimated = estimations(testFrequency)
+
+	SetCPUFrequency(2500000000)
+	if frequency := Calibrate(); frequency != 2500000000 || *estimated != 0 {
+		t.Fatalf("Calibrate = %d after %d estimations, want 2500000000 without any", frequency, *estimated)
+	}
+
+	SetCPUFrequency(0)
+	if frequency := Calibrate(); frequency != testFrequency || *estimated != 1 {
+		t.Errorf("Calibrate = %d after %d estimations, want %d estimated", frequency, *estimated, testFrequency)
+	}
+}
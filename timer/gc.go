@@ -0,0 +1,53 @@
+package timer
+
+import (
+	"runtime"
+	"time"
+)
+
+// gcStats holds the garbage collector counters at the start of a profile.
+type gcStats struct {
+	numGC        uint32
+	pauseTotalNs uint64
+}
+
+func readGCStats() gcStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	return gcStats{
+		numGC:        memStats.NumGC,
+		pauseTotalNs: memStats.PauseTotalNs,
+	}
+}
+
+/*
+SetGCStats enables reporting the garbage collections that happened between the
+first Start and the report, along with their total pause time. It is disabled
+by default since reading the runtime statistics briefly stops the world, once
+at the first Start and once per report. Enable it before the first Start.
+*/
+func (p *Profiler) SetGCStats(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.gcEnabled = enabled
+}
+
+// gcDelta returns the collections since the start of the profile, if tracked.
+func (p *Profiler) gcDelta() (int64, time.Duration, bool) {
+	if !p.gcEnabled || p.totalTiming.start == 0 {
+		return 0, 0, false
+	}
+
+	var current = readGCStats()
+	var count = int64(current.numGC - p.gcStart.numGC)
+	var pause = time.Duration(current.pauseTotalNs - p.gcStart.pauseTotalNs)
+
+	return count, pause, true
+}
+
+// SetGCStats enables garbage collection reporting on the default profiler.
+func SetGCStats(enabled bool) {
+	defaultProfiler.SetGCStats(enabled)
+}
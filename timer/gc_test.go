@@ -0,0 +1,45 @@
+package timer
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGCStats(t *testing.T) {
+	var tests = []struct {
+		name    string
+		enabled bool
+	}{
+		{"disabled", false},
+		{"enabled", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			p.SetGCStats(test.enabled)
+
+			// Collections before the first Start aren't counted
+			runtime.GC()
+			p.Start("a")
+			runtime.GC()
+			runtime.GC()
+			clock.advance(1000)
+			p.Stop("a")
+
+			var report = p.Snapshot()
+			if test.enabled && (report.GCCount < 2 || report.GCPause <= 0) {
+				t.Errorf("%d collections pausing %v, want at least the 2 forced", report.GCCount, report.GCPause)
+			}
+			if !test.enabled && (report.GCCount != 0 || report.GCPause != 0) {
+				t.Errorf("%d collections pausing %v reported while disabled", report.GCCount, report.GCPause)
+			}
+			if text := output(p); strings.Contains(text, "collections:") != test.enabled {
+				t.Errorf("gc line printed = %v, want %v:\n%s", !test.enabled, test.enabled, text)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package timer
+
+import (
+	"reflect"
+	"sync"
+)
+
+type typedNameKey struct {
+	name string
+	t    reflect.Type
+}
+
+// Names derived by TypedName, by base name and type
+var typedNames sync.Map
+
+/*
+TypedName returns the anchor name of a generic function instantiated with T,
+the name followed by the type in brackets, e.g. "Sort[int]", so that each
+instantiation gets an anchor of its own:
+
+	func Sort[T constraints.Ordered](values []T) {
+		defer timer.Stop(timer.StartTyped[T]("Sort"))
+		...
+	}
+
+Methods can't have type parameters: with a Profiler of its own, pass
+TypedName[T]("Sort") to Start and Stop. The string is built once per name and
+type, later calls only costing a map lookup. Type names quickly exceed the
+maximum anchor name length: consider the HashLongNames policy to keep
+truncated names apart.
+*/
+func TypedName[T any](name string) string {
+	var key = typedNameKey{name: name, t: reflect.TypeOf((*T)(nil)).Elem()}
+	if typed, exists := typedNames.Load(key); exists {
+		return typed.(string)
+	}
+
+	var typed = name + "[" + key.t.String() + "]"
+	typedNames.Store(key, typed)
+	return typed
+}
+
+// StartTyped starts the anchor named by TypedName on the default profiler, and
+// returns that name for the matching Stop.
+func StartTyped[T any](name string) string {
+	var typed = TypedName[T](name)
+	defaultProfiler.Start(typed)
+	return typed
+}
@@ -0,0 +1,49 @@
+package timer
+
+import "testing"
+
+type typedPoint struct{ x, y int }
+
+func TestTypedName(t *testing.T) {
+	var tests = []struct {
+		got  string
+		want string
+	}{
+		{TypedName[int]("Sort"), "Sort[int]"},
+		{TypedName[string]("Sort"), "Sort[string]"},
+		{TypedName[[]byte]("Sort"), "Sort[[]uint8]"},
+		{TypedName[typedPoint]("Sort"), "Sort[timer.typedPoint]"},
+		{TypedName[error]("Sort"), "Sort[error]"},
+		// Cached
+		{TypedName[int]("Sort"), "Sort[int]"},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("typed name %q, want %q", test.got, test.want)
+		}
+	}
+}
+
+func TestStartTyped(t *testing.T) {
+	var clock = useFakeClock(t)
+	defaultProfiler.Reset()
+	t.Cleanup(defaultProfiler.Reset)
+
+	for _, run := range []func(){
+		func() { Stop(StartTyped[int]("sum")) },
+		func() { Stop(StartTyped[float64]("sum")) },
+		func() { Stop(StartTyped[int]("sum")) },
+	} {
+		run()
+		clock.advance(10)
+	}
+
+	var report = defaultProfiler.Snapshot()
+	if hits := resultOf(t, report, "sum[int]").Hits; hits != 2 {
+		t.Errorf("sum[int]: %d hits, want 2", hits)
+	}
+	if hits := resultOf(t, report, "sum[float64]").Hits; hits != 1 {
+		t.Errorf("sum[float64]: %d hits, want 1", hits)
+	}
+}
@@ -0,0 +1,122 @@
+package timer
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+/*
+Regression is an anchor slower than in the golden profile it was compared
+to. Golden and Current are its compared times, in milliseconds, the mean time
+per hit for CompareToGolden and the elapsed time for Compare. Ratio is the
+second divided by the first, and Change the increase in percent.
+*/
+type Regression struct {
+	Name    string
+	Golden  float64
+	Current float64
+	Ratio   float64
+	Change  float64
+}
+
+/*
+CompareToGolden reads a profile saved with WriteBinary or WriteJSON and
+returns the anchors whose mean time per hit grew by more than tolerance, a
+fraction of the golden time: 0.1 allows 10% slower. Anchors missing from
+either profile, or not hit, are not compared.
+*/
+func (p *Profiler) CompareToGolden(r io.Reader, tolerance float64) ([]Regression, error) {
+	var golden, err = readProfile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return compareReports(golden, p.Snapshot(), tolerance), nil
+}
+
+// readProfile decodes a report in the binary format, detected by its magic
+// string, or in JSON.
+func readProfile(r io.Reader) (Report, error) {
+	var buffered = bufio.NewReader(r)
+
+	if magic, err := buffered.Peek(len(binaryMagic)); err == nil && string(magic) == binaryMagic {
+		return ReadBinary(buffered)
+	}
+
+	var report Report
+	if err := json.NewDecoder(buffered).Decode(&report); err != nil {
+		return Report{}, err
+	}
+	// Reports written before ReportMeta lack it
+	report.updateMeta()
+
+	return report, nil
+}
+
+func compareReports(golden Report, current Report, tolerance float64) []Regression {
+	var goldenMeans = make(map[string]float64, len(golden.Anchors))
+	for _, result := range golden.Anchors {
+		if result.Hits > 0 {
+			goldenMeans[result.Name] = result.Elapsed / float64(result.Hits)
+		}
+	}
+
+	var regressions []Regression
+	for _, result := range current.Anchors {
+		var goldenMean, exists = goldenMeans[result.Name]
+		if !exists || result.Hits == 0 || goldenMean <= 0 {
+			continue
+		}
+
+		var mean = result.Elapsed / float64(result.Hits)
+		if mean > goldenMean*(1+tolerance) {
+			regressions = append(regressions, Regression{
+				Name:    result.Name,
+				Golden:  goldenMean,
+				Current: mean,
+				Ratio:   mean / goldenMean,
+				Change:  100 * (mean/goldenMean - 1),
+			})
+		}
+	}
+
+	return regressions
+}
+
+// TB is the part of testing.TB used by CheckGolden.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+/*
+CheckGolden is CompareToGolden for tests and benchmarks: it fails tb for
+every regression, or if the golden profile can't be read.
+*/
+func (p *Profiler) CheckGolden(tb TB, r io.Reader, tolerance float64) {
+	tb.Helper()
+
+	var regressions, err = p.CompareToGolden(r, tolerance)
+	if err != nil {
+		tb.Errorf("timer: reading the golden profile: %v", err)
+		return
+	}
+
+	for _, regression := range regressions {
+		tb.Errorf("timer: %s regressed from %.6fms to %.6fms per hit (x%.2f)", regression.Name,
+			regression.Golden, regression.Current, regression.Ratio)
+	}
+}
+
+// CompareToGolden compares the default profiler to a saved golden profile.
+func CompareToGolden(r io.Reader, tolerance float64) ([]Regression, error) {
+	return defaultProfiler.CompareToGolden(r, tolerance)
+}
+
+// CheckGolden fails tb for every regression of the default profiler against
+// a saved golden profile.
+func CheckGolden(tb TB, r io.Reader, tolerance float64) {
+	tb.Helper()
+	defaultProfiler.CheckGolden(tb, r, tolerance)
+}
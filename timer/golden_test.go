@@ -0,0 +1,142 @@
+package timer
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeTB records the failures reported by CheckGolden.
+type fakeTB struct {
+	errors []string
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+// profileWith returns a profiler whose anchors took the given CPU timer units
+// per hit, over two hits each.
+func profileWith(clock *fakeClock, perHit map[string]int64, order []string) *Profiler {
+	var p = New()
+	for _, name := range order {
+		for i := 0; i < 2; i++ {
+			p.Start(name)
+			clock.advance(perHit[name])
+			p.Stop(name)
+		}
+	}
+
+	return p
+}
+
+// encodeGolden writes the report in the named format.
+func encodeGolden(tb testing.TB, format string, report Report) []byte {
+	tb.Helper()
+
+	var buffer bytes.Buffer
+	var err error
+	if format == "binary" {
+		err = WriteBinary(&buffer, report)
+	} else {
+		// As written by WriteJSON
+		err = writeJSON(&buffer, report)
+	}
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestCompareToGolden(t *testing.T) {
+	var tests = []struct {
+		name      string
+		tolerance float64
+		want      []string
+	}{
+		{"above the tolerance", 0.1, []string{"slower"}},
+		{"tight tolerance", 0.01, []string{"noisy", "slower"}},
+		{"loose tolerance", 1, nil},
+	}
+
+	for _, format := range []string{"json", "binary"} {
+		for _, test := range tests {
+			t.Run(format+"/"+test.name, func(t *testing.T) {
+				var clock = useFakeClock(t)
+				var golden = profileWith(clock, map[string]int64{"noisy": 1000, "slower": 1000,
+					"steady": 1000, "removed": 1000}, []string{"noisy", "slower", "steady", "removed"}).Snapshot()
+				// Registered but never hit
+				golden.Anchors = append(golden.Anchors, AnchorResult{Name: "unhit"})
+				var data = encodeGolden(t, format, golden)
+
+				var current = profileWith(clock, map[string]int64{"noisy": 1050, "slower": 1500,
+					"steady": 1000, "added": 5000, "unhit": 5000},
+					[]string{"noisy", "slower", "steady", "added", "unhit"})
+
+				var regressions, err = current.CompareToGolden(bytes.NewReader(data), test.tolerance)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var names []string
+				for _, regression := range regressions {
+					names = append(names, regression.Name)
+				}
+				if !reflect.DeepEqual(names, test.want) {
+					t.Errorf("regressions %v, want %v", names, test.want)
+				}
+				for _, regression := range regressions {
+					if regression.Ratio != regression.Current/regression.Golden {
+						t.Errorf("%s: ratio %v of %v over %v", regression.Name, regression.Ratio,
+							regression.Current, regression.Golden)
+					}
+				}
+
+				var tb fakeTB
+				current.CheckGolden(&tb, bytes.NewReader(data), test.tolerance)
+				if len(tb.errors) != len(test.want) {
+					t.Errorf("%d failures, want %d: %v", len(tb.errors), len(test.want), tb.errors)
+				}
+				for i, name := range test.want {
+					if i < len(tb.errors) && !strings.Contains(tb.errors[i], name+" regressed") {
+						t.Errorf("failure %q doesn't name %s", tb.errors[i], name)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestCompareToCorruptGolden(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"not a profile", "{not json"},
+		{"unknown version", binaryMagic + "\xff"},
+		{"truncated binary", binaryMagic + string([]byte{binaryVersion}) + "\x10ab"},
+		{"truncated json", `{"name": "golden", "anchors": [`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var current = profileWith(clock, map[string]int64{"a": 1000}, []string{"a"})
+
+			if _, err := current.CompareToGolden(strings.NewReader(test.data), 0.1); err == nil {
+				t.Error("CompareToGolden succeeded on a corrupt golden profile")
+			}
+
+			var tb fakeTB
+			current.CheckGolden(&tb, strings.NewReader(test.data), 0.1)
+			if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "reading the golden profile") {
+				t.Errorf("failures %v, want one about reading the golden profile", tb.errors)
+			}
+		})
+	}
+}
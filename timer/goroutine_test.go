@@ -0,0 +1,63 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoroutineStats(t *testing.T) {
+	useFakeClock(t)
+	var p = New()
+	p.SetGoroutineStats(true)
+
+	var hit = func() {
+		p.Start("shared")
+		p.Stop("shared")
+	}
+	hit()
+	// One after the other, the anchors of a profiler being nested
+	for g := 0; g < 4; g++ {
+		var done = make(chan struct{})
+		go func() {
+			defer close(done)
+			hit()
+			hit()
+		}()
+		<-done
+	}
+
+	var hits = p.GoroutineHits("shared")
+	if len(hits) != 5 || hits[currentGoroutineID()] != 1 {
+		t.Errorf("hits per goroutine %v, want 5 goroutines, this one hitting once", hits)
+	}
+	var total int64
+	for _, count := range hits {
+		total = total + count
+	}
+	if total != 9 {
+		t.Errorf("%d hits over the goroutines, want 9", total)
+	}
+
+	if result := resultOf(t, p.Snapshot(), "shared"); result.Goroutines != 5 {
+		t.Errorf("%d goroutines, want 5", result.Goroutines)
+	}
+	if text := output(p); !strings.Contains(text, ", goroutines: 5") {
+		t.Errorf("report doesn't show the goroutines:\n%s", text)
+	}
+	if hits := p.GoroutineHits("unknown"); hits != nil {
+		t.Errorf("unknown anchor hits %v, want nil", hits)
+	}
+}
+
+func TestCurrentGoroutineID(t *testing.T) {
+	var main = currentGoroutineID()
+	var other = make(chan uint64)
+	go func() { other <- currentGoroutineID() }()
+
+	if id := <-other; main == 0 || id == 0 || id == main {
+		t.Errorf("goroutine IDs %d and %d, want distinct non-zero IDs", main, id)
+	}
+	if id := currentGoroutineID(); id != main {
+		t.Errorf("goroutine ID %d, then %d", main, id)
+	}
+}
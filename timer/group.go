@@ -0,0 +1,164 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Group of the anchors without a category in the grouped output
+const unlabeledGroup = "(unlabeled)"
+
+/*
+StartLabeled is Start also labeling the anchor with a category, e.g. its
+subsystem such as "db" or "http", as SetCategory does. The label is kept for
+the next hits, an empty label leaving the current one in place.
+*/
+func (p *Profiler) StartLabeled(anchorName string, label string) {
+	if profilingDisabled() {
+		return
+	}
+
+	if label != "" {
+		p.SetCategory(anchorName, label)
+	}
+
+	p.Start(anchorName)
+}
+
+/*
+OutputGrouped displays the same report as Output, with the time of every
+category summed above the anchors, the anchors without one falling into the
+"(unlabeled)" group. Subtotals leave out the children, so that they add up to
+the total minus the unaccounted time.
+*/
+func (p *Profiler) OutputGrouped() {
+	if profilingDisabled() {
+		return
+	}
+
+	var destination, closeDestination = outputDestination()
+	defer closeDestination()
+
+	p.WriteGrouped(destination)
+}
+
+// WriteGrouped writes the report of OutputGrouped to w.
+func (p *Profiler) WriteGrouped(w io.Writer) {
+	if profilingDisabled() {
+		return
+	}
+
+	var report = reportBuffer{destination: w}
+
+	p.mu.Lock()
+	p.write(&report, writeOptions{grouped: true})
+	p.mu.Unlock()
+
+	w.Write(report.Bytes())
+}
+
+type group struct {
+	label   string
+	hits    int64
+	tscount int64
+	anchors int
+}
+
+// writeGroups writes the subtotals of the categories, most expensive first.
+func (p *Profiler) writeGroups(w io.Writer) {
+	var positions = make(map[string]int)
+	var groups []group
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		var label = p.categories[anchor.name]
+		if label == "" {
+			label = unlabeledGroup
+		}
+
+		var position, exists = positions[label]
+		if !exists {
+			position = len(groups)
+			positions[label] = position
+			groups = append(groups, group{label: label})
+		}
+
+		groups[position].hits = groups[position].hits + anchor.hits
+		groups[position].tscount = groups[position].tscount + anchor.tscount
+		groups[position].anchors = groups[position].anchors + 1
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].tscount > groups[j].tscount
+	})
+
+	fmt.Fprintf(w, "%*s:\n", p.nameLength(), "groups")
+	for _, g := range groups {
+		fmt.Fprintf(w, "%*s: %s (%s) -- calls: %d, anchors: %d\n", p.nameLength()+2, g.label,
+			p.formatElapsed(p.milliseconds(g.tscount)), formatPercent(g.tscount, p.totalAnchor.tscount),
+			g.hits, g.anchors)
+	}
+}
+
+/*
+AggregatedResult sums the results of several anchors. Since anchors only
+account for their own time, excluding children, the TSCount of all groups adds
+up to the total minus the uninstrumented time.
+*/
+type AggregatedResult struct {
+	Anchors int
+
+	Hits    int64
+	TSCount int64
+	Bytes   int64
+
+	Elapsed float64
+	Percent float64
+}
+
+/*
+GroupBy aggregates the anchors by the key keyFn derives from their name, e.g.
+everything before the first dot for subsystem level rollups. Unlike the
+categories of OutputGrouped, the grouping isn't stored on the anchors.
+*/
+func (p *Profiler) GroupBy(keyFn func(name string) string) map[string]AggregatedResult {
+	var report = p.Snapshot()
+	var groups = make(map[string]AggregatedResult)
+
+	for _, result := range report.Anchors {
+		var key = keyFn(result.Name)
+		var group = groups[key]
+
+		group.Anchors = group.Anchors + 1
+		group.Hits = group.Hits + result.Hits
+		group.TSCount = group.TSCount + result.TSCount
+		group.Bytes = group.Bytes + result.Bytes
+		group.Elapsed = group.Elapsed + result.Elapsed
+		group.Percent = group.Percent + result.Percent
+
+		groups[key] = group
+	}
+
+	return groups
+}
+
+// StartLabeled starts an anchor of the default profiler with a category.
+func StartLabeled(anchorName string, label string) {
+	defaultProfiler.StartLabeled(anchorName, label)
+}
+
+// OutputGrouped displays the default profiler report with the subtotals of
+// its categories.
+func OutputGrouped() {
+	defaultProfiler.OutputGrouped()
+}
+
+// WriteGrouped writes the default profiler report with the subtotals of its
+// categories to w.
+func WriteGrouped(w io.Writer) {
+	defaultProfiler.WriteGrouped(w)
+}
+
+// GroupBy aggregates the anchors of the default profiler.
+func GroupBy(keyFn func(name string) string) map[string]AggregatedResult {
+	return defaultProfiler.GroupBy(keyFn)
+}
@@ -0,0 +1,96 @@
+package timer
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.Start("run")
+	clock.advance(100)
+	for _, name := range []string{"db.query", "http.read", "db.query", "db.commit"} {
+		p.StartThroughput(name, 10)
+		clock.advance(200)
+		p.Stop(name)
+	}
+	p.Stop("run")
+
+	var groups = p.GroupBy(func(name string) string {
+		var subsystem, _, _ = strings.Cut(name, ".")
+		return subsystem
+	})
+	var want = map[string]AggregatedResult{
+		"run":  {Anchors: 1, Hits: 1, TSCount: 100, Elapsed: 0.0001, Percent: 100.0 / 9},
+		"db":   {Anchors: 2, Hits: 3, TSCount: 600, Bytes: 30, Elapsed: 0.0006, Percent: 600.0 / 9},
+		"http": {Anchors: 1, Hits: 1, TSCount: 200, Bytes: 10, Elapsed: 0.0002, Percent: 200.0 / 9},
+	}
+	if len(groups) != len(want) {
+		t.Errorf("got %d groups %+v, want %d", len(groups), groups, len(want))
+	}
+	for key, expected := range want {
+		var got = groups[key]
+		// Summed floating point values
+		if math.Abs(got.Elapsed-expected.Elapsed) > 1e-12 || math.Abs(got.Percent-expected.Percent) > 1e-9 {
+			t.Errorf("%s: %vms, %v%%, want %vms, %v%%", key, got.Elapsed, got.Percent, expected.Elapsed, expected.Percent)
+		}
+		got.Elapsed, got.Percent, expected.Elapsed, expected.Percent = 0, 0, 0, 0
+		if got != expected {
+			t.Errorf("%s: got %+v, want %+v", key, got, expected)
+		}
+	}
+
+	// Reconciled against the total
+	var tscount int64
+	for _, group := range groups {
+		tscount = tscount + group.TSCount
+	}
+	if total := p.Snapshot().Total.TSCount; tscount != total {
+		t.Errorf("groups sum up to %d units, the total is %d", tscount, total)
+	}
+}
+
+func TestWriteGrouped(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.StartLabeled("query", "db")
+	clock.advance(3000000)
+	p.Stop("query")
+	p.StartLabeled("insert", "db")
+	clock.advance(1000000)
+	p.Stop("insert")
+	p.StartLabeled("serve", "http")
+	clock.advance(2000000)
+	// Keeps its label
+	p.StartLabeled("query", "")
+	clock.advance(1000000)
+	p.Stop("query")
+	p.Stop("serve")
+	p.Start("misc")
+	clock.advance(1000000)
+	p.Stop("misc")
+
+	var buffer bytes.Buffer
+	p.WriteGrouped(&buffer)
+	var text = generatedLine.ReplaceAllString(buffer.String(), "")
+
+	// Children left out of the subtotals
+	const groups = `            groups:
+                  db:      5.000ms (62.50%) -- calls: 3, anchors: 2
+                http:      2.000ms (25.00%) -- calls: 1, anchors: 1
+         (unlabeled):      1.000ms (12.50%) -- calls: 1, anchors: 1
+`
+	if !strings.Contains(text, groups) {
+		t.Errorf("grouped report doesn't contain\n%s\nin\n%s", groups, text)
+	}
+	if plain := generatedLine.ReplaceAllString(output(p), ""); strings.Replace(text, groups, "", 1) != plain {
+		t.Errorf("grouped report\n%s\nwant the report of Output\n%s\nwith the groups", text, plain)
+	}
+	if category := resultOf(t, p.Snapshot(), "query").Category; category != "db" {
+		t.Errorf("query labeled %q, want db", category)
+	}
+}
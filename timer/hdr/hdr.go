@@ -0,0 +1,106 @@
+/*
+Package hdr records the latency distribution of chosen anchors of a
+timer.Profiler in High Dynamic Range histograms, giving accurate percentiles
+such as the p99.9 over several orders of magnitude without keeping every
+sample.
+
+	var recorder = hdr.New(p)
+	recorder.EnableHDR("request", time.Microsecond, time.Minute, 3)
+	...
+	var p999 = recorder.Percentile("request", 99.9)
+
+Values are the time between each Start and Stop of the anchor, children
+included. The recorder installs itself with Profiler.SetOnStop, replacing any
+function set before.
+*/
+package hdr
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+// ErrInvalidRange is returned by EnableHDR for a range or precision the
+// histogram can't track.
+var ErrInvalidRange = errors.New("hdr: invalid histogram range or precision")
+
+// Recorder holds the histograms of the anchors of a profiler.
+type Recorder struct {
+	mu         sync.Mutex
+	histograms map[string]*histogram
+}
+
+// New returns a Recorder receiving the hits of p.
+func New(p *timer.Profiler) *Recorder {
+	var r = &Recorder{histograms: make(map[string]*histogram)}
+	p.SetOnStop(r.record)
+	return r
+}
+
+/*
+EnableHDR starts recording the named anchor in a histogram tracking durations
+from min to max with sigfigs significant figures, 1 to 5. Durations outside
+the range are clamped to it. Enabling an anchor again starts a new histogram.
+The name is the one recorded by the profiler, after prefixing and truncation.
+*/
+func (r *Recorder) EnableHDR(name string, min time.Duration, max time.Duration, sigfigs int) error {
+	if min < 1 || max < 2*min || sigfigs < 1 || sigfigs > 5 {
+		return ErrInvalidRange
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.histograms[name] = newHistogram(int64(min), int64(max), sigfigs)
+	return nil
+}
+
+// DisableHDR stops recording the named anchor and drops its histogram.
+func (r *Recorder) DisableHDR(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.histograms, name)
+}
+
+/*
+Percentile returns the duration below which percentile percent of the hits of
+the named anchor fall, e.g. 99.9, within the precision of its histogram. It is
+zero for an anchor not enabled or not hit yet.
+*/
+func (r *Recorder) Percentile(name string, percentile float64) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var h, exists = r.histograms[name]
+	if !exists {
+		return 0
+	}
+
+	return time.Duration(h.valueAtPercentile(percentile))
+}
+
+// Count returns the number of hits recorded for the named anchor.
+func (r *Recorder) Count(name string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var h, exists = r.histograms[name]
+	if !exists {
+		return 0
+	}
+
+	return h.totalCount
+}
+
+func (r *Recorder) record(name string, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if h, exists := r.histograms[name]; exists {
+		h.record(int64(elapsed))
+	}
+}
@@ -0,0 +1,72 @@
+package hdr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+func TestEnableHDRRange(t *testing.T) {
+	var tests = []struct {
+		name     string
+		min, max time.Duration
+		sigfigs  int
+		valid    bool
+	}{
+		{"valid", time.Microsecond, time.Minute, 3, true},
+		{"zero min", 0, time.Minute, 3, false},
+		{"max below twice min", time.Second, time.Second, 3, false},
+		{"no significant figure", time.Microsecond, time.Minute, 0, false},
+		{"too many significant figures", time.Microsecond, time.Minute, 6, false},
+	}
+
+	for _, test := range tests {
+		var r = New(timer.New())
+		if err := r.EnableHDR("a", test.min, test.max, test.sigfigs); (err == nil) != test.valid {
+			t.Errorf("%s: EnableHDR = %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var p = timer.New()
+	var r = New(p)
+	if err := r.EnableHDR("request", time.Microsecond, time.Minute, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the enabled anchors are recorded
+	for i := 0; i < 3; i++ {
+		p.Start("request")
+		p.Start("other")
+		p.Stop("other")
+		p.Stop("request")
+	}
+	if count := r.Count("request"); count != 3 {
+		t.Errorf("recorded %d hits of request, want 3", count)
+	}
+	if count := r.Count("other"); count != 0 {
+		t.Errorf("recorded %d hits of other, not enabled", count)
+	}
+
+	// Enabling again starts over
+	r.EnableHDR("request", time.Microsecond, time.Minute, 3)
+	for _, elapsed := range []time.Duration{1, 2, 3, 4, 100} {
+		r.record("request", elapsed*time.Millisecond)
+	}
+	if count := r.Count("request"); count != 5 {
+		t.Errorf("recorded %d hits, want the 5 since enabled again", count)
+	}
+	if p50 := r.Percentile("request", 50); p50 < 3*time.Millisecond || p50 > 3003*time.Microsecond {
+		t.Errorf("p50 = %v, want 3ms", p50)
+	}
+	if p99 := r.Percentile("request", 99); p99 < 100*time.Millisecond || p99 > 100100*time.Microsecond {
+		t.Errorf("p99 = %v, want 100ms", p99)
+	}
+
+	r.DisableHDR("request")
+	if count, p50 := r.Count("request"), r.Percentile("request", 50); count != 0 || p50 != 0 {
+		t.Errorf("disabled anchor: %d hits, p50 %v, want none", count, p50)
+	}
+}
@@ -0,0 +1,132 @@
+package hdr
+
+import (
+	"math"
+	"math/bits"
+)
+
+/*
+histogram is a High Dynamic Range histogram: values between lowest and
+highest are counted in buckets whose width grows with the magnitude of the
+values, keeping a constant relative precision of the given number of
+significant figures.
+*/
+type histogram struct {
+	lowest  int64
+	highest int64
+
+	unitMagnitude               int
+	subBucketHalfCountMagnitude int
+	subBucketCount              int64
+	subBucketHalfCount          int64
+	subBucketMask               int64
+
+	counts     []int64
+	totalCount int64
+}
+
+func newHistogram(lowest int64, highest int64, sigfigs int) *histogram {
+	var largestSingleUnit = 2 * int64(math.Pow10(sigfigs))
+	var subBucketCountMagnitude = bits.Len64(uint64(largestSingleUnit - 1))
+
+	var h = &histogram{
+		lowest:        lowest,
+		highest:       highest,
+		unitMagnitude: bits.Len64(uint64(lowest)) - 1,
+	}
+
+	h.subBucketHalfCountMagnitude = subBucketCountMagnitude - 1
+	if h.subBucketHalfCountMagnitude < 0 {
+		h.subBucketHalfCountMagnitude = 0
+	}
+	h.subBucketCount = 1 << uint(h.subBucketHalfCountMagnitude+1)
+	h.subBucketHalfCount = h.subBucketCount / 2
+	h.subBucketMask = (h.subBucketCount - 1) << uint(h.unitMagnitude)
+
+	var bucketCount = 1
+	var smallestUntrackable = h.subBucketCount << uint(h.unitMagnitude)
+	for smallestUntrackable <= highest {
+		if smallestUntrackable > math.MaxInt64/2 {
+			bucketCount = bucketCount + 1
+			break
+		}
+		smallestUntrackable = smallestUntrackable << 1
+		bucketCount = bucketCount + 1
+	}
+
+	h.counts = make([]int64, (bucketCount+1)*int(h.subBucketHalfCount))
+	return h
+}
+
+func (h *histogram) bucketIndex(value int64) int {
+	var magnitude = bits.Len64(uint64(value | h.subBucketMask))
+	return magnitude - h.unitMagnitude - (h.subBucketHalfCountMagnitude + 1)
+}
+
+func (h *histogram) countsIndex(value int64) int {
+	var bucket = h.bucketIndex(value)
+	var subBucket = int(value >> uint(bucket+h.unitMagnitude))
+	return (bucket+1)<<uint(h.subBucketHalfCountMagnitude) + subBucket - int(h.subBucketHalfCount)
+}
+
+// highestEquivalentValue returns the largest value counted at index.
+func (h *histogram) highestEquivalentValue(index int) int64 {
+	var bucket = index>>uint(h.subBucketHalfCountMagnitude) - 1
+	var subBucket = int64(index&(int(h.subBucketHalfCount)-1)) + h.subBucketHalfCount
+	if bucket < 0 {
+		subBucket = subBucket - h.subBucketHalfCount
+		bucket = 0
+	}
+
+	var shift = uint(bucket + h.unitMagnitude)
+	return (subBucket+1)<<shift - 1
+}
+
+// record counts value, clamped to the trackable range.
+func (h *histogram) record(value int64) {
+	if value < h.lowest {
+		value = h.lowest
+	}
+	if value > h.highest {
+		value = h.highest
+	}
+
+	var index = h.countsIndex(value)
+	if index < 0 || index >= len(h.counts) {
+		return
+	}
+
+	h.counts[index] = h.counts[index] + 1
+	h.totalCount = h.totalCount + 1
+}
+
+// valueAtPercentile returns the value below which percentile percent of the
+// recorded values fall, within the histogram precision.
+func (h *histogram) valueAtPercentile(percentile float64) int64 {
+	if h.totalCount == 0 {
+		return 0
+	}
+
+	if percentile > 100 {
+		percentile = 100
+	}
+
+	var target = int64(percentile/100*float64(h.totalCount) + 0.5)
+	if target < 1 {
+		target = 1
+	}
+
+	var total int64
+	for index, count := range h.counts {
+		total = total + count
+		if total >= target {
+			var value = h.highestEquivalentValue(index)
+			if value > h.highest {
+				return h.highest
+			}
+			return value
+		}
+	}
+
+	return h.highest
+}
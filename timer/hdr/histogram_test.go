@@ -0,0 +1,63 @@
+package hdr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHistogramPercentiles(t *testing.T) {
+	var tests = []struct {
+		name    string
+		lowest  int64
+		highest int64
+		sigfigs int
+	}{
+		{"nanoseconds to a second", 1, 1000000000, 3},
+		{"microseconds to a minute", 1000, 60000000000, 2},
+		{"coarse", 1000, 1000000000, 1},
+		{"fine", 1, 100000000, 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var h = newHistogram(test.lowest, test.highest, test.sigfigs)
+
+			// 1000 values evenly spread over 3 orders of magnitude
+			var values []int64
+			for i := 1; i <= 1000; i++ {
+				var value = int64(float64(test.lowest) * math.Pow(1000, float64(i)/1000) * 10)
+				values = append(values, value)
+				h.record(value)
+			}
+			if h.totalCount != 1000 {
+				t.Fatalf("counted %d values, want 1000", h.totalCount)
+			}
+
+			var precision = math.Pow10(-test.sigfigs)
+			for _, percentile := range []float64{1, 50, 90, 99, 99.9, 100} {
+				var want = values[int(percentile*10+0.5)-1]
+				var got = h.valueAtPercentile(percentile)
+				if math.Abs(float64(got-want))/float64(want) > precision {
+					t.Errorf("p%v = %d, want %d within %v", percentile, got, want, precision)
+				}
+			}
+		})
+	}
+}
+
+func TestHistogramClamps(t *testing.T) {
+	var h = newHistogram(1000, 1000000, 3)
+	h.record(1)
+	h.record(1000000000)
+
+	// The lowest value is the resolution of the histogram
+	if low := h.valueAtPercentile(1); low < 1000 || low >= 2000 {
+		t.Errorf("low value counted at %d, want the lowest 1000 within its resolution", low)
+	}
+	if high := h.valueAtPercentile(100); high != 1000000 {
+		t.Errorf("high value counted at %d, want the highest 1000000", high)
+	}
+	if empty := newHistogram(1, 1000, 3).valueAtPercentile(50); empty != 0 {
+		t.Errorf("empty histogram p50 = %d, want 0", empty)
+	}
+}
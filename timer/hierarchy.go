@@ -0,0 +1,92 @@
+package timer
+
+import "sort"
+
+// hierarchyOrder returns the anchors depth first, each anchor followed by its
+// children in the order they were first started, or sorted by less if set.
+func (p *Profiler) hierarchyOrder(less func(a *anchor, b *anchor) bool) []*anchor {
+	var children = make(map[*anchor][]*anchor, p.index)
+	var roots []*anchor
+
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		if anchor.parent == nil {
+			roots = append(roots, anchor)
+		} else {
+			children[anchor.parent] = append(children[anchor.parent], anchor)
+		}
+	}
+
+	var ordered = make([]*anchor, 0, p.index)
+	var visit func(anchors []*anchor)
+	visit = func(anchors []*anchor) {
+		if less != nil {
+			sort.SliceStable(anchors, func(i, j int) bool {
+				return less(anchors[i], anchors[j])
+			})
+		}
+
+		for _, anchor := range anchors {
+			ordered = append(ordered, anchor)
+			visit(children[anchor])
+		}
+	}
+	visit(roots)
+
+	return ordered
+}
+
+/*
+CriticalPath returns the chain of nested anchors taking the most time: the
+total, then its child with the largest time including its own children, then
+that anchor's largest child and so on down to a leaf.
+*/
+func (p *Profiler) CriticalPath() []AnchorResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sumSubtrees()
+
+	var path = []AnchorResult{p.result(p.totalAnchor)}
+
+	var parent *anchor
+	for {
+		var heaviest *anchor
+		for _, anchor := range p.anchors[1 : p.index+1] {
+			if anchor.parent == parent && (heaviest == nil || anchor.subtree > heaviest.subtree) {
+				heaviest = anchor
+			}
+		}
+
+		if heaviest == nil {
+			return path
+		}
+
+		path = append(path, p.result(heaviest))
+		parent = heaviest
+	}
+}
+
+// CriticalPath returns the most time consuming chain of anchors of the
+// default profiler.
+func CriticalPath() []AnchorResult {
+	return defaultProfiler.CriticalPath()
+}
+
+/*
+SetPercentOfParent adds to each anchor line of Output the time of the anchor
+including its children as a percentage of the same time of its parent, the
+total for top-level anchors. Unlike the percentage of the total, it doesn't
+shrink with depth, which helps drilling down a subtree level by level.
+*/
+func (p *Profiler) SetPercentOfParent(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.showPercentOfParent = enabled
+}
+
+// SetPercentOfParent shows the percentage of parent in the default profiler
+// Output.
+func SetPercentOfParent(enabled bool) {
+	defaultProfiler.SetPercentOfParent(enabled)
+}
@@ -0,0 +1,73 @@
+package timer
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runTree records the own times a: 500 with its child x: 10, and b: 10 with
+// its children c: 600, itself holding d: 400, and y: 600.
+func runTree(p *Profiler, clock *fakeClock) {
+	var step = func(name string, tscount int64, children func()) {
+		p.Start(name)
+		clock.advance(tscount)
+		children()
+		p.Stop(name)
+	}
+	var leaf = func() {}
+
+	step("a", 500, func() { step("x", 10, leaf) })
+	step("b", 10, func() {
+		step("c", 600, func() { step("d", 400, leaf) })
+		step("y", 600, leaf)
+	})
+}
+
+func TestCriticalPath(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	if path := p.CriticalPath(); len(path) != 1 || path[0].Name != TOTAL_ANCHOR_NAME {
+		t.Errorf("empty profile path %+v, want the total alone", path)
+	}
+
+	runTree(p, clock)
+
+	// The heaviest subtree at each level, not the heaviest own time
+	var names []string
+	for _, result := range p.CriticalPath() {
+		names = append(names, result.Name)
+	}
+	if want := []string{TOTAL_ANCHOR_NAME, "b", "c", "d"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("path %v, want %v", names, want)
+	}
+}
+
+func TestPercentOfParent(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	runTree(p, clock)
+
+	// Subtree times over that of the parent, the total at the top level
+	var want = map[string]float64{
+		"a": 100 * 510.0 / 2120,
+		"x": 100 * 10.0 / 510,
+		"b": 100 * 1610.0 / 2120,
+		"c": 100 * 1000.0 / 1610,
+		"d": 40,
+		"y": 100 * 600.0 / 1610,
+	}
+	for _, result := range p.Snapshot().Anchors {
+		if math.Abs(result.PercentOfParent-want[result.Name]) > 1e-9 {
+			t.Errorf("%s: %v%% of its parent, want %v%%", result.Name, result.PercentOfParent, want[result.Name])
+		}
+	}
+
+	for _, enabled := range []bool{false, true} {
+		p.SetPercentOfParent(enabled)
+		if text := output(p); strings.Contains(text, ", of parent: 40.00%") != enabled {
+			t.Errorf("percent of parent shown = %v, want %v:\n%s", !enabled, enabled, text)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package timer
+
+import "time"
+
+/*
+SetOnRegister sets a function called with the name of every new anchor, once,
+when it is first started or recorded and before its first hit is counted. A
+nil function removes the hook. The function runs with the profiler locked: it
+must be quick and must not call the profiler.
+*/
+func (p *Profiler) SetOnRegister(onRegister func(name string)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.onRegister = onRegister
+}
+
+// SetOnRegister sets the function called for every new anchor of the
+// default profiler.
+func SetOnRegister(onRegister func(name string)) {
+	defaultProfiler.SetOnRegister(onRegister)
+}
+
+/*
+SetOnStop sets a function called on every Stop with the name of the anchor
+and the time since the matching Start, children included, which suits latency
+distributions. Warm-up hits are left out. A nil function removes the hook. The
+function runs with the profiler locked: it must be quick and must not call the
+profiler.
+*/
+func (p *Profiler) SetOnStop(onStop func(name string, elapsed time.Duration)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.onStop = onStop
+}
+
+// SetOnStop sets the function called on every Stop of the default profiler.
+func SetOnStop(onStop func(name string, elapsed time.Duration)) {
+	defaultProfiler.SetOnStop(onStop)
+}
@@ -0,0 +1,38 @@
+package timer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOnRegister(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	var registered []string
+	p.SetOnRegister(func(name string) {
+		registered = append(registered, name)
+	})
+
+	for i := 0; i < 3; i++ {
+		p.Start("a")
+		p.Start("b")
+		clock.advance(10)
+		p.Stop("b")
+		p.Stop("a")
+	}
+	p.RecordDuration("c", time.Microsecond)
+	p.RecordDuration("a", time.Microsecond)
+
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(registered, want) {
+		t.Errorf("registered %v, want %v once each", registered, want)
+	}
+
+	p.SetOnRegister(nil)
+	p.Start("d")
+	p.Stop("d")
+	if len(registered) != 3 {
+		t.Errorf("removed hook called for %v", registered[3:])
+	}
+}
@@ -0,0 +1,96 @@
+package timer
+
+import "io"
+
+/*
+AddBytes adds processed bytes to a started anchor, for when the amount is only
+known after the work, unlike with StartThroughput.
+*/
+func (p *Profiler) AddBytes(anchorName string, processedBytes int64) {
+	if profilingDisabled() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		warnError(&AnchorError{Op: "add bytes", Anchor: anchorName, Err: err})
+		return
+	}
+
+	var anchor, exists = p.anchorsByName[key]
+	if !exists {
+		if !p.limitReached {
+			warnError(&AnchorError{Op: "add bytes", Anchor: anchorName, Err: ErrUnknownAnchor})
+		}
+		return
+	}
+
+	anchor.bytes = anchor.bytes + processedBytes
+	if anchor.latest != nil {
+		anchor.latest.bytes = anchor.latest.bytes + processedBytes
+	}
+}
+
+type profiledReader struct {
+	profiler *Profiler
+	name     string
+	reader   io.Reader
+}
+
+func (r *profiledReader) Read(b []byte) (int, error) {
+	r.profiler.Start(r.name)
+	var n, err = r.reader.Read(b)
+	r.profiler.AddBytes(r.name, int64(n))
+	r.profiler.Stop(r.name)
+
+	return n, err
+}
+
+type profiledWriter struct {
+	profiler *Profiler
+	name     string
+	writer   io.Writer
+}
+
+func (w *profiledWriter) Write(b []byte) (int, error) {
+	w.profiler.Start(w.name)
+	var n, err = w.writer.Write(b)
+	w.profiler.AddBytes(w.name, int64(n))
+	w.profiler.Stop(w.name)
+
+	return n, err
+}
+
+/*
+WrapReader returns a reader timing each Read of r into the named anchor, and
+counting the bytes read for its throughput.
+*/
+func (p *Profiler) WrapReader(anchorName string, r io.Reader) io.Reader {
+	return &profiledReader{profiler: p, name: anchorName, reader: r}
+}
+
+/*
+WrapWriter returns a writer timing each Write to w into the named anchor, and
+counting the bytes written for its throughput.
+*/
+func (p *Profiler) WrapWriter(anchorName string, w io.Writer) io.Writer {
+	return &profiledWriter{profiler: p, name: anchorName, writer: w}
+}
+
+// AddBytes adds processed bytes to an anchor of the default profiler.
+func AddBytes(anchorName string, processedBytes int64) {
+	defaultProfiler.AddBytes(anchorName, processedBytes)
+}
+
+// WrapReader times the reads of r on the default profiler.
+func WrapReader(anchorName string, r io.Reader) io.Reader {
+	return defaultProfiler.WrapReader(anchorName, r)
+}
+
+// WrapWriter times the writes to w on the default profiler.
+func WrapWriter(anchorName string, w io.Writer) io.Writer {
+	return defaultProfiler.WrapWriter(anchorName, w)
+}
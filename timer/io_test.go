@@ -0,0 +1,62 @@
+package timer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// slowReader advances the clock by ticks on each Read.
+type slowReader struct {
+	reader io.Reader
+	clock  *fakeClock
+	ticks  int64
+}
+
+func (r *slowReader) Read(b []byte) (int, error) {
+	r.clock.advance(r.ticks)
+	return r.reader.Read(b)
+}
+
+func TestWrapReaderWriter(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	var data = strings.Repeat("x", 10000)
+	var source = &slowReader{reader: strings.NewReader(data), clock: clock, ticks: 100}
+	var written bytes.Buffer
+	var buffer = make([]byte, 4096)
+	if _, err := io.CopyBuffer(p.WrapWriter("write", &written), p.WrapReader("read", source), buffer); err != nil {
+		t.Fatal(err)
+	}
+	if written.String() != data {
+		t.Errorf("copied %d bytes, want %d", written.Len(), len(data))
+	}
+
+	// 3 reads, then the one hitting io.EOF
+	var report = p.Snapshot()
+	var read, write = resultOf(t, report, "read"), resultOf(t, report, "write")
+	if read.Hits != 4 || read.Bytes != 10000 || read.TSCount != 400 {
+		t.Errorf("read %d hits of %d bytes in %d units, want 4 of 10000 in 400", read.Hits, read.Bytes, read.TSCount)
+	}
+	if write.Hits != 3 || write.Bytes != 10000 {
+		t.Errorf("write %d hits of %d bytes, want 3 of 10000", write.Hits, write.Bytes)
+	}
+}
+
+func TestWrapReaderErrors(t *testing.T) {
+	useFakeClock(t)
+	var p = New()
+
+	var failure = errors.New("failure")
+	var n, err = p.WrapReader("read", iotest.ErrReader(failure)).Read(make([]byte, 8))
+	if n != 0 || err != failure {
+		t.Errorf("Read = %d, %v, want 0, %v", n, err, failure)
+	}
+	if result := resultOf(t, p.Snapshot(), "read"); result.Hits != 1 || result.Open {
+		t.Errorf("read %+v, want a single stopped hit", result)
+	}
+}
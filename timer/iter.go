@@ -0,0 +1,37 @@
+//go:build go1.23
+
+package timer
+
+import "iter"
+
+// AnchorView is the state of an anchor as yielded by Anchors.
+type AnchorView = AnchorResult
+
+/*
+Anchors returns an iterator over the anchors in hierarchy order, each anchor
+followed by its children. The anchors are copied when the iteration starts,
+so they reflect a consistent state even while profiling continues.
+*/
+func (p *Profiler) Anchors() iter.Seq[AnchorView] {
+	return func(yield func(AnchorView) bool) {
+		p.mu.Lock()
+		p.sumSubtrees()
+		var ordered = p.hierarchyOrder(nil)
+		var views = make([]AnchorView, len(ordered))
+		for i, anchor := range ordered {
+			views[i] = p.result(anchor)
+		}
+		p.mu.Unlock()
+
+		for _, view := range views {
+			if !yield(view) {
+				return
+			}
+		}
+	}
+}
+
+// Anchors iterates over the anchors of the default profiler.
+func Anchors() iter.Seq[AnchorView] {
+	return defaultProfiler.Anchors()
+}
@@ -0,0 +1,45 @@
+//go:build go1.23
+
+package timer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnchorsIterator(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	// Registered in a different order than the hierarchy
+	for _, path := range [][]string{{"a", "b"}, {"c", "d"}, {"a", "e"}} {
+		for _, name := range path {
+			p.Start(name)
+			clock.advance(10)
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			p.Stop(path[i])
+		}
+	}
+
+	var names []string
+	p.Anchors()(func(view AnchorView) bool {
+		names = append(names, view.Name)
+		// Copied beforehand, not deadlocking
+		p.Start("during")
+		p.Stop("during")
+		return true
+	})
+	if want := []string{"a", "b", "e", "c", "d"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("yielded %v, want %v", names, want)
+	}
+
+	var first []string
+	p.Anchors()(func(view AnchorView) bool {
+		first = append(first, view.Name)
+		return len(first) < 2
+	})
+	if len(first) != 2 {
+		t.Errorf("yielded %v after stopping at 2", first)
+	}
+}
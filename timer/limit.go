@@ -0,0 +1,42 @@
+package timer
+
+import "sort"
+
+/*
+LimitOutput makes Output list only the maxAnchors anchors with the most elapsed
+time of their own, in the order selected by SetOutputOrder, the others being
+summed up in a single "others" line. The total is unaffected. Zero or a
+negative value lists every anchor, which is the default.
+*/
+func (p *Profiler) LimitOutput(maxAnchors int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.outputLimit = maxAnchors
+}
+
+// listedAnchors returns the anchors Output lists under LimitOutput, nil when
+// every anchor is listed.
+func (p *Profiler) listedAnchors() map[*anchor]bool {
+	if p.outputLimit <= 0 || p.index <= p.outputLimit {
+		return nil
+	}
+
+	var anchors = append([]*anchor(nil), p.anchors[1:p.index+1]...)
+	sort.Slice(anchors, func(i, j int) bool {
+		return anchors[i].tscount > anchors[j].tscount
+	})
+
+	var listed = make(map[*anchor]bool, p.outputLimit)
+	for _, anchor := range anchors[:p.outputLimit] {
+		listed[anchor] = true
+	}
+
+	return listed
+}
+
+// LimitOutput caps the number of anchors listed by the default profiler
+// Output.
+func LimitOutput(maxAnchors int) {
+	defaultProfiler.LimitOutput(maxAnchors)
+}
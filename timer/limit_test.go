@@ -0,0 +1,48 @@
+package timer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLimitOutput(t *testing.T) {
+	var names = []string{"a", "b", "c", "d", "e"}
+	var tests = []struct {
+		limit  int
+		rows   []string
+		others string
+	}{
+		{0, names, ""},
+		{-1, names, ""},
+		{5, names, ""},
+		// Listed in registration order, the shortest summed up
+		{2, []string{"b", "d"}, "others:      0.000ms (20.00%) -- calls: 3, anchors: 3"},
+	}
+
+	for _, test := range tests {
+		var clock = useFakeClock(t)
+		var p = New()
+		for i, name := range names {
+			p.Start(name)
+			clock.advance(int64(100 + 500*(i%2)))
+			p.Stop(name)
+		}
+		p.LimitOutput(test.limit)
+
+		var text = output(p)
+		if rows := anchorRows(text, names...); !reflect.DeepEqual(rows, test.rows) {
+			t.Errorf("limit %d: rows %v, want %v", test.limit, rows, test.rows)
+		}
+		if test.others != "" && !strings.Contains(text, test.others) {
+			t.Errorf("limit %d: report doesn't contain %q:\n%s", test.limit, test.others, text)
+		}
+		if test.others == "" && strings.Contains(text, "others:") {
+			t.Errorf("limit %d: unexpected others line:\n%s", test.limit, text)
+		}
+		// The total reflects every anchor
+		if total := p.Snapshot().Total.TSCount; total != 1500 {
+			t.Errorf("limit %d: total %d units, want 1500", test.limit, total)
+		}
+	}
+}
@@ -1,43 +1,253 @@
 package timer
 
-// #cgo CFLAGS: -g -Wall
-// #include <stdlib.h>
-// #include "timer.h"
-import "C"
 import (
-	"fmt"
-	"os"
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// TIMER_ENV_VAR disables profiling when set to "0", "false", "off" or "no",
+// see Start.
 const TIMER_ENV_VAR = "TIMER"
 
 const TOTAL_ANCHOR_NAME = "total"
 const anchorNameMaxLength = 18
 
-const maxHandledAnchors = 1000
+// The anchor slots grow on demand from initialAnchorSlots, up to
+// maxHandledAnchors which only guards against names built from unbounded
+// input, such as request IDs
+const initialAnchorSlots = 64
+const maxHandledAnchors = 1 << 20
 
-var verbose bool
 var cpuFrequency int64
 
-var index int
-var anchors []*anchor = make([]*anchor, maxHandledAnchors)
-var anchorsByName = make(map[string]*anchor, maxHandledAnchors)
+/*
+Profiler holds the state of a single profiling session. The package level
+functions operate on a default, unnamed Profiler; independent instances can be
+created with NewProfiler when several sessions must be reported side by side.
+
+A Profiler is safe for concurrent use, its state being guarded by a mutex, but
+the hierarchy is shared by every goroutine: an anchor first started while an
+anchor of another goroutine is open is registered as its child, and pauses it
+under AccountingExclusive. Anchors may be stopped in any order, the ones
+started later keep running. Give each goroutine its own Profiler, reported
+together with CombinedOutput or Merge, to keep their hierarchies apart.
+*/
+type Profiler struct {
+	mu sync.Mutex
+
+	name string
+
+	// Run metadata set by SetMetadata and AddMetadata
+	metadata map[string]string
+
+	session
+	// Sessions saved by PushSession
+	sessions []session
+
+	seriesInterval   time.Duration
+	seriesMaxBuckets int
+
+	wallThroughput bool
+	wallTime       bool
+
+	// Units of the byte counts of Output, set by SetByteUnits
+	byteUnits ByteUnits
+
+	// CPU timer units deducted from every hit, zero unless
+	// SetOverheadCompensation is enabled
+	overheadCompensation int64
+
+	nameTooLongPolicy NameTooLongPolicy
+	strictNames       bool
+
+	// Limits set by SetMaxAnchors and SetMaxNameLength, zero for the default
+	maxAnchors    int
+	maxNameLength int
+
+	// First full name truncated into each anchor, emptied once a collision
+	// was warned about
+	truncatedNames map[string]string
+
+	gcEnabled bool
+
+	allocStats bool
+
+	autoReset bool
+
+	precision int
+
+	// Multiple of the clock resolution set by SetResolutionWarning
+	resolutionMultiple int64
+
+	// Reference frequency set by NormalizeTo, zero if not normalized
+	normalizedFrequency int64
+
+	disabledAnchors map[string]bool
+
+	accounting AccountingMode
+
+	// Category of each anchor name, set by SetCategory
+	categories map[string]string
+
+	// Anchor names left out of the percentage base, set by
+	// SetExcludedFromTotal
+	excludedFromTotal map[string]bool
+
+	// Anchors whose time per hit distribution is recorded
+	distributions map[string]bool
+
+	// Longest time per hit of the anchors, set by SetBudget
+	budgets map[string]time.Duration
+
+	// Time the anchors are compared to, set by SetReferenceDuration
+	referenceDuration time.Duration
+
+	// External time bases set by SetThroughputDuration
+	throughputDurations map[string]time.Duration
+
+	outputOrder    OutputOrder
+	flatOutput     bool
+	proportionBars bool
+
+	// Maximum number of anchors listed by Output, unlimited if zero
+	outputLimit int
+
+	// Fewest hits of the anchors listed by Output, set by SetMinHits
+	minHits int64
+
+	// Number of warm-up hits of each anchor name, set by SetWarmup
+	warmups map[string]int64
+
+	deviationStats bool
+
+	// Concatenation of the prefixes pushed by PushPrefix, and the length of
+	// the concatenation before each push
+	prefix        string
+	prefixLengths []int
 
-var totalTiming *timing = &timing{}
-var currentAnchor *anchor
-var currentTiming *timing
+	timingStackCapacity int
 
-var totalAnchor = &anchor{
-	name: TOTAL_ANCHOR_NAME,
+	// Maximum number of anchors open at once, set by SetMaxNesting
+	maxNesting int64
+
+	onRegister func(name string)
+	onStop     func(name string, elapsed time.Duration)
+
+	showPercentOfParent bool
+
+	goroutineStats bool
+
+	// Closed to stop the sampler started by StartSampling
+	samplerDone chan struct{}
+
+	// Closed to stop the goroutine started by StartAutoFlush, and by it
+	// when exiting
+	flushDone     chan struct{}
+	flushFinished chan struct{}
+
+	// Pending Stop calls of the anchors open during the latest Reset
+	orphans map[string]int64
 }
 
+// session is the recorded state of a profile, as opposed to its options.
+type session struct {
+	index         int
+	anchors       []*anchor
+	anchorsByName map[string]*anchor
+
+	totalTiming   *timing
+	currentAnchor *anchor
+	currentTiming *timing
+
+	totalAnchor *anchor
+
+	limitReached bool
+
+	// Number of negative durations clamped to zero
+	clockAnomalies int64
+
+	// Number of Stop calls ignored for lack of a matching Start
+	unmatchedStops int64
+
+	// CPU timer units added to the anchors without being measured within
+	// the total: recorded durations and spans, and the calls estimated by
+	// StartSampled
+	externalTSCount int64
+
+	// Number of Start calls not matched by a Stop yet, and its maximum
+	openDepth int64
+	maxDepth  int64
+
+	nestingLimitReached bool
+
+	gcStart gcStats
+
+	// Preallocated timings available to Start, see SetTimingStackCapacity
+	freeTimings []*timing
+
+	// Spans of RecordSpan that may enclose the next one, and the bounds of
+	// all of them
+	spans     []recordedSpan
+	spanStart int64
+	spanEnd   int64
+
+	// Phases begun by BeginPhase, and the running one
+	phases       []*phase
+	currentPhase *phase
+}
+
+func newSession() session {
+	return session{
+		anchors:       make([]*anchor, initialAnchorSlots),
+		anchorsByName: make(map[string]*anchor, initialAnchorSlots),
+
+		totalTiming: &timing{},
+		totalAnchor: &anchor{
+			name: TOTAL_ANCHOR_NAME,
+		},
+	}
+}
+
+var defaultProfiler = NewProfiler("")
+
 type timing struct {
 	start int64
+	// CPU timer reading at Start, unlike start never moved when a child
+	// stops, for the time including children
+	inclusiveStart int64
+	// OS timer reading at Start, only taken for wall time throughput
+	wallStart int64
+	// Allocation counters at Start, only read when tracking allocations
+	allocsStart allocCounters
 	// Do we need to note the stop time here?
 
 	previous *timing
 	anchor   *anchor
+	// Enclosing timing of the same anchor when it is started recursively
+	outer *timing
+	// Set for a warm-up hit, whose time is not recorded
+	warmup bool
+	// CPU timer units accumulated by the anchor during the hit so far
+	own int64
+	// Set for a timing taken from the preallocated stack
+	pooled bool
+	// CPU timer reading at BlockStart, zero unless blocked
+	blockStart int64
+	// Bytes processed during the hit so far
+	bytes int64
+	// Set when the anchor was started again within this hit, whose own time
+	// then leaves out the recursive calls
+	reentered bool
+	// Calls the hit stands for when started by StartSampled, zero otherwise
+	sampleRate int64
+	// CPU timer units of the sampled calls not timed within the hit, still to
+	// leave out of its own time
+	sampledDebt int64
 }
 
 type anchor struct {
@@ -47,71 +257,318 @@ type anchor struct {
 	bytes   int64
 	elapsed float64
 
+	// Items added by StartOps and AddOps
+	ops int64
+
+	// Inclusive wall time, in OS timer units
+	wall int64
+
+	// Inclusive CPU timer units of the anchor and of the anchors started
+	// directly in it, only kept under AccountingInclusive
+	inclusive      int64
+	childInclusive int64
+
+	// Inclusive heap allocations, only counted when tracking allocations
+	allocs     int64
+	allocBytes int64
+
 	name string
 
 	active bool
 
+	// Number of Start calls not matched by a Stop yet, i.e. the current
+	// recursion depth, unrelated to the hierarchy depth
+	open         int64
+	maxRecursion int64
+
+	// Number of Start calls ignored while the anchor was disabled, whose Stop
+	// must be ignored too
+	skipped int64
+
+	// Hits added by Count, without any timing
+	counted int64
+
+	// Calls to StartSampled so far, kept across resets, and the latest rate
+	// of the timed ones
+	sampleCalls int64
+	sampleRate  int64
+
 	parent *anchor
 	latest *timing
+
+	// CPU timer reading of the first hit since the latest reset
+	firstHit int64
+
+	// Warm-up hits done so far, kept across resets
+	warmedUp int64
+
+	// Per hit time and bytes statistics
+	variation variation
+	hitRange  hitRange
+	payload   payload
+
+	// Per hit time histogram, only kept for anchors tracked by
+	// TrackDistribution
+	distribution *distribution
+
+	// Samples taken by StartSampling while the anchor was running
+	samples int64
+
+	// CPU timer units spent between BlockStart and BlockEnd
+	blocked int64
+
+	// Hits longer than the budget set by SetBudget
+	overBudget int64
+
+	// Time including the children as of the latest sumSubtrees
+	subtree int64
+
+	// Hits per goroutine ID, only kept when SetGoroutineStats is enabled
+	goroutines map[uint64]int64
+
+	series *timeSeries
 }
 
+// Reference the OS timer readings are taken from
+var osTimerOrigin = time.Now()
+
+// readOSTimer returns the nanoseconds elapsed since the package was
+// initialized, on the monotonic clock so that wall clock adjustments don't
+// distort the calibration or the wall times.
 func readOSTimer() int64 {
-	return time.Now().UnixMicro()
+	return int64(time.Since(osTimerOrigin))
 }
 
 func getOSTimerFreq() int64 {
-	return 1000000
+	return int64(time.Second)
 }
 
-func readCPUTimer() int64 {
-	cvalue := C.ReadCPUTimer()
-	return int64(cvalue)
+// mulDiv returns a * b / c, c being positive, without overflowing on the
+// product, the result being clamped to the int64 range.
+func mulDiv(a int64, b int64, c int64) int64 {
+	var negative = (a < 0) != (b < 0)
+	var hi, lo = bits.Mul64(absolute(a), absolute(b))
+	if hi >= uint64(c) {
+		// Quotient beyond 64 bits
+		if negative {
+			return math.MinInt64
+		}
+		return math.MaxInt64
+	}
+
+	var quotient, _ = bits.Div64(hi, lo, uint64(c))
+	switch {
+	case quotient > math.MaxInt64 && negative:
+		return math.MinInt64
+	case quotient > math.MaxInt64:
+		return math.MaxInt64
+	case negative:
+		return -int64(quotient)
+	}
+	return int64(quotient)
 }
 
-func getCPUTimerFreq(millisecondsToWait int64) int64 {
-	osFrequency := getOSTimerFreq()
-	if verbose {
-		fmt.Printf("   OS Freq: %v (reported)\n", osFrequency)
+func absolute(value int64) uint64 {
+	if value < 0 {
+		return uint64(-value)
 	}
 
-	cpuStart := readCPUTimer()
-	osStart := readOSTimer()
+	return uint64(value)
+}
+
+func getCPUTimerFreq(millisecondsToWait int64) int64 {
+	return measureCPUTimerFreq(readCPUTimer, readOSTimer, getOSTimerFreq(), millisecondsToWait)
+}
+
+/*
+measureCPUTimerFreq estimates the frequency of the CPU timer read by readCPU,
+counting its units while the OS timer read by readOS, of frequency
+osFrequency, advances by millisecondsToWait. Both clocks are parameters so
+that the computation can be driven by deterministic clocks.
+*/
+func measureCPUTimerFreq(readCPU func() int64, readOS func() int64, osFrequency int64,
+	millisecondsToWait int64) int64 {
+	verbosef("   OS Freq: %v (reported)\n", osFrequency)
+
+	cpuStart := readCPU()
+	osStart := readOS()
 	var osEnd, osElapsed int64
 	osWaitTime := osFrequency * millisecondsToWait / 1000
 	for osElapsed < osWaitTime {
-		osEnd = readOSTimer()
+		osEnd = readOS()
 		osElapsed = osEnd - osStart
 	}
 
-	cpuEnd := readCPUTimer()
+	cpuEnd := readCPU()
 	cpuElapsed := cpuEnd - cpuStart
-	cpuFrequency := osFrequency * cpuElapsed / osElapsed
+	if osElapsed <= 0 {
+		// Nothing to wait for, or a clock going backwards
+		return 0
+	}
+	// The product overflows int64 beyond a few seconds at 1e9 OS timer units
+	cpuFrequency := mulDiv(osFrequency, cpuElapsed, osElapsed)
 
-	if verbose {
-		fmt.Printf("  OS timer: %v -> %v = %v elapsed\n", osStart, osEnd, osElapsed)
-		fmt.Printf("OS seconds: %.4f\n", float64(osElapsed)/float64(osFrequency))
+	if verboseEnabled() {
+		verbosef("  OS timer: %v -> %v = %v elapsed\n", osStart, osEnd, osElapsed)
+		verbosef("OS seconds: %.4f\n", float64(osElapsed)/float64(osFrequency))
 
-		fmt.Printf(" CPU timer: %v -> %v = %v\n", cpuStart, cpuEnd, cpuElapsed)
-		fmt.Printf("  CPU freq: %v (estimated)\n", cpuFrequency)
+		verbosef(" CPU timer: %v -> %v = %v\n", cpuStart, cpuEnd, cpuElapsed)
+		verbosef("  CPU freq: %v (estimated)\n", cpuFrequency)
+		verbosef("Timer read: %v (CPU timer units)\n", ReadTimerOverhead())
 	}
 
 	return cpuFrequency
 }
 
+/*
+NewProfiler returns a fresh, empty Profiler. The name is printed as a header
+by Output, making reports of several profilers distinguishable.
+*/
+func NewProfiler(name string) *Profiler {
+	p := &Profiler{name: name, precision: defaultPrecision, resolutionMultiple: defaultResolutionMultiple}
+	p.Reset()
+	return p
+}
+
+// New returns a fresh, empty and unnamed Profiler, e.g. for a single request
+// handler, as NewProfiler("") does.
+func New() *Profiler {
+	return NewProfiler("")
+}
+
+// Name returns the name given to the profiler.
+func (p *Profiler) Name() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.name
+}
+
+// SetName changes the name printed as a header by Output.
+func (p *Profiler) SetName(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.name = name
+}
+
+var calibrationMutex sync.Mutex
+
+/*
+Calibrate estimates the CPU timer frequency, busy-waiting for 50ms, and
+returns it. Without cgo or off x86 the frequency is known instead, that of the
+performance counter on Windows or of the monotonic clock elsewhere. The
+estimation only happens once, the first Start otherwise paying it: call
+Calibrate during a warm-up phase to control when, enable
+SetBackgroundCalibration, or supply the frequency with SetCPUFrequency or
+TIMER_CPU_FREQ_ENV_VAR. It is safe to call concurrently.
+*/
+func Calibrate() int64 {
+	if frequency := atomic.LoadInt64(&cpuFrequency); frequency != 0 {
+		return frequency
+	}
+
+	calibrationMutex.Lock()
+	defer calibrationMutex.Unlock()
+
+	if atomic.LoadInt64(&cpuFrequency) == 0 {
+		if frequency, supplied := frequencyFromEnv(); supplied {
+			supplyCPUFrequency(frequency)
+		} else if atomic.LoadInt32(&backgroundCalibration) != 0 {
+			calibrateInBackground()
+		} else {
+			atomic.StoreInt64(&cpuFrequency, estimateCPUTimerFreq())
+		}
+	}
+
+	return atomic.LoadInt64(&cpuFrequency)
+}
+
+// GetCPUFrequency returns the CPU timer frequency in Hz used to convert the
+// timer units, zero until calibrated.
+func GetCPUFrequency() int64 {
+	return atomic.LoadInt64(&cpuFrequency)
+}
+
+/*
+InvalidateCalibration clears the estimated CPU timer frequency, so that the
+next Start or Calibrate estimates it again, e.g. after a suspend or a change of
+the CPU frequency policy. Recorded CPU timer units are kept, the elapsed time
+of an anchor being converted with the new frequency on its next hit.
+*/
+func InvalidateCalibration() {
+	calibrationMutex.Lock()
+	defer calibrationMutex.Unlock()
+
+	atomic.StoreInt64(&cpuFrequency, 0)
+	atomic.StoreInt32(&provisionalCalibration, 0)
+	atomic.StoreInt32(&suppliedCalibration, 0)
+	calibrationGeneration = calibrationGeneration + 1
+}
+
 // NOTE: Do we need an init function?
 // Reset fullfills a similar role, might simply rename it?
-func Reset() {
-	index = 0
-	anchors = make([]*anchor, maxHandledAnchors)
-	anchorsByName = make(map[string]*anchor, maxHandledAnchors)
 
-	totalTiming = &timing{}
-	currentAnchor = nil
-	currentTiming = nil
+/*
+Reset discards every anchor and starts a fresh profile. Anchors still open are
+dropped with a warning; their pending Stop calls are then ignored, unless the
+anchor was started again since.
+*/
+func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopSampling()
+	p.orphanOpenAnchors("resetting")
+	p.session = newSession()
+	p.allocateTimings()
+}
+
+// register returns the named anchor, creating it as a child of the current
+// anchor when first seen.
+func (p *Profiler) register(anchorName string) (*anchor, error) {
+	var registered, exists = p.anchorsByName[anchorName]
+	if exists {
+		return registered, nil
+	}
+
+	if p.index+1 >= p.anchorLimit() {
+		if !p.limitReached {
+			warn("timer: %d anchors reached, new anchors are no longer recorded", p.index)
+		}
+
+		p.limitReached = true
+		return nil, ErrTooManyAnchors
+	}
 
-	totalAnchor = &anchor{
-		name: TOTAL_ANCHOR_NAME,
+	if p.index+1 >= len(p.anchors) {
+		p.anchors = append(p.anchors, make([]*anchor, len(p.anchors))...)
 	}
+
+	// Copy the name, so that a substring of a large caller string doesn't
+	// keep it alive for the whole profile
+	anchorName = string([]byte(anchorName))
+
+	registered = &anchor{
+		name:   anchorName,
+		active: true,
+	}
+
+	p.anchorsByName[anchorName] = registered
+	p.index = p.index + 1
+	p.anchors[p.index] = registered
+
+	if p.currentAnchor != nil {
+		registered.depth = p.currentAnchor.depth + 1
+	}
+
+	registered.parent = p.currentAnchor
+
+	if p.onRegister != nil {
+		p.onRegister(anchorName)
+	}
+
+	return registered, nil
 }
 
 /*
@@ -119,162 +576,423 @@ Start begins recording time for the specified anchor name.
 Stop MUST be called with the same anchor name at some point. Deferring the Stop
 call might be a good idea to time a complete block.
 
-Profiler can be disabled by setting TIMER env variable to "0".
+An anchor may be started again before its Stop, e.g. by a recursive function:
+every call counts a hit, the enclosing call is paused while the inner one runs
+and is resumed by its Stop, so that the time is counted once rather than once
+per recursion level.
+
+Profiler can be disabled by setting TIMER env variable to "0" (or "false",
+"off", "no") before the program starts, or by calling Disable. Setting it to
+"verbose" prints the calibration diagnostics.
 */
-func Start(anchorName string) {
-	StartThroughput(anchorName, 0)
+func (p *Profiler) Start(anchorName string) {
+	warnError(p.StartThroughputE(anchorName, 0))
 }
 
-func StartThroughput(anchorName string, processedBytes int64) {
-	if os.Getenv(TIMER_ENV_VAR) == "0" {
-		return
-	}
+// StartThroughput is Start also counting processedBytes for the hit. Output
+// reports the mean of the hit throughputs, each hit's bytes over its own time.
+func (p *Profiler) StartThroughput(anchorName string, processedBytes int64) {
+	warnError(p.StartThroughputE(anchorName, processedBytes))
+}
 
-	if cpuFrequency == 0 {
-		cpuFrequency = getCPUTimerFreq(50)
-	}
+/*
+StartE is Start reporting misuses: it returns an *AnchorError wrapping
+ErrTooManyAnchors when the anchor could not be registered, or
+ErrParentMismatch under SetStrictNames.
+*/
+func (p *Profiler) StartE(anchorName string) error {
+	return p.StartThroughputE(anchorName, 0)
+}
 
-	if len(anchorName) > anchorNameMaxLength {
-		anchorName = anchorName[:anchorNameMaxLength]
+// StartThroughputE is StartThroughput reporting misuses, see StartE.
+func (p *Profiler) StartThroughputE(anchorName string, processedBytes int64) error {
+	if profilingDisabled() {
+		return nil
 	}
 
-	var startingAnchor *anchor
-	var exists bool
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	startingAnchor, exists = anchorsByName[anchorName]
-	if !exists {
-		startingAnchor = &anchor{
-			name:   anchorName,
-			active: true,
-		}
+	return p.start(anchorName, processedBytes)
+}
+
+// start is StartThroughputE with the lock held.
+func (p *Profiler) start(anchorName string, processedBytes int64) error {
+	// Calibrate before any clock reading: the total only starts with the
+	// reading below, so the calibration busy-wait is never part of it
+	Calibrate()
 
-		anchorsByName[anchorName] = startingAnchor
-		index = index + 1
-		anchors[index] = startingAnchor
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return &AnchorError{Op: "start", Anchor: anchorName, Err: err}
+	}
 
-		if currentAnchor != nil {
-			startingAnchor.depth = currentAnchor.depth + 1
+	if p.disabledAnchors[key] {
+		if disabled, exists := p.anchorsByName[key]; exists {
+			disabled.skipped = disabled.skipped + 1
 		}
+		return nil
+	}
 
-		startingAnchor.parent = currentAnchor
+	startingAnchor, err := p.register(key)
+	if err != nil {
+		return &AnchorError{Op: "start", Anchor: anchorName, Err: err}
 	}
 
+	if p.beyondMaxNesting(startingAnchor) {
+		return nil
+	}
+
+	var mismatch = p.parentMismatch(startingAnchor)
+
 	// NOTE: Need to keep track of the previous anchor as well?
 	startingAnchor.hits = startingAnchor.hits + 1
-	startingAnchor.bytes = startingAnchor.bytes + processedBytes
-	currentAnchor = startingAnchor
+	startingAnchor.open = startingAnchor.open + 1
+	if startingAnchor.open > startingAnchor.maxRecursion {
+		startingAnchor.maxRecursion = startingAnchor.open
+	}
+	var warmup = startingAnchor.warmedUp < p.warmups[key]
+	if warmup {
+		startingAnchor.warmedUp = startingAnchor.warmedUp + 1
+	} else {
+		startingAnchor.bytes = startingAnchor.bytes + processedBytes
+	}
+	p.currentAnchor = startingAnchor
+
+	if p.gcEnabled && p.totalTiming.start == 0 {
+		p.gcStart = readGCStats()
+	}
+
+	if p.goroutineStats {
+		startingAnchor.countGoroutine(currentGoroutineID())
+	}
+
+	if p.currentPhase != nil && !warmup {
+		var counters = p.currentPhase.countersOf(startingAnchor)
+		counters.hits = counters.hits + 1
+	}
+
+	var allocsStart allocCounters
+	if p.allocStats {
+		allocsStart = readAllocCounters()
+	}
 
 	// Clock reading, limit operations as much as possible from now on
 	var current = readCPUTimer()
 
-	var startingTiming *timing
-	// TODO: Create a large pool of objects and reuse them instead of creating
-	// and discarding them regularly? Interesting thing to look at
-	startingTiming = &timing{
-		start:    current,
-		previous: currentTiming,
-		anchor:   startingAnchor,
+	var startingTiming = p.newTiming()
+	startingTiming.start = current
+	startingTiming.inclusiveStart = current
+	startingTiming.allocsStart = allocsStart
+	startingTiming.previous = p.currentTiming
+	startingTiming.anchor = startingAnchor
+	startingTiming.warmup = warmup
+	startingTiming.bytes = processedBytes
+
+	if startingAnchor.open > 1 {
+		startingTiming.outer = startingAnchor.latest
+		startingTiming.outer.reentered = true
+	}
+
+	if p.wallThroughput || p.wallTime {
+		startingTiming.wallStart = readOSTimer()
 	}
 
 	startingAnchor.latest = startingTiming
+	p.openDepth = p.openDepth + 1
+	if p.openDepth > p.maxDepth {
+		p.maxDepth = p.openDepth
+	}
+	if startingAnchor.firstHit == 0 {
+		startingAnchor.firstHit = current
+	}
+
+	if p.totalTiming.start == 0 {
+		p.totalTiming.start = current
+		p.totalTiming.anchor = p.totalAnchor
+		p.totalAnchor.latest = p.totalTiming
+	}
+
+	if p.currentTiming != nil && p.currentTiming.blockStart != 0 {
+		p.endBlock(p.currentTiming, current)
+	}
 
-	if totalTiming.start == 0 {
-		totalTiming.start = current
-		totalTiming.anchor = totalAnchor
-		totalAnchor.latest = totalTiming
+	if p.currentTiming != nil && p.accounting == AccountingExclusive {
+		p.currentTiming.anchor.active = false
+		if !p.currentTiming.warmup {
+			var running = p.repaySampled(p.currentTiming, current-p.currentTiming.start)
+			p.accumulate(p.currentTiming.anchor, running, current)
+			p.currentTiming.own = p.currentTiming.own + nonNegative(running)
+		}
 	}
 
-	if currentTiming != nil {
-		currentTiming.anchor.active = false
-		currentTiming.anchor.tscount = currentTiming.anchor.tscount + current - currentTiming.start
+	p.currentTiming = startingTiming
+
+	if mismatch {
+		return &AnchorError{Op: "start", Anchor: anchorName, Err: ErrParentMismatch}
 	}
 
-	currentTiming = startingTiming
+	return nil
 }
 
 /*
-Stop ends the recording for the specified anchor name.
+Stop ends the recording for the specified anchor name. Stopping an anchor that
+is not open is ignored with a warning, use StopE to detect it: a redundant
+Stop, e.g. a deferred Stop following an explicit one, leaves the profile
+untouched, the open anchors and the timings of later calls included.
 */
-func Stop(anchorName string) {
-	if os.Getenv(TIMER_ENV_VAR) == "0" {
-		return
+func (p *Profiler) Stop(anchorName string) {
+	warnError(p.StopE(anchorName))
+}
+
+/*
+StopE is Stop reporting misuses: it returns an *AnchorError wrapping
+ErrUnknownAnchor or ErrStackUnderflow, leaving the profile untouched but for
+the count of such calls shown by Output and kept in Report.UnmatchedStops.
+*/
+func (p *Profiler) StopE(anchorName string) error {
+	if profilingDisabled() {
+		return nil
 	}
 
 	var end = readCPUTimer()
 
-	if len(anchorName) > anchorNameMaxLength {
-		anchorName = anchorName[:anchorNameMaxLength]
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.countUnmatched(p.stop(anchorName, end))
+}
+
+// countUnmatched counts the Stop calls failing with err for lack of a matching
+// Start, and returns err.
+func (p *Profiler) countUnmatched(err error) error {
+	if errors.Is(err, ErrUnknownAnchor) || errors.Is(err, ErrStackUnderflow) {
+		p.unmatchedStops = p.unmatchedStops + 1
 	}
 
-	var anchor = anchorsByName[anchorName]
+	return err
+}
+
+// stop is StopE with the lock held, end being the CPU timer reading closing
+// the anchor.
+func (p *Profiler) stop(anchorName string, end int64) error {
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return &AnchorError{Op: "stop", Anchor: anchorName, Err: err}
+	}
 
-	// Note: Anchor is about hierarchy
-	// Note: Timing is about recursion
+	return p.stopKey(anchorName, key, end)
+}
 
-	var previousTiming *timing = anchor.latest.previous
-	if previousTiming != nil {
-		previousTiming.start = end
-		previousTiming.anchor.active = true
+// stopKey is stop for the anchor already resolved to key by anchorKey.
+func (p *Profiler) stopKey(anchorName string, key string, end int64) error {
+	var wallEnd int64
+	if p.wallThroughput || p.wallTime {
+		wallEnd = readOSTimer()
 	}
 
-	if anchor.parent != nil {
-		anchor.parent.latest.start = end
-		anchor.parent.active = true
+	var allocsEnd allocCounters
+	if p.allocStats {
+		allocsEnd = readAllocCounters()
 	}
 
-	currentAnchor = anchor.parent
-	currentTiming = previousTiming
+	var anchor, exists = p.anchorsByName[key]
+	if !exists && p.disabledAnchors[key] {
+		return nil
+	}
 
-	anchor.tscount = anchor.tscount + end - anchor.latest.start
-	anchor.elapsed = float64(anchor.tscount) / float64(cpuFrequency/1000)
+	if (!exists || anchor.open == 0) && p.orphans[key] > 0 {
+		// Started before a Reset
+		p.orphans[key] = p.orphans[key] - 1
+		return nil
+	}
 
-	totalAnchor.tscount = end - totalTiming.start
-	totalAnchor.elapsed = float64(totalAnchor.tscount) / float64(cpuFrequency/1000)
-}
+	if exists && anchor.skipped > 0 {
+		anchor.skipped = anchor.skipped - 1
+		return nil
+	}
 
-/*
-Output displays computed information for the current timer execution, to the
-standard output.
-*/
-func Output() {
-	if os.Getenv(TIMER_ENV_VAR) == "0" {
-		return
+	if !exists {
+		if p.limitReached {
+			// Probably dropped by the Start
+			return &AnchorError{Op: "stop", Anchor: anchorName, Err: ErrTooManyAnchors}
+		}
+
+		return &AnchorError{Op: "stop", Anchor: anchorName, Err: ErrUnknownAnchor}
 	}
 
-	// NOTE: Should the output be generated here?
-	// Seems weird. It's handy, but maybe timer shouldn't print
-	// directly and should return data to the calling code
-	// Maybe code to be put in a test/an example
+	if anchor.open == 0 {
+		return &AnchorError{Op: "stop", Anchor: anchorName, Err: ErrStackUnderflow}
+	}
 
-	fmt.Println()
+	anchor.open = anchor.open - 1
+	p.openDepth = p.openDepth - 1
 
-	var padding = anchorNameMaxLength
-	fmt.Printf("%*s: %10.3fms (CPU freq: %d)\n", padding, totalAnchor.name,
-		totalAnchor.elapsed, cpuFrequency)
+	// Note: Anchor is about hierarchy
+	// Note: Timing is about recursion
+
+	var closing = anchor.latest
+	if closing.blockStart != 0 {
+		p.endBlock(closing, end)
+	}
+
+	// Inclusive wall time of a recursive anchor is that of its outermost call
+	if wallEnd != 0 && closing.wallStart != 0 && closing.outer == nil && !closing.warmup {
+		anchor.wall = anchor.wall + wallEnd - closing.wallStart
+	}
+
+	if allocsEnd.mallocs != 0 && closing.allocsStart.mallocs != 0 && closing.outer == nil && !closing.warmup {
+		anchor.allocs = anchor.allocs + int64(allocsEnd.mallocs-closing.allocsStart.mallocs)
+		anchor.allocBytes = anchor.allocBytes + int64(allocsEnd.allocBytes-closing.allocsStart.allocBytes)
+	}
 
-	for index, anchor := range anchors {
-		if index == 0 {
-			// Skip the first timing section for now
-			continue
+	// Resume whichever timing was paused by the matching Start, which is the
+	// same anchor for a recursive call and the parent anchor otherwise
+	var previousTiming *timing = closing.previous
+	var innermost = closing == p.currentTiming
+	if innermost {
+		p.currentAnchor = nil
+		if previousTiming != nil {
+			if p.accounting == AccountingExclusive {
+				previousTiming.start = end
+			}
+			previousTiming.anchor.active = true
+			p.currentAnchor = previousTiming.anchor
 		}
 
-		if anchor == nil {
-			break
+		p.currentTiming = previousTiming
+	} else {
+		// Stopped before the anchors started after it, e.g. by interleaved
+		// goroutines: those keep running, and the time of this one was
+		// already counted when the next one paused it
+		p.unlinkTiming(closing)
+	}
+
+	if p.accounting == AccountingInclusive {
+		p.settleInclusive(closing, end)
+	} else if !closing.warmup {
+		var running int64
+		if innermost {
+			running = p.repaySampled(closing, end-closing.start)
+		}
+		p.accumulate(anchor, running, end)
+		var hit = p.compensate(anchor, closing.own+nonNegative(running))
+		p.scaleSampled(anchor, closing, hit)
+		anchor.variation.add(float64(hit))
+		if !closing.reentered {
+			// A re-entered hit would look shorter than any actual call
+			p.addHit(anchor, hit)
+			anchor.payload.addRate(closing.bytes, p.milliseconds(hit)/1000)
 		}
+	}
+	if !closing.warmup {
+		anchor.payload.add(closing.bytes)
+	}
+	anchor.latest = closing.outer
 
-		var percent = 100 * float64(anchor.tscount) / float64(totalAnchor.tscount)
-		var padding = anchorNameMaxLength + 2*anchor.depth
+	if p.onStop != nil && !closing.warmup {
+		p.onStop(anchor.name, ticksToDuration(nonNegative(end-closing.inclusiveStart)))
+	}
 
-		if anchor.bytes == 0 {
-			fmt.Printf("%*s: %10.3fms (%5.2f%%) -- calls: %d\n", padding, anchor.name,
-				anchor.elapsed, percent, anchor.hits)
-		} else {
-			var megabytes = float64(anchor.bytes) / (1024 * 1024)
-			var gigabytes = float64(anchor.bytes) / (1024 * 1024 * 1024)
-			var throughput = gigabytes / (float64(anchor.tscount) / float64(cpuFrequency))
+	// Nothing refers to the timing anymore, it was unlinked otherwise
+	p.releaseTiming(closing)
+
+	p.totalAnchor.tscount = end - p.totalTiming.start
+	if p.totalAnchor.tscount < 0 {
+		p.clockAnomalies = p.clockAnomalies + 1
+		p.totalAnchor.tscount = 0
+	}
+	p.totalAnchor.elapsed = ticksToMilliseconds(p.totalAnchor.tscount)
 
-			fmt.Printf("%*s: %10.3fms (%5.2f%%) -- calls: %d, %7.2fMB at %5.3fGB/s\n",
-				padding, anchor.name, anchor.elapsed, percent, anchor.hits, megabytes, throughput)
+	return nil
+}
+
+// unlinkTiming removes a timing that is not the innermost one from the chain of
+// open timings.
+func (p *Profiler) unlinkTiming(removed *timing) {
+	for current := p.currentTiming; current != nil; current = current.previous {
+		if current.previous == removed {
+			current.previous = removed.previous
+			return
 		}
 	}
 }
+
+/*
+SetWallThroughput selects the time base of the throughput column of Output.
+
+By default throughput is computed over CPU time, the CPU timer units spent in
+the anchor itself excluding its children, which suits CPU-bound stages. When
+enabled, it is computed over wall time read from the OS timer between Start
+and Stop, including children and blocked periods, which suits I/O-bound
+stages. Wall time is only recorded while enabled, at the cost of an extra OS
+timer read in Start and Stop.
+*/
+func (p *Profiler) SetWallThroughput(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.wallThroughput = enabled
+}
+
+// SetName changes the name of the default profiler, empty unless set.
+func SetName(name string) {
+	defaultProfiler.SetName(name)
+}
+
+// Reset clears every anchor recorded by the default profiler.
+func Reset() {
+	defaultProfiler.Reset()
+}
+
+/*
+Start begins recording time for the specified anchor name on the default
+profiler. See Profiler.Start.
+*/
+func Start(anchorName string) {
+	defaultProfiler.Start(anchorName)
+}
+
+func StartThroughput(anchorName string, processedBytes int64) {
+	defaultProfiler.StartThroughput(anchorName, processedBytes)
+}
+
+// StartE is Start on the default profiler, reporting misuses.
+func StartE(anchorName string) error {
+	return defaultProfiler.StartE(anchorName)
+}
+
+// StartThroughputE is StartThroughput on the default profiler, reporting
+// misuses.
+func StartThroughputE(anchorName string, processedBytes int64) error {
+	return defaultProfiler.StartThroughputE(anchorName, processedBytes)
+}
+
+/*
+Stop ends the recording for the specified anchor name on the default profiler.
+*/
+func Stop(anchorName string) {
+	defaultProfiler.Stop(anchorName)
+}
+
+// StopE is Stop on the default profiler, reporting misuses.
+func StopE(anchorName string) error {
+	return defaultProfiler.StopE(anchorName)
+}
+
+// SetWallThroughput selects the throughput time base of the default profiler.
+func SetWallThroughput(enabled bool) {
+	defaultProfiler.SetWallThroughput(enabled)
+}
+
+/*
+Output displays the report of the default profiler to the standard output.
+*/
+func Output() {
+	defaultProfiler.Output()
+}
+
+// OutputTo writes the report of the default profiler to w.
+func OutputTo(w io.Writer) {
+	defaultProfiler.OutputTo(w)
+}
@@ -0,0 +1,634 @@
+package timer
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Frequency of the fake CPU timer, one unit per nanosecond
+const testFrequency = 1000000000
+
+// fakeClock is a CPU timer only advancing when told to.
+type fakeClock struct {
+	now int64
+}
+
+func (c *fakeClock) read() int64 {
+	return atomic.LoadInt64(&c.now)
+}
+
+func (c *fakeClock) advance(ticks int64) {
+	atomic.AddInt64(&c.now, ticks)
+}
+
+func (c *fakeClock) set(ticks int64) {
+	atomic.StoreInt64(&c.now, ticks)
+}
+
+// useFakeClock replaces the CPU timer with a fake one of frequency
+// testFrequency until the end of the test, calibrating again on the next
+// Start.
+func useFakeClock(tb testing.TB) *fakeClock {
+	tb.Helper()
+
+	// Zero stands for a timing not started yet
+	var clock = &fakeClock{now: 1000}
+
+	var savedClock, savedFreq = clockFn, freqFn
+	clockFn = clock.read
+	freqFn = func() int64 { return testFrequency }
+	InvalidateCalibration()
+
+	tb.Cleanup(func() {
+		clockFn, freqFn = savedClock, savedFreq
+		InvalidateCalibration()
+	})
+
+	return clock
+}
+
+// warnings collects the warnings emitted during a test.
+type warnings struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (w *warnings) add(msg string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.messages = append(w.messages, msg)
+}
+
+func (w *warnings) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return strings.Join(w.messages, "\n")
+}
+
+// captureWarnings routes the warnings to the returned collector until the end
+// of the test.
+func captureWarnings(tb testing.TB) *warnings {
+	tb.Helper()
+
+	var captured = &warnings{}
+	SetWarningHandler(captured.add)
+	tb.Cleanup(func() { SetWarningHandler(nil) })
+
+	return captured
+}
+
+// resultOf returns the result of the named anchor in the report.
+func resultOf(tb testing.TB, report Report, name string) AnchorResult {
+	tb.Helper()
+
+	for _, result := range report.Anchors {
+		if result.Name == name {
+			return result
+		}
+	}
+
+	tb.Fatalf("no anchor %q in the report", name)
+	return AnchorResult{}
+}
+
+// output returns the text report of the profiler.
+func output(p *Profiler) string {
+	var buffer bytes.Buffer
+	p.OutputTo(&buffer)
+	return buffer.String()
+}
+
+func TestRecursiveAnchorCollapsed(t *testing.T) {
+	var tests = []struct {
+		name  string
+		depth int64
+	}{
+		{"not recursive", 1},
+		{"recursive", 3},
+		{"deeply recursive", 50},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+
+			var recurse func(level int64)
+			recurse = func(level int64) {
+				p.Start("recurse")
+				clock.advance(100)
+				if level < test.depth {
+					recurse(level + 1)
+				}
+				p.Stop("recurse")
+			}
+			recurse(1)
+
+			var report = p.Snapshot()
+			if len(report.Anchors) != 1 {
+				t.Fatalf("got %d anchors, want a single one", len(report.Anchors))
+			}
+
+			var result = resultOf(t, report, "recurse")
+			if result.Hits != test.depth {
+				t.Errorf("hits = %d, want %d", result.Hits, test.depth)
+			}
+			if result.MaxRecursion != test.depth {
+				t.Errorf("max recursion = %d, want %d", result.MaxRecursion, test.depth)
+			}
+			if result.TSCount != 100*test.depth {
+				t.Errorf("tscount = %d, want %d", result.TSCount, 100*test.depth)
+			}
+
+			var text = output(p)
+			if lines := strings.Count(text, " recurse:"); lines != 1 {
+				t.Errorf("recursive anchor on %d lines:\n%s", lines, text)
+			}
+			if test.depth > 1 && !strings.Contains(text, "recursion: "+strconv.FormatInt(test.depth, 10)) {
+				t.Errorf("recursion depth missing from the report:\n%s", text)
+			}
+		})
+	}
+}
+
+func TestTotalExcludesCalibration(t *testing.T) {
+	var tests = []struct {
+		name        string
+		calibration int64
+	}{
+		{"instant", 0},
+		{"busy-wait", 50 * testFrequency / 1000},
+		{"slow", testFrequency},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			freqFn = func() int64 {
+				// The estimation busy-waits on the timer it measures
+				clock.advance(test.calibration)
+				return testFrequency
+			}
+
+			var p = New()
+			p.Start("work")
+			clock.advance(250)
+			p.Stop("work")
+
+			var report = p.Snapshot()
+			if report.Total.TSCount != 250 {
+				t.Errorf("total tscount = %d, want 250", report.Total.TSCount)
+			}
+			if result := resultOf(t, report, "work"); result.TSCount != 250 {
+				t.Errorf("work tscount = %d, want 250", result.TSCount)
+			}
+		})
+	}
+}
+
+func TestBackwardsClockClamped(t *testing.T) {
+	var tests = []struct {
+		name string
+		run  func(p *Profiler, clock *fakeClock)
+	}{
+		{"stop before start", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(-500)
+			p.Stop("a")
+		}},
+		{"child before parent", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(-500)
+			p.Start("b")
+			clock.advance(100)
+			p.Stop("b")
+			p.Stop("a")
+		}},
+		{"counter reset", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(100)
+			p.Stop("a")
+			clock.set(1)
+			p.Start("a")
+			p.Stop("a")
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			test.run(p, clock)
+
+			var report = p.Snapshot()
+			if report.ClockAnomalies == 0 {
+				t.Error("no clock anomaly counted")
+			}
+			for _, result := range append(report.Anchors, report.Total) {
+				if result.TSCount < 0 || result.Elapsed < 0 {
+					t.Errorf("%s: negative tscount %d, elapsed %v", result.Name, result.TSCount, result.Elapsed)
+				}
+			}
+
+			if text := output(p); !strings.Contains(text, "negative durations clamped to zero") {
+				t.Errorf("clock anomalies missing from the report:\n%s", text)
+			}
+		})
+	}
+}
+
+// readings returns a clock returning the values in turn, then the last one.
+func readings(values ...int64) func() int64 {
+	return func() int64 {
+		var value = values[0]
+		if len(values) > 1 {
+			values = values[1:]
+		}
+		return value
+	}
+}
+
+func TestMeasureCPUTimerFreq(t *testing.T) {
+	var tests = []struct {
+		name         string
+		cpu          []int64
+		os           []int64
+		osFrequency  int64
+		milliseconds int64
+		want         int64
+	}{
+		{"faster CPU timer", []int64{100, 100 + 36000000}, []int64{0, 4000000, 8000000, 12000000},
+			1000000000, 10, 3000000000},
+		{"slower CPU timer", []int64{0, 2500000}, []int64{0, 10000000}, 1000000000, 10, 250000000},
+		{"coarse OS timer", []int64{0, 120000000}, []int64{7, 30, 57}, 1000, 50, 2400000000},
+		{"beyond int64 product", []int64{0, 40000000000}, []int64{0, 10000000000}, 1000000000, 10000,
+			4000000000},
+		{"nothing to wait for", []int64{0, 1000}, []int64{0}, 1000000000, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got = measureCPUTimerFreq(readings(test.cpu...), readings(test.os...), test.osFrequency,
+				test.milliseconds)
+			if got != test.want {
+				t.Errorf("frequency = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDoubleStopIgnored(t *testing.T) {
+	type want struct {
+		tscount int64
+		parent  string
+	}
+
+	var tests = []struct {
+		name string
+		run  func(p *Profiler, clock *fakeClock)
+		want map[string]want
+	}{
+		{"top-level anchor", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(10)
+			p.Stop("a")
+			p.Stop("a")
+			p.Start("b")
+			clock.advance(20)
+			p.Stop("b")
+		}, map[string]want{"a": {10, ""}, "b": {20, ""}}},
+		{"child anchor", func(p *Profiler, clock *fakeClock) {
+			p.Start("parent")
+			p.Start("a")
+			clock.advance(10)
+			p.Stop("a")
+			p.Stop("a")
+			p.Start("b")
+			clock.advance(20)
+			p.Stop("b")
+			clock.advance(5)
+			p.Stop("parent")
+		}, map[string]want{"parent": {5, ""}, "a": {10, "parent"}, "b": {20, "parent"}}},
+		{"scope and explicit stop", func(p *Profiler, clock *fakeClock) {
+			var stop = p.Scope("a")
+			clock.advance(10)
+			p.Stop("a")
+			stop()
+			p.Start("b")
+			clock.advance(20)
+			p.Stop("b")
+		}, map[string]want{"a": {10, ""}, "b": {20, ""}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var captured = captureWarnings(t)
+			var p = New()
+			test.run(p, clock)
+
+			var report = p.Snapshot()
+			for name, want := range test.want {
+				var result = resultOf(t, report, name)
+				if result.TSCount != want.tscount {
+					t.Errorf("%s: tscount = %d, want %d", name, result.TSCount, want.tscount)
+				}
+				if result.ParentName != want.parent {
+					t.Errorf("%s: parent = %q, want %q", name, result.ParentName, want.parent)
+				}
+				if result.Open {
+					t.Errorf("%s: still open", name)
+				}
+			}
+			if report.UnmatchedStops != 1 {
+				t.Errorf("%d unmatched stops, want 1", report.UnmatchedStops)
+			}
+			if !strings.Contains(captured.String(), `"a"`) {
+				t.Errorf("no warning about the redundant Stop: %q", captured.String())
+			}
+			if err := p.StopE("a"); !errors.Is(err, ErrStackUnderflow) {
+				t.Errorf("StopE = %v, want ErrStackUnderflow", err)
+			}
+		})
+	}
+}
+
+func TestConcurrentDistinctAnchors(t *testing.T) {
+	var tests = []struct {
+		name string
+		// Profiler of each goroutine, sharing one when nil
+		profiler func() *Profiler
+	}{
+		{"shared profiler", nil},
+		{"profiler per goroutine", New},
+	}
+
+	const goroutines = 50
+	const hits = 200
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var shared = New()
+
+			var profilers = make([]*Profiler, goroutines)
+			var started sync.WaitGroup
+			for g := range profilers {
+				profilers[g] = shared
+				if test.profiler != nil {
+					profilers[g] = test.profiler()
+				}
+
+				started.Add(1)
+				go func(p *Profiler, name string) {
+					defer started.Done()
+					for i := 0; i < hits; i++ {
+						p.Start(name)
+						clock.advance(1)
+						p.Stop(name)
+					}
+				}(profilers[g], "worker"+strconv.Itoa(g))
+			}
+			started.Wait()
+
+			for g, p := range profilers {
+				var report = p.Snapshot()
+				var result = resultOf(t, report, "worker"+strconv.Itoa(g))
+				if result.Hits != hits || result.Open {
+					t.Errorf("%s: %d hits, open %v, want %d stopped hits", result.Name, result.Hits,
+						result.Open, hits)
+				}
+				if report.UnmatchedStops != 0 {
+					t.Errorf("%d unmatched stops", report.UnmatchedStops)
+				}
+				if err := p.Validate(); err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestManyAnchors(t *testing.T) {
+	var tests = []struct {
+		name       string
+		maxAnchors int
+		want       int
+	}{
+		{"growing slots", 0, 1500},
+		{"limited", 1000, 1000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			p.SetMaxAnchors(test.maxAnchors)
+
+			for i := 0; i < 1500; i++ {
+				p.Start("anchor" + strconv.Itoa(i))
+				clock.advance(1)
+				p.Stop("anchor" + strconv.Itoa(i))
+			}
+			// Anchors registered before the limit keep being timed
+			p.Start("anchor0")
+			clock.advance(1)
+			p.Stop("anchor0")
+
+			var report = p.Snapshot()
+			if len(report.Anchors) != test.want {
+				t.Errorf("%d anchors recorded, want %d", len(report.Anchors), test.want)
+			}
+			if report.LimitReached != (test.want < 1500) {
+				t.Errorf("limit reached = %v", report.LimitReached)
+			}
+			if result := resultOf(t, report, "anchor0"); result.Hits != 2 {
+				t.Errorf("anchor0: %d hits, want 2", result.Hits)
+			}
+
+			var last = " anchor" + strconv.Itoa(test.want-1) + ":"
+			if text := output(p); !strings.Contains(text, last) {
+				t.Errorf("report doesn't list%s", last)
+			}
+		})
+	}
+}
+
+func TestStopUnknownAnchor(t *testing.T) {
+	var tests = []struct {
+		name string
+		run  func(p *Profiler, clock *fakeClock)
+		want error
+	}{
+		{"nothing started", func(p *Profiler, clock *fakeClock) {}, ErrUnknownAnchor},
+		{"typo", func(p *Profiler, clock *fakeClock) {
+			p.Start("open")
+			clock.advance(10)
+		}, ErrUnknownAnchor},
+		{"already stopped", func(p *Profiler, clock *fakeClock) {
+			p.Start("typo")
+			p.Stop("typo")
+		}, ErrStackUnderflow},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			test.run(p, clock)
+
+			p.Stop("typo")
+			var err = p.StopE("typo")
+			if !errors.Is(err, test.want) {
+				t.Errorf("StopE = %v, want %v", err, test.want)
+			}
+			var anchorErr *AnchorError
+			if !errors.As(err, &anchorErr) || anchorErr.Anchor != "typo" {
+				t.Errorf("StopE = %v, want an *AnchorError naming the anchor", err)
+			}
+
+			var report = p.Snapshot()
+			if report.UnmatchedStops != 2 {
+				t.Errorf("%d unmatched stops, want 2", report.UnmatchedStops)
+			}
+			for _, result := range report.Anchors {
+				if result.Name == "open" && !result.Open {
+					t.Errorf("the open anchor was closed by the unmatched Stop")
+				}
+			}
+
+			p.Start("after")
+			clock.advance(20)
+			p.Stop("after")
+			if result := resultOf(t, p.Snapshot(), "after"); result.TSCount != 20 {
+				t.Errorf("after: tscount = %d, want 20", result.TSCount)
+			}
+
+			if text := output(p); !strings.Contains(text, "2 Stop calls without a matching Start") {
+				t.Errorf("unmatched stops missing from the report:\n%s", text)
+			}
+		})
+	}
+}
+
+func TestFibonacciRecursion(t *testing.T) {
+	var tests = []struct {
+		name       string
+		n          int
+		accounting AccountingMode
+	}{
+		{"base case", 1, AccountingExclusive},
+		{"fib(5)", 5, AccountingExclusive},
+		{"fib(15)", 15, AccountingExclusive},
+		{"fib(15) inclusive", 15, AccountingInclusive},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			p.SetAccountingMode(test.accounting)
+
+			var calls int64
+			var fib func(n int) int
+			fib = func(n int) int {
+				p.Start("fib")
+				defer p.Stop("fib")
+
+				calls = calls + 1
+				clock.advance(10)
+				if n < 2 {
+					return n
+				}
+				return fib(n-1) + fib(n-2)
+			}
+			fib(test.n)
+
+			var report = p.Snapshot()
+			var result = resultOf(t, report, "fib")
+			if result.Hits != calls {
+				t.Errorf("hits = %d, want %d calls", result.Hits, calls)
+			}
+			// Each tick counted once, whatever the recursion depth
+			if result.TSCount != 10*calls || report.Total.TSCount != 10*calls {
+				t.Errorf("tscount = %d, total %d, want %d", result.TSCount, report.Total.TSCount, 10*calls)
+			}
+			if result.MaxRecursion != int64(test.n) {
+				t.Errorf("max recursion = %d, want %d", result.MaxRecursion, test.n)
+			}
+		})
+	}
+}
+
+func TestNamedProfilers(t *testing.T) {
+	var clock = useFakeClock(t)
+	captureWarnings(t)
+
+	var parse, render = NewProfiler("parse"), New()
+	if parse.Name() != "parse" || render.Name() != "" {
+		t.Errorf("names %q and %q, want %q and none", parse.Name(), render.Name(), "parse")
+	}
+
+	parse.Start("a")
+	clock.advance(100)
+	parse.Stop("a")
+	render.SetName("render")
+
+	if text := output(parse); !strings.Contains(text, "profile: parse\n") {
+		t.Errorf("report doesn't name the profiler:\n%s", text)
+	}
+	if text := output(New()); strings.Contains(text, "profile:") {
+		t.Errorf("unnamed profiler printed a header:\n%s", text)
+	}
+	if report := render.Snapshot(); report.Name != "render" || len(report.Anchors) != 0 {
+		t.Errorf("report %q with %d anchors, want %q without any", report.Name, len(report.Anchors), "render")
+	}
+	if report := parse.Snapshot(); report.Name != "parse" || resultOf(t, report, "a").Hits != 1 {
+		t.Errorf("report %q, want %q with one hit", report.Name, "parse")
+	}
+}
+
+func TestIndependentProfilers(t *testing.T) {
+	var clock = useFakeClock(t)
+	var first, second = New(), New()
+
+	// Same names, interleaved
+	first.Start("a")
+	clock.advance(100)
+	second.Start("a")
+	clock.advance(50)
+	first.Stop("a")
+	second.Start("b")
+	clock.advance(20)
+	second.Stop("b")
+	second.Stop("a")
+
+	if result := resultOf(t, first.Snapshot(), "a"); result.TSCount != 150 || result.Hits != 1 {
+		t.Errorf("first a: %d hits of %d ticks, want 1 of 150", result.Hits, result.TSCount)
+	}
+	var report = second.Snapshot()
+	if result := resultOf(t, report, "a"); result.TSCount != 50 || result.Hits != 1 {
+		t.Errorf("second a: %d hits of %d ticks, want 1 of 50", result.Hits, result.TSCount)
+	}
+	if result := resultOf(t, report, "b"); result.ParentName != "a" {
+		t.Errorf("second b under %q, want a", result.ParentName)
+	}
+	if count := first.AnchorCount(); count != 1 {
+		t.Errorf("first profiler has %d anchors, want 1", count)
+	}
+
+	second.Reset()
+	if count, hits := first.AnchorCount(), first.TotalHits(); count != 1 || hits != 1 {
+		t.Errorf("resetting the second profiler left the first with %d anchors and %d hits", count, hits)
+	}
+}
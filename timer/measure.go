@@ -0,0 +1,29 @@
+package timer
+
+/*
+Measure times a call of fn under the named anchor. The anchor is stopped even
+if fn panics, the panic then carrying on unchanged: an error path can't leave
+it open.
+*/
+func (p *Profiler) Measure(anchorName string, fn func()) {
+	p.MeasureThroughput(anchorName, 0, fn)
+}
+
+// MeasureThroughput is Measure also adding processedBytes to the anchor, as
+// StartThroughput does.
+func (p *Profiler) MeasureThroughput(anchorName string, processedBytes int64, fn func()) {
+	defer p.ScopeThroughput(anchorName, processedBytes)()
+
+	fn()
+}
+
+// Measure times a call of fn under an anchor of the default profiler.
+func Measure(anchorName string, fn func()) {
+	defaultProfiler.Measure(anchorName, fn)
+}
+
+// MeasureThroughput times a call of fn processing bytes under an anchor of
+// the default profiler.
+func MeasureThroughput(anchorName string, processedBytes int64, fn func()) {
+	defaultProfiler.MeasureThroughput(anchorName, processedBytes, fn)
+}
@@ -0,0 +1,51 @@
+package timer
+
+import "testing"
+
+func TestMeasure(t *testing.T) {
+	var tests = []struct {
+		name   string
+		panics bool
+	}{
+		{"returning", false},
+		{"panicking", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+
+			var recovered interface{}
+			func() {
+				defer func() { recovered = recover() }()
+				p.MeasureThroughput("read", 1024, func() {
+					clock.advance(100)
+					p.Measure("parse", func() {
+						clock.advance(50)
+						if test.panics {
+							panic("parse error")
+						}
+					})
+				})
+			}()
+
+			// Re-raised once stopped
+			if (recovered == "parse error") != test.panics {
+				t.Errorf("recovered %v", recovered)
+			}
+			var report = p.Snapshot()
+			for _, want := range []struct {
+				name    string
+				tscount int64
+				bytes   int64
+			}{{"read", 100, 1024}, {"parse", 50, 0}} {
+				var result = resultOf(t, report, want.name)
+				if result.Open || result.TSCount != want.tscount || result.Bytes != want.bytes {
+					t.Errorf("%s: open %v, %d ticks, %d bytes, want closed, %d and %d", want.name,
+						result.Open, result.TSCount, result.Bytes, want.tscount, want.bytes)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package timer
+
+import "math"
+
+/*
+MergeMode selects how Merge aggregates the per-hit statistics of an anchor
+found in several reports, counters like Hits, TSCount, Bytes and Elapsed
+always being summed.
+*/
+type MergeMode int
+
+const (
+	// MergeWeighted averages the per-hit statistics weighted by the hits of
+	// each report: for n reports, CyclesPerHit = sum(Hits_i * CyclesPerHit_i)
+	// / sum(Hits_i), which equals the summed TSCount over the summed Hits.
+	// This is the average cost of a call across all runs. Default.
+	MergeWeighted MergeMode = iota
+
+	// MergeUnweighted averages the per-hit statistics, CyclesPerHit, Mean,
+	// BytesPerHit and HitThroughput, of the reports in which the anchor was
+	// hit, each run counting the same regardless of its length:
+	// CyclesPerHit = sum(CyclesPerHit_i) / n.
+	MergeUnweighted
+)
+
+/*
+Merge combines reports, e.g. from several processes of a sharded workload.
+Anchors sharing a name are merged, keeping the deepest depth, and anchors of
+only some reports pass through unchanged. Percentages are recomputed from the
+elapsed times, which stay meaningful across different CPU frequencies unlike
+TSCount. See MergeWeighted for the per-hit statistics.
+
+Reports written to files by WriteJSON or WriteBinary are read back with
+ReadReport, and the merged report is displayed with WriteReport.
+*/
+func Merge(reports ...Report) Report {
+	return MergeWith(MergeWeighted, reports...)
+}
+
+// MergeWith is Merge aggregating per-hit statistics according to mode.
+func MergeWith(mode MergeMode, reports ...Report) Report {
+	var merged Report
+	var positions = make(map[string]int)
+	// Number of reports in which each anchor was hit, for unweighted means
+	var counts = make(map[string]int)
+
+	for i, report := range reports {
+		if merged.Name == "" {
+			merged.Name = report.Name
+		}
+
+		if i == 0 {
+			merged.CPUFrequency = report.CPUFrequency
+			merged.Runtime = report.Runtime
+		} else if merged.CPUFrequency != report.CPUFrequency {
+			// No single frequency relates TSCount to Elapsed anymore
+			merged.CPUFrequency = 0
+		}
+
+		if i == 0 {
+			merged.Metadata = report.Metadata
+		} else {
+			merged.Metadata = commonMetadata(merged.Metadata, report.Metadata)
+		}
+
+		if report.Meta.Generated.After(merged.Meta.Generated) {
+			merged.Meta.Generated = report.Meta.Generated
+		}
+
+		if merged.Runtime != report.Runtime {
+			// Reports from different environments
+			merged.Runtime = RuntimeInfo{}
+		}
+
+		merged.Total = mergeResult(mode, merged.Total, report.Total)
+		merged.GCCount = merged.GCCount + report.GCCount
+		merged.Hits = merged.Hits + report.Hits
+		merged.UnmatchedStops = merged.UnmatchedStops + report.UnmatchedStops
+		merged.Bytes = merged.Bytes + report.Bytes
+		merged.Ops = merged.Ops + report.Ops
+		merged.GCPause = merged.GCPause + report.GCPause
+		merged.LimitReached = merged.LimitReached || report.LimitReached
+		if report.MaxDepth > merged.MaxDepth {
+			merged.MaxDepth = report.MaxDepth
+		}
+
+		for _, result := range report.Anchors {
+			if result.Hits > 0 {
+				counts[result.Name] = counts[result.Name] + 1
+			}
+
+			var position, exists = positions[result.Name]
+			if !exists {
+				positions[result.Name] = len(merged.Anchors)
+				merged.Anchors = append(merged.Anchors, result)
+				continue
+			}
+
+			merged.Anchors[position] = mergeResult(mode, merged.Anchors[position], result)
+		}
+	}
+
+	if merged.Total.Elapsed > 0 {
+		merged.Throughput = float64(merged.Bytes) / (merged.Total.Elapsed / 1000)
+	}
+	merged.OpsPerSecond = opsPerSecond(merged.Ops, merged.Total.Elapsed)
+
+	for i, result := range merged.Anchors {
+		if mode == MergeUnweighted && counts[result.Name] > 0 {
+			// Sums accumulated by mergeResult
+			var n = float64(counts[result.Name])
+			result.CyclesPerHit = result.CyclesPerHit / n
+			result.Mean = result.Mean / n
+			result.BytesPerHit = result.BytesPerHit / n
+			result.HitThroughput = result.HitThroughput / n
+		}
+
+		result.Percent = 0
+		if merged.Total.Elapsed != 0 {
+			result.Percent = 100 * result.Elapsed / merged.Total.Elapsed
+		}
+
+		merged.Anchors[i] = result
+	}
+	merged.updateMeta()
+
+	return merged
+}
+
+// mergeResult adds b to a. Under MergeUnweighted, the per-hit statistics are
+// left as the sums of the per report values, to be divided once all are
+// merged.
+func mergeResult(mode MergeMode, a AnchorResult, b AnchorResult) AnchorResult {
+	var merged = a
+	if merged.Name == "" {
+		merged.Name = b.Name
+	}
+
+	if b.Depth > merged.Depth {
+		merged.Depth = b.Depth
+	}
+
+	merged.Hits = a.Hits + b.Hits
+	merged.Counted = a.Counted + b.Counted
+	merged.TSCount = a.TSCount + b.TSCount
+	merged.Bytes = a.Bytes + b.Bytes
+	merged.Ops = a.Ops + b.Ops
+	merged.Elapsed = a.Elapsed + b.Elapsed
+	merged.Allocs = a.Allocs + b.Allocs
+	merged.AllocBytes = a.AllocBytes + b.AllocBytes
+	merged.Blocked = a.Blocked + b.Blocked
+	merged.OverBudget = a.OverBudget + b.OverBudget
+	if b.Budget != 0 {
+		merged.Budget = b.Budget
+	}
+	merged.Open = a.Open || b.Open
+
+	merged.OpsPerSecond = opsPerSecond(merged.Ops, merged.Elapsed)
+
+	merged.Mean = 0
+	if merged.Hits != 0 {
+		merged.Mean = merged.Elapsed / float64(merged.Hits)
+	}
+
+	merged.BytesPerHit = 0
+	if payloadHits := payloadHits(a) + payloadHits(b); payloadHits != 0 {
+		merged.BytesPerHit = float64(merged.Bytes) / payloadHits
+	}
+
+	if a.Hits == 0 || b.Hits != 0 && b.MinBytes < a.MinBytes {
+		merged.MinBytes = b.MinBytes
+	}
+	if b.MaxBytes > a.MaxBytes {
+		merged.MaxBytes = b.MaxBytes
+	}
+
+	if a.Hits == 0 || b.Hits != 0 && b.MinHit < a.MinHit {
+		merged.MinHit = b.MinHit
+	}
+	if b.MaxHit > a.MaxHit {
+		merged.MaxHit = b.MaxHit
+	}
+
+	if a.Hits != 0 && b.Hits != 0 {
+		// Percentiles of distinct runs don't combine
+		merged.P50, merged.P90, merged.P99 = 0, 0, 0
+	} else if a.Hits == 0 {
+		merged.P50, merged.P90, merged.P99 = b.P50, b.P90, b.P99
+	}
+
+	if b.SampleRate > merged.SampleRate {
+		merged.SampleRate = b.SampleRate
+	}
+
+	if b.MaxRecursion > merged.MaxRecursion {
+		merged.MaxRecursion = b.MaxRecursion
+	}
+
+	switch mode {
+	case MergeUnweighted:
+		merged.CyclesPerHit = a.CyclesPerHit + b.CyclesPerHit
+		merged.Mean = a.Mean + b.Mean
+		merged.BytesPerHit = a.BytesPerHit + b.BytesPerHit
+		merged.HitThroughput = a.HitThroughput + b.HitThroughput
+	default:
+		merged.CyclesPerHit = 0
+		merged.HitThroughput = 0
+		if merged.Hits != 0 {
+			merged.CyclesPerHit = (float64(a.Hits)*a.CyclesPerHit + float64(b.Hits)*b.CyclesPerHit) /
+				float64(merged.Hits)
+			merged.HitThroughput = (float64(a.Hits)*a.HitThroughput + float64(b.Hits)*b.HitThroughput) /
+				float64(merged.Hits)
+		}
+	}
+
+	return merged
+}
+
+// payloadHits returns the number of hits BytesPerHit was computed over, which
+// leaves out the warm-up and Count hits.
+func payloadHits(result AnchorResult) float64 {
+	if result.BytesPerHit == 0 {
+		return 0
+	}
+
+	return math.Round(float64(result.Bytes) / result.BytesPerHit)
+}
+
+// commonMetadata returns the entries a and b agree on, nil if none.
+func commonMetadata(a map[string]string, b map[string]string) map[string]string {
+	var common map[string]string
+	for key, value := range a {
+		if other, exists := b[key]; exists && other == value {
+			if common == nil {
+				common = make(map[string]string)
+			}
+			common[key] = value
+		}
+	}
+
+	return common
+}
@@ -0,0 +1,89 @@
+package timer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeWith(t *testing.T) {
+	var a = Report{
+		Name: "shard", CPUFrequency: testFrequency, Hits: 2, Metadata: map[string]string{"commit": "abc", "shard": "1"},
+		Total: AnchorResult{Name: "total", TSCount: 1000, Elapsed: 0.001},
+		Anchors: []AnchorResult{
+			{Name: "x", Hits: 1, TSCount: 1000, Elapsed: 0.001, Mean: 0.001, CyclesPerHit: 1000,
+				MinHit: 0.001, MaxHit: 0.001, P50: 0.001},
+			{Name: "only a", Depth: 1, ParentName: "x", Hits: 1, Elapsed: 0.0005},
+		},
+	}
+	var b = Report{
+		Name: "shard", CPUFrequency: testFrequency, Hits: 3, Metadata: map[string]string{"commit": "abc", "shard": "2"},
+		Total: AnchorResult{Name: "total", TSCount: 600, Elapsed: 0.003},
+		Anchors: []AnchorResult{
+			{Name: "x", Hits: 3, TSCount: 600, Elapsed: 0.003, Mean: 0.001, CyclesPerHit: 200,
+				MinHit: 0.0002, MaxHit: 0.002, P50: 0.0002},
+		},
+	}
+
+	var tests = []struct {
+		name         string
+		mode         MergeMode
+		cyclesPerHit float64
+	}{
+		// (1*1000 + 3*200) / 4
+		{"weighted", MergeWeighted, 400},
+		// (1000 + 200) / 2
+		{"unweighted", MergeUnweighted, 600},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var merged = MergeWith(test.mode, a, b)
+
+			if len(merged.Anchors) != 2 || merged.Anchors[0].Name != "x" || merged.Anchors[1].Name != "only a" {
+				t.Fatalf("anchors %+v, want x then only a", merged.Anchors)
+			}
+			var x = merged.Anchors[0]
+			if x.Hits != 4 || x.TSCount != 1600 || x.Elapsed != 0.004 {
+				t.Errorf("x: %d hits, tscount %d, %vms, want the sums 4, 1600, 0.004ms", x.Hits, x.TSCount, x.Elapsed)
+			}
+			if x.CyclesPerHit != test.cyclesPerHit {
+				t.Errorf("x: %v cycles per hit, want %v", x.CyclesPerHit, test.cyclesPerHit)
+			}
+			if x.MinHit != 0.0002 || x.MaxHit != 0.002 || x.P50 != 0 {
+				t.Errorf("x: hits from %vms to %vms, p50 %vms, want 0.0002ms to 0.002ms without p50", x.MinHit,
+					x.MaxHit, x.P50)
+			}
+			if x.Percent != 100 {
+				t.Errorf("x: %v%%, want 100%% recomputed from the merged elapsed times", x.Percent)
+			}
+
+			// Passed through unchanged but for its percentage
+			var only = merged.Anchors[1]
+			if only.Depth != 1 || only.ParentName != "x" || only.Hits != 1 || only.Percent != 12.5 {
+				t.Errorf("only a: %+v, want itself at 12.5%%", only)
+			}
+
+			if merged.Hits != 5 || merged.Total.Elapsed != 0.004 || merged.CPUFrequency != testFrequency {
+				t.Errorf("merged %d hits, %vms at %dHz", merged.Hits, merged.Total.Elapsed, merged.CPUFrequency)
+			}
+			if !reflect.DeepEqual(merged.Metadata, map[string]string{"commit": "abc"}) {
+				t.Errorf("metadata %v, want the common entries only", merged.Metadata)
+			}
+			if merged.Meta.Anchors != 2 || merged.Meta.Elapsed != 0.004 {
+				t.Errorf("header %+v, want the merged figures", merged.Meta)
+			}
+		})
+	}
+}
+
+func TestMergeDifferentFrequencies(t *testing.T) {
+	var a = Report{CPUFrequency: testFrequency}
+	var b = Report{CPUFrequency: 2 * testFrequency}
+
+	if merged := Merge(a, b); merged.CPUFrequency != 0 {
+		t.Errorf("CPU frequency %d, want 0 for reports of different frequencies", merged.CPUFrequency)
+	}
+	if merged := Merge(); !reflect.DeepEqual(merged, Report{}) {
+		t.Errorf("Merge() = %+v, want an empty report", merged)
+	}
+}
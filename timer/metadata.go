@@ -0,0 +1,85 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+/*
+SetMetadata replaces the metadata of the run, such as the commit, the input
+dataset or the machine name, with a copy of metadata. It is shown in the
+header of Output and saved with the report by Snapshot, WriteJSON and
+WriteBinary, making archived profiles self-identifying. Reset keeps it.
+*/
+func (p *Profiler) SetMetadata(metadata map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.metadata = make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		p.metadata[key] = value
+	}
+}
+
+// AddMetadata sets a single metadata entry of the run, see SetMetadata.
+func (p *Profiler) AddMetadata(key string, value string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata == nil {
+		p.metadata = make(map[string]string)
+	}
+
+	p.metadata[key] = value
+}
+
+// copyMetadata returns a copy of the metadata for a report, nil if empty.
+func (p *Profiler) copyMetadata() map[string]string {
+	if len(p.metadata) == 0 {
+		return nil
+	}
+
+	var metadata = make(map[string]string, len(p.metadata))
+	for key, value := range p.metadata {
+		metadata[key] = value
+	}
+
+	return metadata
+}
+
+// sortedKeys returns the keys of the metadata in alphabetical order.
+func sortedKeys(metadata map[string]string) []string {
+	var keys = make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// writeMetadata writes the header line of the metadata, if any.
+func (p *Profiler) writeMetadata(w io.Writer) {
+	if len(p.metadata) == 0 {
+		return
+	}
+
+	var entries []string
+	for _, key := range sortedKeys(p.metadata) {
+		entries = append(entries, key+"="+p.metadata[key])
+	}
+
+	fmt.Fprintf(w, "%*s: %s\n", p.nameLength(), "metadata", strings.Join(entries, ", "))
+}
+
+// SetMetadata replaces the run metadata of the default profiler.
+func SetMetadata(metadata map[string]string) {
+	defaultProfiler.SetMetadata(metadata)
+}
+
+// AddMetadata sets a run metadata entry of the default profiler.
+func AddMetadata(key string, value string) {
+	defaultProfiler.AddMetadata(key, value)
+}
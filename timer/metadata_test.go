@@ -0,0 +1,69 @@
+package timer
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	var metadata = map[string]string{"commit": "4dc3c4c", "dataset": "small"}
+	p.SetMetadata(metadata)
+	// Copied
+	metadata["dataset"] = "large"
+	p.AddMetadata("machine", "ci")
+	p.Start("a")
+	clock.advance(10)
+	p.Stop("a")
+
+	var want = map[string]string{"commit": "4dc3c4c", "dataset": "small", "machine": "ci"}
+	var report = p.Snapshot()
+	if !reflect.DeepEqual(report.Metadata, want) {
+		t.Errorf("metadata %v, want %v", report.Metadata, want)
+	}
+	if text := output(p); !strings.Contains(text, "metadata: commit=4dc3c4c, dataset=small, machine=ci\n") {
+		t.Errorf("report doesn't show the metadata:\n%s", text)
+	}
+
+	var buffer bytes.Buffer
+	if err := p.WriteJSON(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Metadata, want) {
+		t.Errorf("JSON metadata %v, want %v", decoded.Metadata, want)
+	}
+
+	buffer.Reset()
+	if err := WriteBinary(&buffer, report); err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err := ReadBinary(&buffer); err != nil || !reflect.DeepEqual(decoded.Metadata, want) {
+		t.Errorf("binary metadata %v (%v), want %v", decoded.Metadata, err, want)
+	}
+
+	p.Reset()
+	if kept := p.Snapshot().Metadata; !reflect.DeepEqual(kept, want) {
+		t.Errorf("after Reset: metadata %v, want %v", kept, want)
+	}
+}
+
+func TestMergeMetadata(t *testing.T) {
+	var a = Report{Metadata: map[string]string{"commit": "4dc3c4c", "machine": "ci-1"}}
+	var b = Report{Metadata: map[string]string{"commit": "4dc3c4c", "machine": "ci-2"}}
+
+	if merged := Merge(a, b); !reflect.DeepEqual(merged.Metadata, map[string]string{"commit": "4dc3c4c"}) {
+		t.Errorf("merged metadata %v, want the commit only", merged.Metadata)
+	}
+	if merged := Merge(a, Report{}); merged.Metadata != nil {
+		t.Errorf("merged metadata %v, want none", merged.Metadata)
+	}
+}
@@ -0,0 +1,26 @@
+package timer
+
+/*
+SetMinHits makes Output and the Results functions leave out the anchors hit
+fewer than n times, such as the one-off startup work that clutters a report
+about steady-state behavior. Their time still counts in the total and is not
+reported as unaccounted; Snapshot keeps every anchor. Zero or a negative value
+lists every anchor, which is the default.
+*/
+func (p *Profiler) SetMinHits(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.minHits = n
+}
+
+// belowMinHits reports whether the anchor is left out under SetMinHits.
+func (p *Profiler) belowMinHits(anchor *anchor) bool {
+	return anchor.hits < p.minHits
+}
+
+// SetMinHits leaves out the anchors of the default profiler hit fewer than n
+// times.
+func SetMinHits(n int64) {
+	defaultProfiler.SetMinHits(n)
+}
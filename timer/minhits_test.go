@@ -0,0 +1,77 @@
+package timer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMinHitsFilter(t *testing.T) {
+	var tests = []struct {
+		name    string
+		minHits int64
+		want    []string
+	}{
+		{"every anchor", 0, []string{"once", "often"}},
+		{"one-off left out", 2, []string{"often"}},
+		{"all left out", 10, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			p.SetMinHits(test.minHits)
+
+			p.Start("once")
+			clock.advance(10)
+			p.Stop("once")
+			for i := 0; i < 5; i++ {
+				p.Start("often")
+				clock.advance(10)
+				p.Stop("often")
+			}
+
+			var names []string
+			for _, result := range p.ResultsInto(nil) {
+				names = append(names, result.Name)
+			}
+			if !reflect.DeepEqual(names, test.want) {
+				t.Errorf("results %v, want %v", names, test.want)
+			}
+			if anchors := len(p.Snapshot().Anchors); anchors != 2 {
+				t.Errorf("snapshot of %d anchors, want every anchor", anchors)
+			}
+
+			var text = output(p)
+			if len(test.want) < 2 && !strings.Contains(text, "anchors with fewer than") {
+				t.Errorf("left out anchors not reported:\n%s", text)
+			}
+		})
+	}
+}
+
+func TestResultsIntoReusesSlice(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.SetDeviationStats(true)
+	p.TrackDistribution("child")
+	for i := 0; i < 3; i++ {
+		p.StartThroughput("parent", 64)
+		p.Start("child")
+		clock.advance(10)
+		p.Stop("child")
+		p.Stop("parent")
+	}
+
+	var results = p.ResultsInto(nil)
+	var allocs = testing.AllocsPerRun(100, func() {
+		results = p.ResultsInto(results)
+	})
+	if allocs != 0 {
+		t.Errorf("%v allocations per ResultsInto into a large enough slice, want none", allocs)
+	}
+	if len(results) != 2 {
+		t.Errorf("%d results, want 2", len(results))
+	}
+}
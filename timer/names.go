@@ -0,0 +1,143 @@
+package timer
+
+import (
+	"fmt"
+	"hash/fnv"
+	"unicode/utf8"
+)
+
+/*
+NameTooLongPolicy selects how anchor names longer than anchorNameMaxLength, or
+the length set by SetMaxNameLength, are handled. Lengths count characters
+rather than bytes, and names are only ever cut between characters, so that
+shortened names stay valid UTF-8.
+*/
+type NameTooLongPolicy int
+
+const (
+	// TruncateLongNames keeps the first characters of the name. Distinct
+	// names sharing that prefix are merged into the same anchor, with a
+	// warning the first time it happens to each prefix. Default.
+	TruncateLongNames NameTooLongPolicy = iota
+
+	// RejectLongNames refuses the name, StartE and StopE return
+	// ErrNameTooLong and nothing is recorded.
+	RejectLongNames
+
+	// HashLongNames keeps the first characters of the name and replaces the
+	// tail with a hash of the full name, keeping distinct names apart. Under
+	// SetMaxNameLength limits too short for the hash, names are truncated.
+	HashLongNames
+
+	// KeepLongNames records the anchor under its full name, which Snapshot
+	// and the other formats report as is, and only shortens it for display
+	// in Output, an ellipsis replacing its middle.
+	KeepLongNames
+)
+
+// Hex digits of a 32 bits hash, plus a separator
+const hashedSuffixLength = 9
+
+// anchorKey returns the name under which the anchor is recorded.
+func (p *Profiler) anchorKey(anchorName string) (string, error) {
+	anchorName = p.prefix + anchorName
+
+	var maxLength = p.nameLength()
+	if utf8.RuneCountInString(anchorName) <= maxLength {
+		return anchorName, nil
+	}
+
+	switch p.nameTooLongPolicy {
+	case RejectLongNames:
+		return "", ErrNameTooLong
+	case HashLongNames:
+		if maxLength < hashedSuffixLength {
+			// No room for the hash, truncated as by default
+			break
+		}
+		var hash = fnv.New32a()
+		hash.Write([]byte(anchorName))
+		var prefix = runePrefix(anchorName, maxLength-hashedSuffixLength)
+		return fmt.Sprintf("%s~%08x", prefix, hash.Sum32()), nil
+	case KeepLongNames:
+		return anchorName, nil
+	}
+
+	var key = runePrefix(anchorName, maxLength)
+	p.checkTruncation(key, anchorName)
+	return key, nil
+}
+
+// checkTruncation warns once per key when distinct names are truncated into
+// it, their timings being merged.
+func (p *Profiler) checkTruncation(key string, anchorName string) {
+	var first, seen = p.truncatedNames[key]
+	if !seen {
+		if p.truncatedNames == nil {
+			p.truncatedNames = make(map[string]string)
+		}
+
+		p.truncatedNames[key] = anchorName
+		return
+	}
+
+	if first == "" || first == anchorName {
+		// Already warned, or the same name again
+		return
+	}
+
+	warn("timer: %q and %q are both truncated to anchor %q and merged, see SetNameTooLongPolicy",
+		first, anchorName, key)
+	p.truncatedNames[key] = ""
+}
+
+// displayName shortens the name of an anchor recorded under KeepLongNames to
+// the width of the Output column, keeping both ends: qualified names sharing
+// a long prefix usually differ by their tail.
+func (p *Profiler) displayName(name string) string {
+	var maxLength = p.nameLength()
+	if utf8.RuneCountInString(name) <= maxLength {
+		return name
+	}
+
+	var head = (maxLength - 1) / 2
+	var tail = maxLength - 1 - head
+	return runePrefix(name, head) + "…" + runeSuffix(name, tail)
+}
+
+// runePrefix returns the first n characters of name.
+func runePrefix(name string, n int) string {
+	for i := range name {
+		if n == 0 {
+			return name[:i]
+		}
+		n = n - 1
+	}
+
+	return name
+}
+
+// runeSuffix returns the last n characters of name.
+func runeSuffix(name string, n int) string {
+	var start = len(name)
+	for ; n > 0 && start > 0; n-- {
+		var _, size = utf8.DecodeLastRuneInString(name[:start])
+		start = start - size
+	}
+
+	return name[start:]
+}
+
+// SetNameTooLongPolicy selects how names exceeding the maximum length are
+// handled, see NameTooLongPolicy.
+func (p *Profiler) SetNameTooLongPolicy(policy NameTooLongPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nameTooLongPolicy = policy
+}
+
+// SetNameTooLongPolicy selects the long name policy of the default profiler.
+func SetNameTooLongPolicy(policy NameTooLongPolicy) {
+	defaultProfiler.SetNameTooLongPolicy(policy)
+}
@@ -0,0 +1,144 @@
+package timer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLongNamesCutBetweenCharacters(t *testing.T) {
+	var tests = []struct {
+		name      string
+		policy    NameTooLongPolicy
+		maxLength int
+		anchor    string
+		key       string
+		display   string
+	}{
+		{"ascii truncated", TruncateLongNames, 6, "abcdefghij", "abcdef", "abcdef"},
+		{"multi-byte truncated", TruncateLongNames, 4, "héllo wörld", "héll", "héll"},
+		{"multi-byte within the limit", TruncateLongNames, 5, "日本語です", "日本語です", "日本語です"},
+		{"multi-byte kept", KeepLongNames, 5, "日本語の長い名前", "日本語の長い名前", "日本…名前"},
+		{"emoji kept", KeepLongNames, 3, "🔥🔥🔥🔥🔥", "🔥🔥🔥🔥🔥", "🔥…🔥"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			p.SetMaxNameLength(test.maxLength)
+			p.SetNameTooLongPolicy(test.policy)
+
+			p.Start(test.anchor)
+			clock.advance(10)
+			p.Stop(test.anchor)
+
+			var result = resultOf(t, p.Snapshot(), test.key)
+			if !utf8.ValidString(result.Name) {
+				t.Errorf("invalid UTF-8 anchor name %q", result.Name)
+			}
+			if display := p.displayName(test.key); display != test.display {
+				t.Errorf("displayed as %q, want %q", display, test.display)
+			}
+
+			var buffer strings.Builder
+			if err := p.WriteJSON(&buffer); err != nil {
+				t.Fatal(err)
+			}
+			if !json.Valid([]byte(buffer.String())) || strings.Contains(buffer.String(), `\ufffd`) {
+				t.Errorf("JSON export mangles the name: %s", buffer.String())
+			}
+			if text := output(p); !utf8.ValidString(text) {
+				t.Errorf("invalid UTF-8 in the report:\n%q", text)
+			}
+		})
+	}
+}
+
+func TestHashedNamesWithinTheLimit(t *testing.T) {
+	var tests = []struct {
+		name      string
+		maxLength int
+		// Whether the two names sharing a prefix stay apart
+		apart bool
+	}{
+		{"default limit", 0, true},
+		{"room for the hash only", hashedSuffixLength, true},
+		{"multi-byte prefix", 12, true},
+		{"too short for the hash", 5, false},
+		{"single character", 1, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			p.SetMaxNameLength(test.maxLength)
+			p.SetNameTooLongPolicy(HashLongNames)
+
+			var names = []string{"éèêëēėę-shared-prefix-one", "éèêëēėę-shared-prefix-two"}
+			for _, name := range names {
+				p.Start(name)
+				p.Stop(name)
+			}
+
+			var report = p.Snapshot()
+			if apart := len(report.Anchors) == 2; apart != test.apart {
+				t.Errorf("%d anchors recorded, names kept apart: %v, want %v", len(report.Anchors), apart,
+					test.apart)
+			}
+			for _, result := range report.Anchors {
+				if length := utf8.RuneCountInString(result.Name); length > p.nameLength() {
+					t.Errorf("key %q of %d characters exceeds the limit of %d", result.Name, length,
+						p.nameLength())
+				}
+				if !utf8.ValidString(result.Name) {
+					t.Errorf("invalid UTF-8 key %q", result.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncationCollisions(t *testing.T) {
+	var first = "service.handlers.user.create"
+	var second = "service.handlers.user.delete"
+
+	var tests = []struct {
+		name    string
+		policy  NameTooLongPolicy
+		anchors int
+		warned  int
+	}{
+		{"truncated", TruncateLongNames, 1, 1},
+		{"kept", KeepLongNames, 2, 0},
+		{"hashed", HashLongNames, 2, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var captured = captureWarnings(t)
+			var p = New()
+			p.SetNameTooLongPolicy(test.policy)
+
+			// Warned once, whatever the hits
+			for i := 0; i < 3; i++ {
+				for _, name := range []string{first, second, first} {
+					p.Start(name)
+					clock.advance(10)
+					p.Stop(name)
+				}
+			}
+
+			if count := p.AnchorCount(); count != test.anchors {
+				t.Errorf("%d anchors, want %d", count, test.anchors)
+			}
+			if warned := strings.Count(captured.String(), "are both truncated"); warned != test.warned {
+				t.Errorf("warned %d times, want %d: %q", warned, test.warned, captured.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package timer
+
+/*
+SetMaxNesting bounds the number of anchors open at once, recursive calls
+included, to protect against runaway recursion: each open anchor holds a
+timing, so an unbounded recursion would hold an unbounded chain of them.
+Beyond the limit, Start only counts a hit on the anchor, without allocating a
+timing nor reading the clock, and the matching Stop is ignored; a warning is
+emitted the first time. The time spent beyond the limit isn't attributed
+precisely, it is counted by the innermost anchor opened within the limit.
+Zero, the default, sets no limit.
+*/
+func (p *Profiler) SetMaxNesting(depth int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if depth < 0 {
+		depth = 0
+	}
+
+	p.maxNesting = int64(depth)
+}
+
+// beyondMaxNesting counts a hit on an anchor started past the nesting limit,
+// if the limit is reached, and reports whether it did.
+func (p *Profiler) beyondMaxNesting(starting *anchor) bool {
+	if p.maxNesting == 0 || p.openDepth < p.maxNesting {
+		return false
+	}
+
+	if !p.nestingLimitReached {
+		warn("timer: %d anchors open at once, %q and deeper anchors only count hits", p.openDepth,
+			starting.name)
+		p.nestingLimitReached = true
+	}
+
+	starting.hits = starting.hits + 1
+	// Matching Stop ignored, as for a disabled anchor
+	starting.skipped = starting.skipped + 1
+	return true
+}
+
+// SetMaxNesting bounds the number of anchors open at once in the default
+// profiler.
+func SetMaxNesting(depth int) {
+	defaultProfiler.SetMaxNesting(depth)
+}
@@ -0,0 +1,126 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxNestingBoundsRecursion(t *testing.T) {
+	var tests = []struct {
+		name  string
+		limit int
+		depth int
+	}{
+		{"single level", 1, 1000},
+		{"shallow limit", 16, 100000},
+		{"within the limit", 64, 32},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var captured = captureWarnings(t)
+			var p = New()
+			p.SetMaxNesting(test.limit)
+
+			var recurse func(level int)
+			recurse = func(level int) {
+				p.Start("recurse")
+				clock.advance(1)
+				if level < test.depth {
+					recurse(level + 1)
+				}
+				p.Stop("recurse")
+			}
+			recurse(1)
+
+			var open = test.depth
+			if open > test.limit {
+				open = test.limit
+			}
+
+			var report = p.Snapshot()
+			var result = resultOf(t, report, "recurse")
+			if result.Hits != int64(test.depth) {
+				t.Errorf("hits = %d, want %d", result.Hits, test.depth)
+			}
+			if result.MaxRecursion != int64(open) || report.MaxDepth != int64(open) {
+				t.Errorf("max recursion %d, max depth %d, want %d", result.MaxRecursion, report.MaxDepth, open)
+			}
+			// Time beyond the limit is counted by the innermost timed call
+			if result.TSCount != int64(test.depth) {
+				t.Errorf("tscount = %d, want %d", result.TSCount, test.depth)
+			}
+			if result.Open || report.UnmatchedStops != 0 {
+				t.Errorf("open %v, %d unmatched stops after balanced calls", result.Open, report.UnmatchedStops)
+			}
+
+			var warned = strings.Count(captured.String(), "only count hits")
+			if test.depth > test.limit && warned != 1 {
+				t.Errorf("warned %d times about the limit, want once", warned)
+			}
+			if test.depth <= test.limit && warned != 0 {
+				t.Errorf("warned about a limit that wasn't reached")
+			}
+		})
+	}
+}
+
+func TestMaxNestingDoesNotAllocate(t *testing.T) {
+	useFakeClock(t)
+	captureWarnings(t)
+	var p = New()
+	p.SetMaxNesting(4)
+
+	for i := 0; i < 4; i++ {
+		p.Start("recurse")
+	}
+
+	// Beyond the limit, a hit holds no timing
+	var allocs = testing.AllocsPerRun(1000, func() {
+		p.Start("recurse")
+		p.Stop("recurse")
+	})
+	if allocs != 0 {
+		t.Errorf("%v allocations per Start and Stop beyond the limit, want none", allocs)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	for _, name := range []string{"a", "b", "c"} {
+		p.Start(name)
+		clock.advance(10)
+	}
+	for _, name := range []string{"c", "b", "a"} {
+		p.Stop(name)
+	}
+	p.Start("d")
+	clock.advance(10)
+	p.Stop("d")
+
+	var report = p.Snapshot()
+	if report.MaxDepth != 3 {
+		t.Errorf("max depth %d, want 3", report.MaxDepth)
+	}
+	if text := output(p); !strings.Contains(text, "max depth: 3 anchors open at once") {
+		t.Errorf("report doesn't show the max depth:\n%s", text)
+	}
+	if merged := Merge(report, Report{MaxDepth: 5}); merged.MaxDepth != 5 {
+		t.Errorf("merged max depth %d, want 5", merged.MaxDepth)
+	}
+
+	// Back to the anchors still open
+	p.Start("a")
+	p.ResetCounters()
+	if depth := p.Snapshot().MaxDepth; depth != 1 {
+		t.Errorf("after ResetCounters: max depth %d, want 1", depth)
+	}
+	p.Stop("a")
+	p.Reset()
+	if depth := p.Snapshot().MaxDepth; depth != 0 {
+		t.Errorf("after Reset: max depth %d, want 0", depth)
+	}
+}
@@ -0,0 +1,39 @@
+package timer
+
+/*
+NormalizeTo converts the CPU timer units of the report to times with the
+reference frequency, in Hz, instead of the estimated one, as if the code ran
+at that frequency: profiles of machines with different frequencies then
+compare by cycles rather than by seconds. Output tells normalized times apart
+with a header line, Snapshot with Report.NormalizedFrequency. Zero restores
+the estimated frequency.
+
+Normalizing is only meaningful when the CPU timer counts cycles at a rate
+close to the actual core frequency.
+*/
+func (p *Profiler) NormalizeTo(frequency int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if frequency < 0 {
+		frequency = 0
+	}
+
+	p.normalizedFrequency = frequency
+}
+
+// milliseconds converts CPU timer units to milliseconds for a report, with
+// the frequency set by NormalizeTo if any.
+func (p *Profiler) milliseconds(tscount int64) float64 {
+	if p.normalizedFrequency == 0 {
+		return ticksToMilliseconds(tscount)
+	}
+
+	return float64(tscount) / (float64(p.normalizedFrequency) / 1000)
+}
+
+// NormalizeTo converts the default profiler times with a reference
+// frequency.
+func NormalizeTo(frequency int64) {
+	defaultProfiler.NormalizeTo(frequency)
+}
@@ -0,0 +1,43 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTo(t *testing.T) {
+	var tests = []struct {
+		name      string
+		frequency int64
+		elapsed   float64
+		header    bool
+	}{
+		{"estimated", 0, 2, false},
+		{"half the frequency", testFrequency / 2, 4, true},
+		{"twice the frequency", 2 * testFrequency, 1, true},
+		{"negative", -1, 2, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			p.Start("a")
+			clock.advance(2000000)
+			p.Stop("a")
+			p.NormalizeTo(test.frequency)
+
+			var report = p.Snapshot()
+			var result = resultOf(t, report, "a")
+			if result.Elapsed != test.elapsed || result.TSCount != 2000000 {
+				t.Errorf("%vms of %d ticks, want %vms of 2000000", result.Elapsed, result.TSCount, test.elapsed)
+			}
+			if header := strings.Contains(output(p), "normalized: times as if run at"); header != test.header {
+				t.Errorf("normalized header %v, want %v", header, test.header)
+			}
+			if (report.NormalizedFrequency != 0) != test.header {
+				t.Errorf("normalized frequency %d", report.NormalizedFrequency)
+			}
+		})
+	}
+}
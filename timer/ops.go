@@ -0,0 +1,90 @@
+package timer
+
+/*
+StartOps is Start also adding ops processed items to the anchor, e.g. parsed
+records or handled requests, for which Output reports a rate in items per
+second rather than bytes.
+*/
+func (p *Profiler) StartOps(anchorName string, ops int64) {
+	if profilingDisabled() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err = p.start(anchorName, 0)
+	if err == nil {
+		err = p.addOps(anchorName, ops)
+	}
+
+	warnError(err)
+}
+
+/*
+AddOps adds processed items to a started anchor, for when the amount is only
+known after the work, unlike with StartOps.
+*/
+func (p *Profiler) AddOps(anchorName string, ops int64) {
+	if profilingDisabled() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	warnError(p.addOps(anchorName, ops))
+}
+
+// addOps is AddOps with the lock held.
+func (p *Profiler) addOps(anchorName string, ops int64) error {
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return &AnchorError{Op: "add ops", Anchor: anchorName, Err: err}
+	}
+
+	if p.disabledAnchors[key] {
+		return nil
+	}
+
+	var anchor, exists = p.anchorsByName[key]
+	if !exists {
+		if p.limitReached {
+			return nil
+		}
+		return &AnchorError{Op: "add ops", Anchor: anchorName, Err: ErrUnknownAnchor}
+	}
+
+	anchor.ops = anchor.ops + ops
+
+	return nil
+}
+
+// totalOps sums the items of every anchor.
+func (p *Profiler) totalOps() int64 {
+	var ops int64
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		ops = ops + anchor.ops
+	}
+
+	return ops
+}
+
+// opsPerSecond divides ops by the elapsed milliseconds, zero if none elapsed.
+func opsPerSecond(ops int64, elapsed float64) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(ops) / (elapsed / 1000)
+}
+
+// StartOps starts an anchor of the default profiler processing ops items.
+func StartOps(anchorName string, ops int64) {
+	defaultProfiler.StartOps(anchorName, ops)
+}
+
+// AddOps adds processed items to an anchor of the default profiler.
+func AddOps(anchorName string, ops int64) {
+	defaultProfiler.AddOps(anchorName, ops)
+}
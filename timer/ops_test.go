@@ -0,0 +1,43 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOps(t *testing.T) {
+	var clock = useFakeClock(t)
+	var captured = captureWarnings(t)
+	var p = New()
+
+	p.StartOps("parse", 1000)
+	clock.advance(500000000)
+	p.AddOps("parse", 500)
+	p.Stop("parse")
+	p.Start("idle")
+	clock.advance(500000000)
+	p.Stop("idle")
+
+	var report = p.Snapshot()
+	if parse := resultOf(t, report, "parse"); parse.Ops != 1500 || parse.OpsPerSecond != 3000 {
+		t.Errorf("parse: %d ops at %v/s, want 1500 at 3000/s", parse.Ops, parse.OpsPerSecond)
+	}
+	if report.Ops != 1500 || report.OpsPerSecond != 1500 {
+		t.Errorf("total: %d ops at %v/s, want 1500 at 1500/s", report.Ops, report.OpsPerSecond)
+	}
+
+	var text = output(p)
+	for _, line := range []string{"ops: 1500 at 1500.0 ops/s (total)", "1500 ops at 3000.0 ops/s (cpu)"} {
+		if !strings.Contains(text, line) {
+			t.Errorf("report doesn't contain %q:\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, "idle:    500.000ms (50.00%) -- calls: 1, avg: 500.000ms,") {
+		t.Errorf("rate reported for an anchor without items:\n%s", text)
+	}
+
+	p.AddOps("unknown", 1)
+	if !strings.Contains(captured.String(), "unknown") {
+		t.Errorf("warning %q doesn't report the unknown anchor", captured.String())
+	}
+}
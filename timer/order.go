@@ -0,0 +1,119 @@
+package timer
+
+import (
+	"io"
+	"sort"
+)
+
+/*
+OutputOrder selects the order of the anchors in Output.
+
+OrderRegistration, the default, lists the anchors in the order they were
+first started, i.e. registered, whatever their parent: an anchor first started
+after a sibling of its parent is listed after that sibling. The other orders
+list the anchors depth first, each anchor followed by its own children, and
+sort the children of each anchor:
+  - OrderFirstHit by the time of their first hit since the latest reset,
+    which reads like an execution timeline;
+  - OrderElapsed by decreasing elapsed time, children included;
+  - OrderName alphabetically.
+
+OrderSelfElapsed drops the hierarchy for a quick look at where the time goes:
+it lists every anchor by decreasing elapsed time of its own, children
+excluded, without indenting the children so that the columns line up.
+*/
+type OutputOrder int
+
+const (
+	OrderRegistration OutputOrder = iota
+	OrderFirstHit
+	OrderElapsed
+	OrderName
+	OrderSelfElapsed
+)
+
+// SetOutputOrder changes the order of the anchors in Output.
+func (p *Profiler) SetOutputOrder(order OutputOrder) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.outputOrder = order
+}
+
+/*
+OutputOrdered displays the same report as Output under OrderName, whatever the
+order set by SetOutputOrder: depth first from the total, the children of each
+anchor sorted alphabetically. The rows then don't depend on which code path
+first started an anchor, and the report header leaves out its generation
+time, so repeated runs of the same workload produce reports differing only
+by their numbers, e.g. for golden files.
+*/
+func (p *Profiler) OutputOrdered() {
+	if profilingDisabled() {
+		return
+	}
+
+	var destination, closeDestination = outputDestination()
+	defer closeDestination()
+
+	p.WriteOrdered(destination)
+}
+
+// WriteOrdered writes the report of OutputOrdered to w.
+func (p *Profiler) WriteOrdered(w io.Writer) {
+	if profilingDisabled() {
+		return
+	}
+
+	var report = reportBuffer{destination: w}
+
+	p.mu.Lock()
+	p.write(&report, writeOptions{ordered: true})
+	p.mu.Unlock()
+
+	w.Write(report.Bytes())
+}
+
+// outputAnchors returns the anchors in the given order, that of
+// SetOutputOrder unless overridden for a single report.
+func (p *Profiler) outputAnchors(order OutputOrder) []*anchor {
+	switch order {
+	case OrderFirstHit:
+		return p.hierarchyOrder(func(a *anchor, b *anchor) bool {
+			// Anchors not hit since the latest reset come last
+			if a.firstHit == 0 || b.firstHit == 0 {
+				return b.firstHit == 0 && a.firstHit != 0
+			}
+			return a.firstHit < b.firstHit
+		})
+	case OrderElapsed:
+		p.sumSubtrees()
+		return p.hierarchyOrder(func(a *anchor, b *anchor) bool {
+			return a.subtree > b.subtree
+		})
+	case OrderName:
+		return p.hierarchyOrder(func(a *anchor, b *anchor) bool {
+			return a.name < b.name
+		})
+	case OrderSelfElapsed:
+		var anchors = append([]*anchor(nil), p.anchors[1:p.index+1]...)
+		sort.SliceStable(anchors, func(i, j int) bool {
+			return anchors[i].tscount > anchors[j].tscount
+		})
+		return anchors
+	}
+
+	return p.anchors[1 : p.index+1]
+}
+
+// SetOutputOrder changes the order of the anchors in the default profiler
+// Output.
+func SetOutputOrder(order OutputOrder) {
+	defaultProfiler.SetOutputOrder(order)
+}
+
+// OutputOrdered displays the default profiler report with the anchors sorted
+// by name.
+func OutputOrdered() {
+	defaultProfiler.OutputOrdered()
+}
@@ -0,0 +1,156 @@
+package timer
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runWorkload records the same anchors and times in the given order: the
+// roots, "parent" holding the children.
+func runWorkload(p *Profiler, clock *fakeClock, roots []string, children []string) {
+	for _, root := range roots {
+		p.Start(root)
+		clock.advance(int64(100 * len(root)))
+		if root == "parent" {
+			for _, child := range children {
+				p.Start(child)
+				clock.advance(10 * int64(child[0]))
+				p.Stop(child)
+			}
+		}
+		p.Stop(root)
+	}
+}
+
+// anchorRows returns the anchor names in the order of the rows of report.
+func anchorRows(report string, names ...string) []string {
+	var known = make(map[string]bool)
+	for _, name := range names {
+		known[name] = true
+	}
+
+	var rows []string
+	for _, line := range strings.Split(report, "\n") {
+		var name = strings.TrimSpace(strings.SplitN(line, ":", 2)[0])
+		if known[name] {
+			rows = append(rows, name)
+		}
+	}
+
+	return rows
+}
+
+func TestWriteOrderedIsDeterministic(t *testing.T) {
+	var names = []string{"z", "parent", "m", "a", "b", "c"}
+	var workloads = []struct {
+		roots    []string
+		children []string
+	}{
+		{[]string{"z", "parent", "m"}, []string{"c", "a", "b"}},
+		{[]string{"m", "parent", "z"}, []string{"b", "a", "c"}},
+	}
+
+	var ordered [][]byte
+	for _, workload := range workloads {
+		var clock = useFakeClock(t)
+		var p = New()
+		runWorkload(p, clock, workload.roots, workload.children)
+
+		var buffer bytes.Buffer
+		p.WriteOrdered(&buffer)
+		ordered = append(ordered, buffer.Bytes())
+
+		// The default order is kept, that of registration
+		var want = append(append([]string{}, workload.roots[:2]...), workload.children...)
+		want = append(want, workload.roots[2])
+		if rows := anchorRows(output(p), names...); !reflect.DeepEqual(rows, want) {
+			t.Errorf("Output rows %v, want the registration order %v", rows, want)
+		}
+	}
+
+	if !bytes.Equal(ordered[0], ordered[1]) {
+		t.Errorf("reports differ:\n%s\n%s", ordered[0], ordered[1])
+	}
+	if strings.Contains(string(ordered[0]), "generated") {
+		t.Errorf("the report tells its generation time:\n%s", ordered[0])
+	}
+	var want = []string{"m", "parent", "a", "b", "c", "z"}
+	if rows := anchorRows(string(ordered[0]), names...); !reflect.DeepEqual(rows, want) {
+		t.Errorf("WriteOrdered rows %v, want %v", rows, want)
+	}
+}
+
+func TestSetOutputOrder(t *testing.T) {
+	var names = []string{"z", "parent", "m", "a", "b", "c"}
+	var tests = []struct {
+		order OutputOrder
+		want  []string
+	}{
+		{OrderRegistration, []string{"z", "parent", "c", "a", "b", "m"}},
+		{OrderFirstHit, []string{"z", "parent", "c", "a", "b", "m"}},
+		{OrderName, []string{"m", "parent", "a", "b", "c", "z"}},
+		// parent: 600 of its own, 3540 with its children
+		{OrderElapsed, []string{"parent", "c", "b", "a", "z", "m"}},
+		{OrderSelfElapsed, []string{"c", "b", "a", "parent", "z", "m"}},
+	}
+
+	for _, test := range tests {
+		var clock = useFakeClock(t)
+		var p = New()
+		runWorkload(p, clock, []string{"z", "parent", "m"}, []string{"c", "a", "b"})
+		p.SetOutputOrder(test.order)
+
+		if rows := anchorRows(output(p), names...); !reflect.DeepEqual(rows, test.want) {
+			t.Errorf("order %d: rows %v, want %v", test.order, rows, test.want)
+		}
+	}
+}
+
+func TestOrderFirstHitAfterReset(t *testing.T) {
+	var names = []string{"a", "b", "c"}
+	var tests = []struct {
+		order OutputOrder
+		want  []string
+	}{
+		{OrderRegistration, []string{"a", "b", "c"}},
+		// c not hit since the reset comes last
+		{OrderFirstHit, []string{"b", "a", "c"}},
+	}
+
+	for _, test := range tests {
+		var clock = useFakeClock(t)
+		var p = New()
+		runWorkload(p, clock, []string{"a", "b", "c"}, nil)
+		p.ResetCounters()
+		runWorkload(p, clock, []string{"b", "a"}, nil)
+		p.SetOutputOrder(test.order)
+
+		if rows := anchorRows(output(p), names...); !reflect.DeepEqual(rows, test.want) {
+			t.Errorf("order %d: rows %v, want %v", test.order, rows, test.want)
+		}
+	}
+}
+
+func TestOrderSelfElapsedIsFlat(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.Start("parent")
+	clock.advance(1000000)
+	p.Start("child")
+	clock.advance(3000000)
+	p.Stop("child")
+	p.Stop("parent")
+	p.SetOutputOrder(OrderSelfElapsed)
+
+	var text = output(p)
+	var total, child, parent = strings.Index(text, " total:"), strings.Index(text, "\n             child:"),
+		strings.Index(text, "\n            parent:")
+	if total < 0 || child < 0 || parent < 0 {
+		t.Fatalf("anchors not aligned on the same column:\n%s", text)
+	}
+	if !(total < child && child < parent) {
+		t.Errorf("want the total line first, then child and parent:\n%s", text)
+	}
+}
@@ -0,0 +1,415 @@
+package timer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+/*
+Output displays computed information for the current timer execution, to the
+standard output or the file named by TIMER_OUTPUT_ENV_VAR. Counters are reset
+afterwards when SetAutoReset is enabled. Before any Start, it only states that
+no timings were recorded, and percentages of a zero total read "n/a".
+*/
+func (p *Profiler) Output() {
+	if profilingDisabled() {
+		return
+	}
+
+	var destination, closeDestination = outputDestination()
+	defer closeDestination()
+
+	p.OutputTo(destination)
+}
+
+/*
+OutputTo writes the same report as Output to w, e.g. a log file or a buffer
+in a test, in the format selected by TIMER_FORMAT_ENV_VAR. Counters are reset
+afterwards when SetAutoReset is enabled.
+
+The report is formatted at once under the lock, so that it is consistent even
+while other goroutines keep starting and stopping anchors, then written once
+the lock is released, so that a slow destination doesn't hold them.
+*/
+func (p *Profiler) OutputTo(w io.Writer) {
+	if profilingDisabled() {
+		return
+	}
+
+	var report = reportBuffer{destination: w}
+
+	p.mu.Lock()
+	p.outputFormat(&report)
+	if p.autoReset {
+		p.resetCounters()
+	}
+	p.mu.Unlock()
+
+	w.Write(report.Bytes())
+}
+
+// reportBuffer holds a report formatted for the destination writer.
+type reportBuffer struct {
+	bytes.Buffer
+	destination io.Writer
+}
+
+// outputDestination opens the destination selected by the environment, see
+// TIMER_OUTPUT_ENV_VAR, and returns it with the function closing it.
+func outputDestination() (*os.File, func()) {
+	var path = os.Getenv(TIMER_OUTPUT_ENV_VAR)
+	if path == "" || path == "-" {
+		return os.Stdout, func() {}
+	}
+
+	var file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		warn("timer: opening %s: %v, using the standard output", TIMER_OUTPUT_ENV_VAR, err)
+		return os.Stdout, func() {}
+	}
+
+	return file, func() { file.Close() }
+}
+
+// writeOptions selects the variations of the text report.
+type writeOptions struct {
+	// Anchor names colored by category
+	colored bool
+	// Only the anchors whose name starts with it are listed
+	prefix string
+	// Anchors listed under OrderName whatever SetOutputOrder, and the
+	// generation time left out, so that runs compare line by line
+	ordered bool
+	// Category subtotals above the anchors
+	grouped bool
+}
+
+// write formats the report to w as selected by options.
+func (p *Profiler) write(w io.Writer, options writeOptions) {
+	p.warnOpenAnchors()
+
+	var order = p.outputOrder
+	if options.ordered {
+		order = OrderName
+	}
+
+	fmt.Fprintln(w)
+
+	var padding = int64(p.nameLength())
+	if p.name != "" {
+		fmt.Fprintf(w, "%*s: %s\n", padding, "profile", p.name)
+	}
+	fmt.Fprintf(w, "%*s: %s\n", padding, "runtime", readRuntimeInfo())
+	p.writeMetadata(w)
+	// Read once, background calibration may change it meanwhile
+	var hz = GetCPUFrequency()
+	fmt.Fprintf(w, "%*s: %s\n", padding, "calibration", calibrationHeader(hz))
+	var meta = p.reportMeta(hz)
+	if options.ordered {
+		meta.Generated = time.Time{}
+	}
+	writeReportMeta(w, int(padding), meta)
+
+	var frequency = "uncalibrated"
+	if hz != 0 {
+		frequency = fmt.Sprint(hz)
+	}
+	if p.overheadCompensation != 0 {
+		fmt.Fprintf(w, "%*s: %d CPU timer units subtracted per hit\n", padding, "overhead",
+			p.overheadCompensation)
+	}
+	if p.normalizedFrequency != 0 {
+		fmt.Fprintf(w, "%*s: times as if run at %.3fGHz, not wall time\n", padding, "normalized",
+			float64(p.normalizedFrequency)/1e9)
+	}
+	if p.index == 0 {
+		// Never started since the latest reset: a 0.000ms total and no
+		// anchor would look like a broken profile
+		fmt.Fprintf(w, "%*s: no timings recorded, no anchor was started\n", padding, p.totalAnchor.name)
+		return
+	}
+
+	fmt.Fprintf(w, "%*s: %s (CPU freq: %s) -- calls: %d\n", padding, p.totalAnchor.name,
+		p.formatElapsed(p.milliseconds(p.totalAnchor.tscount)), frequency, p.totalHits())
+
+	if bytes, throughput := p.totalThroughput(); bytes > 0 {
+		fmt.Fprintf(w, "%*s: %s at %s (total)\n", padding, "throughput",
+			p.byteUnits.megabytes(bytes), p.byteUnits.gigabytesPerSecond(throughput))
+	}
+
+	if ops := p.totalOps(); ops > 0 {
+		fmt.Fprintf(w, "%*s: %d at %.1f ops/s (total)\n", padding, "ops", ops,
+			opsPerSecond(ops, p.milliseconds(p.totalAnchor.tscount)))
+	}
+
+	if p.maxDepth > 0 {
+		fmt.Fprintf(w, "%*s: %d anchors open at once\n", padding, "max depth", p.maxDepth)
+	}
+
+	if p.unmatchedStops > 0 {
+		fmt.Fprintf(w, "%*s: %d Stop calls without a matching Start\n", padding, "unmatched stops",
+			p.unmatchedStops)
+	}
+
+	if p.clockAnomalies > 0 {
+		fmt.Fprintf(w, "%*s: %d negative durations clamped to zero\n", padding, "clock anomalies",
+			p.clockAnomalies)
+	}
+
+	if count, pause, tracked := p.gcDelta(); tracked {
+		var pauseMs = float64(pause) / float64(time.Millisecond)
+		var percent = formatPercent(int64(pause), int64(ticksToDuration(p.totalAnchor.tscount)))
+		fmt.Fprintf(w, "%*s: %s (%s) -- collections: %d\n", padding, "gc",
+			p.formatElapsed(pauseMs), percent, count)
+	}
+
+	if options.grouped {
+		p.writeGroups(w)
+	}
+
+	p.sumSubtrees()
+	var listed = p.listedAnchors()
+	// Anchors left out by LimitOutput
+	var othersCount, othersHits, othersTSCount int64
+
+	var bars = p.barWidth(w)
+
+	var anchors = p.outputAnchors(order)
+	if p.flatOutput {
+		anchors = p.flatAnchors()
+	}
+
+	var filtered, rare int
+	for _, anchor := range anchors {
+		if !strings.HasPrefix(anchor.name, options.prefix) {
+			filtered = filtered + 1
+			continue
+		}
+
+		if p.belowMinHits(anchor) {
+			rare = rare + 1
+			continue
+		}
+
+		if p.excludedFromTotal[anchor.name] {
+			continue
+		}
+
+		if listed != nil && !listed[anchor] {
+			othersCount = othersCount + 1
+			othersHits = othersHits + anchor.hits
+			othersTSCount = othersTSCount + anchor.tscount
+			continue
+		}
+
+		var percent = formatPercent(anchor.tscount, p.totalAnchor.tscount)
+		if bars > 0 {
+			percent = percent + formatBar(anchor.tscount, p.totalAnchor.tscount, bars)
+		}
+		var padding = int64(p.nameLength()) + 2*anchor.depth
+		if p.flatOutput || order == OrderSelfElapsed {
+			padding = int64(p.nameLength())
+		}
+
+		var name = fmt.Sprintf("%*s", padding, p.displayName(anchor.name))
+		if options.colored {
+			name = colorize(name, p.categories[anchor.name])
+		}
+
+		if anchor.untimed() {
+			// Only counted by Count, see formatMean for the average
+			fmt.Fprintf(w, "%s: %12s (%6s) -- calls: %d, avg: %s%s\n", name, "n/a", "n/a",
+				anchor.hits, p.formatMean(anchor), p.details(anchor))
+			continue
+		}
+
+		if p.flatOutput {
+			var inclusivePercent = formatPercent(anchor.subtree, p.totalAnchor.tscount)
+			fmt.Fprintf(w, "%s: %s (%s) incl., %s (%s) excl. -- calls: %d, avg: %s%s\n", name,
+				p.formatElapsed(p.milliseconds(anchor.subtree)), inclusivePercent,
+				p.formatElapsed(p.milliseconds(anchor.tscount)), percent, anchor.hits, p.formatMean(anchor), p.details(anchor))
+		} else {
+			fmt.Fprintf(w, "%s: %s (%s) -- calls: %d, avg: %s%s\n", name,
+				p.formatElapsed(p.milliseconds(anchor.tscount)), percent, anchor.hits, p.formatMean(anchor), p.details(anchor))
+		}
+
+		if d, exists := p.throughputDurations[anchor.name]; exists {
+			fmt.Fprintf(w, "%*s: %s\n", padding+2, "throughput", formatThroughputOver(anchor, d, p.byteUnits))
+		}
+	}
+
+	if othersCount > 0 {
+		var percent = formatPercent(othersTSCount, p.totalAnchor.tscount)
+		fmt.Fprintf(w, "%*s: %s (%s) -- calls: %d, anchors: %d\n", padding, "others",
+			p.formatElapsed(p.milliseconds(othersTSCount)), percent,
+			othersHits, othersCount)
+	}
+
+	p.writeExcluded(w, options.prefix, order)
+
+	if rare > 0 {
+		fmt.Fprintf(w, "%*s: %d anchors with fewer than %d calls\n", padding, "left out", rare, p.minHits)
+	}
+
+	if filtered > 0 {
+		fmt.Fprintf(w, "%*s: %d anchors not starting with %q\n", padding, "filtered out", filtered, options.prefix)
+	} else if p.totalAnchor.tscount != 0 {
+		var unaccounted, clamped = p.unaccountedTSCount()
+		var percent = formatPercent(unaccounted, p.totalAnchor.tscount)
+		var note string
+		if clamped {
+			note = " -- clamped, anchors exceed the total"
+		}
+
+		fmt.Fprintf(w, "%*s: %s (%s)%s\n", padding, "unaccounted",
+			p.formatElapsed(p.milliseconds(unaccounted)), percent, note)
+	}
+
+	p.writePhases(w)
+}
+
+// formatPercent formats part as a percentage of whole, "n/a" when whole is
+// zero.
+func formatPercent(part int64, whole int64) string {
+	if whole <= 0 {
+		return fmt.Sprintf("%6s", "n/a")
+	}
+
+	return fmt.Sprintf("%5.2f%%", 100*float64(part)/float64(whole))
+}
+
+// formatMean formats the elapsed time per hit of the anchor, "n/a" when it was
+// not hit or only counted.
+func (p *Profiler) formatMean(anchor *anchor) string {
+	if anchor.hits == 0 || anchor.untimed() {
+		return "n/a"
+	}
+
+	return strings.TrimSpace(p.formatElapsed(p.milliseconds(anchor.tscount) / float64(anchor.hits)))
+}
+
+// details formats the optional parts of an anchor line of Output.
+func (p *Profiler) details(anchor *anchor) string {
+	var details string
+
+	if p.referenceDuration > 0 {
+		details += fmt.Sprintf(", of reference: %5.2f%%", p.percentOfReference(anchor))
+	}
+
+	if p.showPercentOfParent {
+		details += fmt.Sprintf(", of parent: %5.2f%%", p.percentOfParent(anchor))
+	}
+
+	if anchor.maxRecursion > 1 {
+		details += fmt.Sprintf(", recursion: %d", anchor.maxRecursion)
+	}
+
+	var seconds = p.milliseconds(anchor.tscount) / 1000
+	var base = "cpu"
+	if p.wallThroughput {
+		seconds = float64(anchor.wall) / float64(getOSTimerFreq())
+		base = "wall"
+	}
+
+	if anchor.bytes != 0 {
+		var throughput, throughputBase = "n/a", base
+		if seconds > 0 {
+			throughput = p.byteUnits.gigabytesPerSecond(float64(anchor.bytes) / seconds)
+		}
+		if rate := anchor.payload.hitThroughput(); rate > 0 && !p.wallThroughput {
+			// Hits of very different sizes would skew the lumped rate
+			throughput, throughputBase = p.byteUnits.gigabytesPerSecond(rate), "cpu, per hit"
+		}
+
+		details += fmt.Sprintf(", %s at %s (%s)", p.byteUnits.megabytes(anchor.bytes), throughput, throughputBase)
+		details += formatPayload(anchor, p.byteUnits)
+	}
+
+	if anchor.ops != 0 {
+		var rate = "n/a"
+		if seconds > 0 {
+			rate = fmt.Sprintf("%.1f ops/s", float64(anchor.ops)/seconds)
+		}
+
+		details += fmt.Sprintf(", %d ops at %s (%s)", anchor.ops, rate, base)
+	}
+
+	if anchor.hitRange.count > 1 {
+		var shortest = p.formatElapsed(p.milliseconds(anchor.hitRange.min))
+		var longest = p.formatElapsed(p.milliseconds(anchor.hitRange.max))
+		details += fmt.Sprintf(", range: %s - %s", strings.TrimSpace(shortest), strings.TrimSpace(longest))
+	}
+
+	if anchor.distribution != nil && anchor.distribution.count > 0 {
+		var p50, p90, p99 = p.percentiles(anchor)
+		details += fmt.Sprintf(", p50: %s, p90: %s, p99: %s", strings.TrimSpace(p.formatElapsed(p50)),
+			strings.TrimSpace(p.formatElapsed(p90)), strings.TrimSpace(p.formatElapsed(p99)))
+	}
+
+	if p.deviationStats && anchor.variation.count > 1 {
+		var stdDev = p.milliseconds(1) * anchor.variation.stdDev()
+		details += fmt.Sprintf(", stddev: %s (cv: %.2f)", strings.TrimSpace(p.formatElapsed(stdDev)),
+			anchor.variation.cv())
+	}
+
+	if anchor.blocked > 0 {
+		var blocked = p.milliseconds(anchor.blocked)
+		details += fmt.Sprintf(", blocked: %s", strings.TrimSpace(p.formatElapsed(blocked)))
+	}
+
+	if p.goroutineStats {
+		details += fmt.Sprintf(", goroutines: %d", len(anchor.goroutines))
+	}
+
+	if anchor.samples > 0 {
+		details += fmt.Sprintf(", samples: %d (%s)", anchor.samples,
+			strings.TrimSpace(formatPercent(anchor.samples, p.totalAnchor.samples)))
+	}
+
+	if p.wallTime {
+		// Subtree set by sumSubtrees in write
+		var cpu = ticksToMilliseconds(anchor.subtree)
+		var wall = float64(anchor.wall) / float64(getOSTimerFreq()/1000)
+		details += fmt.Sprintf(", wall: %s / cpu: %s (incl.)", p.formatElapsed(wall), p.formatElapsed(cpu))
+	}
+
+	if p.allocStats {
+		details += fmt.Sprintf(", allocs: %d (%s)", anchor.allocs, p.byteUnits.formatSize(float64(anchor.allocBytes)))
+	}
+
+	details += p.formatBudget(anchor)
+
+	if anchor.sampleRate > 1 {
+		details += fmt.Sprintf(" [sampled 1/%d]", anchor.sampleRate)
+	}
+
+	if p.belowResolution(anchor) {
+		details += " [below resolution]"
+	}
+
+	if anchor.open > 0 {
+		// Missing Stop, time since the latest Start isn't counted yet
+		details += " [open]"
+	}
+
+	return details
+}
+
+// warnOpenAnchors warns about anchors started but not stopped, whose time isn't
+// fully reported.
+func (p *Profiler) warnOpenAnchors() {
+	var open []string
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		if anchor.open > 0 {
+			open = append(open, anchor.name)
+		}
+	}
+
+	if len(open) > 0 {
+		warn("timer: reporting %d anchors still open: %s", len(open), strings.Join(open, ", "))
+	}
+}
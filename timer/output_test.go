@@ -0,0 +1,299 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOutputFlagsOpenAnchors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		starts []string
+		stops  []string
+		open   []string
+	}{
+		{"never stopped", []string{"a"}, nil, []string{"a"}},
+		{"child left open", []string{"a", "b"}, []string{"a"}, []string{"b"}},
+		{"parent left open", []string{"a", "b"}, []string{"b"}, []string{"a"}},
+		{"balanced", []string{"a", "b"}, []string{"b", "a"}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var captured = captureWarnings(t)
+			var p = New()
+
+			for _, name := range test.starts {
+				p.Start(name)
+				clock.advance(10)
+			}
+			for _, name := range test.stops {
+				p.Stop(name)
+			}
+
+			var report = p.Snapshot()
+			var open = make(map[string]bool)
+			for _, name := range test.open {
+				open[name] = true
+			}
+			for _, result := range report.Anchors {
+				if result.Open != open[result.Name] {
+					t.Errorf("%s: open = %v, want %v", result.Name, result.Open, open[result.Name])
+				}
+			}
+
+			var text = output(p)
+			if got := strings.Count(text, "[open]"); got != len(test.open) {
+				t.Errorf("%d anchors flagged [open], want %d:\n%s", got, len(test.open), text)
+			}
+
+			var warning = captured.String()
+			if len(test.open) == 0 {
+				if warning != "" {
+					t.Errorf("unexpected warning %q", warning)
+				}
+				return
+			}
+			if !strings.Contains(warning, "still open: "+strings.Join(test.open, ", ")) {
+				t.Errorf("warning %q doesn't name the open anchors %v", warning, test.open)
+			}
+		})
+	}
+}
+
+func TestOutputDegenerateStates(t *testing.T) {
+	var tests = []struct {
+		name      string
+		frequency int64
+		run       func(p *Profiler, clock *fakeClock)
+		want      string
+	}{
+		{"never started", testFrequency, func(p *Profiler, clock *fakeClock) {}, "no timings recorded"},
+		{"uncalibrated", 0, func(p *Profiler, clock *fakeClock) {
+			p.StartThroughput("a", 1024)
+			clock.advance(100)
+			p.Stop("a")
+		}, "(CPU freq: uncalibrated)"},
+		{"zero tscount", testFrequency, func(p *Profiler, clock *fakeClock) {
+			p.StartThroughput("a", 1024)
+			p.Stop("a")
+		}, "(   n/a)"},
+		{"only counted", testFrequency, func(p *Profiler, clock *fakeClock) {
+			p.Count("a")
+		}, "avg: n/a"},
+		{"still open", testFrequency, func(p *Profiler, clock *fakeClock) {
+			p.StartThroughput("a", 1024)
+		}, "[open]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			freqFn = func() int64 { return test.frequency }
+			captureWarnings(t)
+			var p = New()
+			test.run(p, clock)
+
+			var text = output(p)
+			if strings.Contains(text, "NaN") || strings.Contains(text, "Inf") {
+				t.Errorf("invalid number in the report:\n%s", text)
+			}
+			if !strings.Contains(text, test.want) {
+				t.Errorf("report doesn't contain %q:\n%s", test.want, text)
+			}
+
+			// Fails on NaN and infinite numbers
+			if err := p.WriteJSON(io.Discard); err != nil {
+				t.Errorf("WriteJSON: %v", err)
+			}
+		})
+	}
+}
+
+func TestOutputDuringRecording(t *testing.T) {
+	var tests = []struct {
+		name   string
+		report func(p *Profiler) error
+	}{
+		{"text", func(p *Profiler) error {
+			if text := output(p); strings.Contains(text, "NaN") {
+				return fmt.Errorf("invalid number in the report:\n%s", text)
+			}
+			return nil
+		}},
+		{"json", func(p *Profiler) error {
+			return p.WriteJSON(io.Discard)
+		}},
+		{"snapshot", func(p *Profiler) error {
+			var report = p.Snapshot()
+			var hits int64
+			for _, result := range report.Anchors {
+				hits = hits + result.Hits
+			}
+			if hits != report.Hits {
+				return fmt.Errorf("anchors sum up to %d hits, the report counts %d", hits, report.Hits)
+			}
+			return nil
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+
+			var recording sync.WaitGroup
+			var done = make(chan struct{})
+			for g := 0; g < 8; g++ {
+				recording.Add(1)
+				go func(name string) {
+					defer recording.Done()
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+
+						p.StartThroughput(name, 64)
+						clock.advance(10)
+						p.Stop(name)
+					}
+				}(fmt.Sprint("worker", g))
+			}
+
+			for i := 0; i < 50; i++ {
+				if err := test.report(p); err != nil {
+					t.Error(err)
+					break
+				}
+			}
+			close(done)
+			recording.Wait()
+		})
+	}
+}
+
+// stdout returns what run writes to the standard output.
+func stdout(tb testing.TB, run func()) string {
+	tb.Helper()
+
+	var read, write, err = os.Pipe()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer read.Close()
+
+	var saved = os.Stdout
+	os.Stdout = write
+	defer func() { os.Stdout = saved }()
+
+	var written = make(chan string)
+	go func() {
+		var data, _ = io.ReadAll(read)
+		written <- string(data)
+	}()
+
+	run()
+	write.Close()
+	return <-written
+}
+
+func TestOutputDestination(t *testing.T) {
+	var directory = t.TempDir()
+
+	var tests = []struct {
+		name    string
+		path    string
+		file    string
+		stdout  bool
+		warning string
+	}{
+		{"unset", "", "", true, ""},
+		{"dash", "-", "", true, ""},
+		{"file", filepath.Join(directory, "report.txt"), filepath.Join(directory, "report.txt"), false, ""},
+		{"failing path", filepath.Join(directory, "missing", "report.txt"), "", true,
+			"timer: opening TIMER_OUTPUT"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var captured = captureWarnings(t)
+			t.Setenv(TIMER_OUTPUT_ENV_VAR, test.path)
+
+			var p = New()
+			p.Start("a")
+			clock.advance(1000)
+			p.Stop("a")
+			var want = output(p)
+
+			var printed = stdout(t, func() {
+				p.Output()
+				p.Output()
+			})
+
+			var got = printed
+			if !test.stdout {
+				if printed != "" {
+					t.Errorf("wrote %q to the standard output", printed)
+				}
+				var data, err = os.ReadFile(test.file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = string(data)
+			}
+
+			// Appended to, and the same report as OutputTo
+			if got != want+want {
+				t.Errorf("got\n%s\nwant twice\n%s", got, want)
+			}
+			if warning := captured.String(); !strings.Contains(warning, test.warning) ||
+				(test.warning == "" && warning != "") {
+				t.Errorf("warning %q, want %q", warning, test.warning)
+			}
+		})
+	}
+}
+
+func TestOutputBeforeAnyStart(t *testing.T) {
+	var tests = []struct {
+		name string
+		run  func(p *Profiler, clock *fakeClock)
+	}{
+		{"new", func(p *Profiler, clock *fakeClock) {}},
+		{"reset", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(100)
+			p.Stop("a")
+			p.Reset()
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			freqFn = func() int64 { return 0 }
+			var p = New()
+			test.run(p, clock)
+
+			var text = output(p)
+			if !strings.HasSuffix(text, "             total: no timings recorded, no anchor was started\n") {
+				t.Errorf("report doesn't end with the empty total:\n%s", text)
+			}
+			for _, invalid := range []string{"NaN", "Inf", "%", "unaccounted"} {
+				if strings.Contains(text, invalid) {
+					t.Errorf("report contains %q:\n%s", invalid, text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package timer
+
+import (
+	"sync"
+	"time"
+)
+
+const timerOverheadIterations = 1000
+
+var timerOverhead int64
+var timerOverheadOnce sync.Once
+
+// measureTimerOverhead averages the cost of back to back readCPUTimer calls,
+// which includes crossing the cgo boundary.
+func measureTimerOverhead(iterations int) int64 {
+	var start = readCPUTimer()
+	for i := 0; i < iterations; i++ {
+		readCPUTimer()
+	}
+	var end = readCPUTimer()
+
+	return (end - start) / int64(iterations+1)
+}
+
+/*
+ReadTimerOverhead returns the cost of a single CPU timer read, in CPU timer
+units. Every Start and Stop pays it at least once, so anchors lasting only a
+few times this value mostly measure the timer itself.
+
+The value is measured on first use and cached afterwards. It is safe to call
+concurrently.
+*/
+func ReadTimerOverhead() int64 {
+	timerOverheadOnce.Do(func() {
+		timerOverhead = measureTimerOverhead(timerOverheadIterations)
+	})
+
+	return timerOverhead
+}
+
+/*
+EstimateOverhead returns the average cost of a Start/Stop pair, measured over
+iterations pairs on a throwaway profiler so the real profile isn't affected.
+Use it to judge whether instrumenting a hot loop is affordable.
+*/
+func EstimateOverhead(iterations int) time.Duration {
+	if iterations <= 0 {
+		return 0
+	}
+
+	var scratch = NewProfiler("")
+	// Pay the calibration before measuring
+	scratch.Start("overhead")
+	scratch.Stop("overhead")
+
+	var start = readCPUTimer()
+	for i := 0; i < iterations; i++ {
+		scratch.Start("overhead")
+		scratch.Stop("overhead")
+	}
+	var end = readCPUTimer()
+
+	scratch.Reset()
+
+	return ticksToDuration((end - start) / int64(iterations))
+}
@@ -0,0 +1,59 @@
+package timer
+
+import (
+	"testing"
+)
+
+// tickingClock is a CPU timer advancing by step on every read.
+func tickingClock(tb testing.TB, step int64) {
+	tb.Helper()
+
+	var clock = useFakeClock(tb)
+	clockFn = func() int64 {
+		clock.advance(step)
+		return clock.read()
+	}
+}
+
+func TestMeasureTimerOverhead(t *testing.T) {
+	for _, iterations := range []int{0, 1, 1000} {
+		tickingClock(t, 7)
+		if got := measureTimerOverhead(iterations); got != 7 {
+			t.Errorf("%d iterations: overhead = %d, want 7", iterations, got)
+		}
+	}
+}
+
+func TestReadTimerOverhead(t *testing.T) {
+	var first = ReadTimerOverhead()
+	if first < 0 {
+		t.Errorf("overhead = %d, want a positive cost", first)
+	}
+
+	// Measured once, whatever the clock does afterwards
+	tickingClock(t, 1000)
+	if got := ReadTimerOverhead(); got != first {
+		t.Errorf("overhead = %d, then %d, want it cached", first, got)
+	}
+}
+
+func TestEstimateOverhead(t *testing.T) {
+	tickingClock(t, 10)
+	captureWarnings(t)
+	Reset()
+	t.Cleanup(Reset)
+
+	for _, iterations := range []int{0, -1} {
+		if got := EstimateOverhead(iterations); got != 0 {
+			t.Errorf("%d iterations: overhead = %v, want 0", iterations, got)
+		}
+	}
+
+	if got := EstimateOverhead(100); got <= 0 {
+		t.Errorf("overhead = %v, want a positive duration", got)
+	}
+	// Measured on a throwaway profiler
+	if report := Snapshot(); len(report.Anchors) != 0 {
+		t.Errorf("the default profiler recorded %d anchors", len(report.Anchors))
+	}
+}
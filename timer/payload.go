@@ -0,0 +1,59 @@
+package timer
+
+import "fmt"
+
+// payload tracks the bytes processed per hit.
+type payload struct {
+	count int64
+	min   int64
+	max   int64
+
+	// Sum of the rates of the hits with bytes and a duration, in bytes per
+	// second, and their number
+	rateSum float64
+	rated   int64
+}
+
+func (s *payload) add(bytes int64) {
+	if s.count == 0 || bytes < s.min {
+		s.min = bytes
+	}
+	if bytes > s.max {
+		s.max = bytes
+	}
+	s.count = s.count + 1
+}
+
+// addRate adds the rate of a hit processing bytes in seconds.
+func (s *payload) addRate(bytes int64, seconds float64) {
+	if bytes <= 0 || seconds <= 0 {
+		return
+	}
+
+	s.rateSum = s.rateSum + float64(bytes)/seconds
+	s.rated = s.rated + 1
+}
+
+/*
+hitThroughput returns the mean of the rates of the hits, in bytes per second,
+zero if no hit processed bytes in a measurable time. Each hit weighs the same
+whatever its size, so that a single giant call doesn't stand for the rate of
+all the others as it does in the total bytes over the total time.
+*/
+func (s *payload) hitThroughput() float64 {
+	if s.rated == 0 {
+		return 0
+	}
+
+	return s.rateSum / float64(s.rated)
+}
+
+// formatPayload formats the bytes per hit of the anchor for Output.
+func formatPayload(anchor *anchor, units ByteUnits) string {
+	if anchor.payload.count == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(", payload: %s avg (%s - %s)", units.formatSize(float64(anchor.bytes)/float64(anchor.payload.count)),
+		units.formatSize(float64(anchor.payload.min)), units.formatSize(float64(anchor.payload.max)))
+}
@@ -0,0 +1,82 @@
+package timer
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestHitThroughputOfUnevenHits(t *testing.T) {
+	type hit struct {
+		bytes int64
+		ticks int64
+	}
+
+	var tests = []struct {
+		name string
+		hits []hit
+		want float64
+	}{
+		{"same rate", []hit{{1000, 1000}, {1000000000, 1000000000}}, 1e9},
+		{"giant slow hit", []hit{{2000, 1000}, {1000000000, 2000000000}}, (2e9 + 0.5e9) / 2},
+		{"hit without bytes", []hit{{1000, 1000}, {0, 5000}}, 1e9},
+		{"no bytes", []hit{{0, 1000}}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+
+			var bytes int64
+			for _, hit := range test.hits {
+				p.StartThroughput("a", hit.bytes)
+				clock.advance(hit.ticks)
+				p.Stop("a")
+				bytes = bytes + hit.bytes
+			}
+
+			var result = resultOf(t, p.Snapshot(), "a")
+			if math.Abs(result.HitThroughput-test.want) > 1e-6*test.want {
+				t.Errorf("hit throughput = %v, want %v", result.HitThroughput, test.want)
+			}
+			if result.Bytes != bytes {
+				t.Errorf("bytes = %d, want %d", result.Bytes, bytes)
+			}
+			if math.IsNaN(result.BytesPerHit) || math.IsInf(result.BytesPerHit, 0) {
+				t.Errorf("bytes per hit = %v", result.BytesPerHit)
+			}
+		})
+	}
+}
+
+func TestBytesPerHit(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.StartThroughput("a", 100)
+	clock.advance(1000)
+	p.Stop("a")
+	p.StartThroughput("a", 300)
+	p.AddBytes("a", 200)
+	clock.advance(1000)
+	p.Stop("a")
+	p.Count("a")
+
+	var result = resultOf(t, p.Snapshot(), "a")
+	if result.BytesPerHit != 300 || result.MinBytes != 100 || result.MaxBytes != 500 {
+		t.Errorf("%v bytes per hit (%d - %d), want 300 (100 - 500)",
+			result.BytesPerHit, result.MinBytes, result.MaxBytes)
+	}
+	if text := output(p); !strings.Contains(text, "payload: 300B avg (100B - 500B)") {
+		t.Errorf("report doesn't show the payload:\n%s", text)
+	}
+
+	p.Reset()
+	p.StartThroughput("a", 50)
+	clock.advance(1000)
+	p.Stop("a")
+	if result = resultOf(t, p.Snapshot(), "a"); result.MinBytes != 50 || result.MaxBytes != 50 {
+		t.Errorf("after Reset: %d - %d bytes, want 50 - 50", result.MinBytes, result.MaxBytes)
+	}
+}
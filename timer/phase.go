@@ -0,0 +1,206 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+)
+
+// phase is a named window of the profile, see BeginPhase.
+type phase struct {
+	name string
+
+	// CPU timer units spent in the phase, and the reading when it was begun
+	// if it is running
+	tscount int64
+	start   int64
+
+	// Hits and own CPU timer units of the anchors during the phase, in the
+	// order they were first seen in it
+	anchors  []*anchor
+	counters map[*anchor]*phaseCounters
+}
+
+type phaseCounters struct {
+	hits    int64
+	tscount int64
+}
+
+func (ph *phase) countersOf(anchor *anchor) *phaseCounters {
+	var counters, exists = ph.counters[anchor]
+	if !exists {
+		counters = &phaseCounters{}
+		ph.counters[anchor] = counters
+		ph.anchors = append(ph.anchors, anchor)
+	}
+
+	return counters
+}
+
+/*
+BeginPhase starts the named phase, a window of the profile such as startup,
+steady state or shutdown, until the matching EndPhase. Output then shows a
+sub-total per phase, followed by the anchors run during it with their share of
+that sub-total, the phase being their 100% baseline. Beginning a phase again
+adds to it. Phases don't nest: beginning a phase ends the running one.
+
+Phases rely on each anchor accumulating its own time as it runs, and are not
+recorded under AccountingInclusive.
+*/
+func (p *Profiler) BeginPhase(name string) {
+	if profilingDisabled() {
+		return
+	}
+
+	var now = readCPUTimer()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.currentPhase != nil {
+		warn("timer: phase %q begun while %q is running, ending it", name, p.currentPhase.name)
+		p.endPhase(now)
+	}
+
+	var begun *phase
+	for _, existing := range p.phases {
+		if existing.name == name {
+			begun = existing
+		}
+	}
+
+	if begun == nil {
+		begun = &phase{name: name, counters: make(map[*anchor]*phaseCounters)}
+		p.phases = append(p.phases, begun)
+	}
+
+	// Time run so far belongs to the previous window
+	p.settleRunning(now)
+	begun.start = now
+	p.currentPhase = begun
+}
+
+// EndPhase ends the named phase begun by BeginPhase. It is ignored with a
+// warning if that phase isn't running.
+func (p *Profiler) EndPhase(name string) {
+	if profilingDisabled() {
+		return
+	}
+
+	var now = readCPUTimer()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.currentPhase == nil || p.currentPhase.name != name {
+		warn("timer: ending phase %q which isn't running", name)
+		return
+	}
+
+	p.endPhase(now)
+}
+
+func (p *Profiler) endPhase(now int64) {
+	p.settleRunning(now)
+	p.currentPhase.tscount = p.currentPhase.tscount + nonNegative(now-p.currentPhase.start)
+	p.currentPhase.start = 0
+	p.currentPhase = nil
+}
+
+// settleRunning accumulates the time run so far by the innermost open anchor,
+// its timing starting over from now.
+func (p *Profiler) settleRunning(now int64) {
+	var running = p.currentTiming
+	if running == nil || p.accounting != AccountingExclusive || running.blockStart != 0 {
+		return
+	}
+
+	if !running.warmup {
+		var tscount = p.repaySampled(running, now-running.start)
+		p.accumulate(running.anchor, tscount, now)
+		running.own = running.own + nonNegative(tscount)
+	}
+	running.start = now
+}
+
+// phaseTSCount returns the time of the phase, up to now if it is running.
+func phaseTSCount(ph *phase) int64 {
+	if ph.start == 0 {
+		return ph.tscount
+	}
+
+	return ph.tscount + nonNegative(readCPUTimer()-ph.start)
+}
+
+// writePhases writes the sub-total of each phase and its anchors.
+func (p *Profiler) writePhases(w io.Writer) {
+	for _, ph := range p.phases {
+		var tscount = phaseTSCount(ph)
+		var note string
+		if ph.start != 0 {
+			note = " [running]"
+		}
+
+		fmt.Fprintf(w, "%*s: %s (%s) -- phase%s\n", p.nameLength(), "["+ph.name+"]",
+			p.formatElapsed(p.milliseconds(tscount)), formatPercent(tscount, p.totalAnchor.tscount), note)
+
+		for _, anchor := range ph.anchors {
+			var counters = ph.counters[anchor]
+			fmt.Fprintf(w, "%*s: %s (%s) -- calls: %d\n", p.nameLength()+2, anchor.name,
+				p.formatElapsed(p.milliseconds(counters.tscount)), formatPercent(counters.tscount, tscount),
+				counters.hits)
+		}
+	}
+}
+
+/*
+PhaseResult holds the sub-total of a phase and the anchors run during it,
+whose Hits, TSCount and Elapsed only count the phase and whose Percent is
+relative to it.
+*/
+type PhaseResult struct {
+	Name    string         `json:"name"`
+	TSCount int64          `json:"tscount_ticks"`
+	Elapsed float64        `json:"elapsed_ms"`
+	Percent float64        `json:"percent"`
+	Anchors []AnchorResult `json:"anchors"`
+}
+
+func (p *Profiler) phaseResults() []PhaseResult {
+	var results []PhaseResult
+	for _, ph := range p.phases {
+		var tscount = phaseTSCount(ph)
+		var result = PhaseResult{Name: ph.name, TSCount: tscount, Elapsed: p.milliseconds(tscount)}
+		if p.totalAnchor.tscount != 0 {
+			result.Percent = 100 * float64(tscount) / float64(p.totalAnchor.tscount)
+		}
+
+		for _, anchor := range ph.anchors {
+			var counters = ph.counters[anchor]
+			var anchorResult = AnchorResult{
+				Name:    anchor.name,
+				Depth:   anchor.depth,
+				Hits:    counters.hits,
+				TSCount: counters.tscount,
+				Elapsed: p.milliseconds(counters.tscount),
+			}
+			if tscount != 0 {
+				anchorResult.Percent = 100 * float64(counters.tscount) / float64(tscount)
+			}
+			result.Anchors = append(result.Anchors, anchorResult)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// BeginPhase starts a named phase of the default profiler.
+func BeginPhase(name string) {
+	defaultProfiler.BeginPhase(name)
+}
+
+// EndPhase ends a named phase of the default profiler.
+func EndPhase(name string) {
+	defaultProfiler.EndPhase(name)
+}
@@ -0,0 +1,57 @@
+package timer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPhases(t *testing.T) {
+	var clock = useFakeClock(t)
+	var captured = captureWarnings(t)
+	var p = New()
+
+	p.BeginPhase("startup")
+	p.Start("load")
+	clock.advance(100)
+	p.Stop("load")
+	p.Start("work")
+	clock.advance(200)
+	// Split across the phases while running, its hit counted when started
+	p.BeginPhase("steady")
+	clock.advance(300)
+	p.Stop("work")
+	p.EndPhase("steady")
+	p.Start("work")
+	clock.advance(400)
+	p.Stop("work")
+
+	var report = p.Snapshot()
+	var want = []PhaseResult{
+		{Name: "startup", TSCount: 300, Elapsed: 0.0003, Percent: 30, Anchors: []AnchorResult{
+			{Name: "load", Hits: 1, TSCount: 100, Elapsed: 0.0001, Percent: 100.0 / 3},
+			{Name: "work", Hits: 1, TSCount: 200, Elapsed: 0.0002, Percent: 200.0 / 3},
+		}},
+		{Name: "steady", TSCount: 300, Elapsed: 0.0003, Percent: 30, Anchors: []AnchorResult{
+			{Name: "work", Hits: 0, TSCount: 300, Elapsed: 0.0003, Percent: 100},
+		}},
+	}
+	if !reflect.DeepEqual(report.Phases, want) {
+		t.Errorf("phases\n%+v\nwant\n%+v", report.Phases, want)
+	}
+	if !strings.Contains(captured.String(), `phase "steady" begun while "startup" is running`) {
+		t.Errorf("warning %q doesn't report the running phase", captured.String())
+	}
+
+	var text = output(p)
+	for _, line := range []string{"[startup]:", "-- phase", " (30.00%) -- phase"} {
+		if !strings.Contains(text, line) {
+			t.Errorf("report doesn't contain %q:\n%s", line, text)
+		}
+	}
+
+	p.EndPhase("steady")
+	if !strings.Contains(captured.String(), `ending phase "steady" which isn't running`) {
+		t.Errorf("warning %q doesn't report the phase not running", captured.String())
+	}
+}
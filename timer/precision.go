@@ -0,0 +1,49 @@
+package timer
+
+import "fmt"
+
+// AutoPrecision makes Output pick the unit of each duration from its
+// magnitude, from nanoseconds to seconds.
+const AutoPrecision = -1
+
+const defaultPrecision = 3
+
+/*
+SetPrecision sets the number of decimals of the milliseconds displayed by
+Output, 3 by default. AutoPrecision displays each duration in the unit that
+suits its magnitude instead, so that an 800ns anchor doesn't show as 0.000ms.
+*/
+func (p *Profiler) SetPrecision(decimals int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if decimals < AutoPrecision {
+		decimals = defaultPrecision
+	}
+
+	p.precision = decimals
+}
+
+// formatElapsed formats a duration in milliseconds for Output, along with
+// its unit.
+func (p *Profiler) formatElapsed(milliseconds float64) string {
+	if p.precision != AutoPrecision {
+		return fmt.Sprintf("%10.*fms", p.precision, milliseconds)
+	}
+
+	switch {
+	case milliseconds != 0 && milliseconds < 0.001:
+		return fmt.Sprintf("%10.3fns", milliseconds*1000*1000)
+	case milliseconds != 0 && milliseconds < 1:
+		return fmt.Sprintf("%10.3fµs", milliseconds*1000)
+	case milliseconds >= 1000:
+		return fmt.Sprintf("%10.3fs ", milliseconds/1000)
+	default:
+		return fmt.Sprintf("%10.3fms", milliseconds)
+	}
+}
+
+// SetPrecision sets the decimals displayed by the default profiler Output.
+func SetPrecision(decimals int) {
+	defaultProfiler.SetPrecision(decimals)
+}
@@ -0,0 +1,48 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatElapsed(t *testing.T) {
+	var tests = []struct {
+		precision    int
+		milliseconds float64
+		want         string
+	}{
+		{defaultPrecision, 0.0008, "     0.001ms"},
+		{defaultPrecision, 1234.5, "  1234.500ms"},
+		{6, 0.0008, "  0.000800ms"},
+		{0, 12.6, "        13ms"},
+		// Invalid, back to the default
+		{-2, 0.0008, "     0.001ms"},
+		{AutoPrecision, 0, "     0.000ms"},
+		{AutoPrecision, 0.0008, "   800.000ns"},
+		{AutoPrecision, 0.25, "   250.000µs"},
+		{AutoPrecision, 12.5, "    12.500ms"},
+		{AutoPrecision, 61000, "    61.000s "},
+	}
+
+	for _, test := range tests {
+		var p = New()
+		p.SetPrecision(test.precision)
+		if got := p.formatElapsed(test.milliseconds); got != test.want {
+			t.Errorf("precision %d: %vms formatted %q, want %q", test.precision, test.milliseconds, got, test.want)
+		}
+	}
+}
+
+func TestOutputPrecision(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.SetPrecision(AutoPrecision)
+
+	p.Start("tiny")
+	clock.advance(800)
+	p.Stop("tiny")
+
+	if text := output(p); !strings.Contains(text, "tiny:    800.000ns") {
+		t.Errorf("an 800ns anchor isn't shown in nanoseconds:\n%s", text)
+	}
+}
@@ -0,0 +1,44 @@
+package timer
+
+/*
+PushPrefix prepends prefix to every anchor name given to the profiler until
+the matching PopPrefix, e.g. "mylib." for a library sharing the default
+profiler with its host. Pushed prefixes concatenate. The maximum name length
+applies to the prefixed name.
+
+The prefix is state of the profiler, not of the calling goroutine: an anchor
+must be stopped under the prefixes it was started with.
+*/
+func (p *Profiler) PushPrefix(prefix string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prefixLengths = append(p.prefixLengths, len(p.prefix))
+	p.prefix = p.prefix + prefix
+}
+
+// PopPrefix removes the prefix pushed last, a warning being emitted if none
+// is left.
+func (p *Profiler) PopPrefix() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.prefixLengths) == 0 {
+		warn("timer: PopPrefix called without a matching PushPrefix")
+		return
+	}
+
+	var last = len(p.prefixLengths) - 1
+	p.prefix = p.prefix[:p.prefixLengths[last]]
+	p.prefixLengths = p.prefixLengths[:last]
+}
+
+// PushPrefix prepends a prefix to the default profiler anchor names.
+func PushPrefix(prefix string) {
+	defaultProfiler.PushPrefix(prefix)
+}
+
+// PopPrefix removes the default profiler prefix pushed last.
+func PopPrefix() {
+	defaultProfiler.PopPrefix()
+}
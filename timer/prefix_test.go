@@ -0,0 +1,59 @@
+package timer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPushPrefix(t *testing.T) {
+	var clock = useFakeClock(t)
+	var captured = captureWarnings(t)
+	var p = New()
+
+	p.Start("main")
+	p.PushPrefix("lib.")
+	p.Start("parse")
+	p.PushPrefix("io.")
+	p.Start("read")
+	clock.advance(10)
+	p.Stop("read")
+	p.PopPrefix()
+	p.Stop("parse")
+	p.PopPrefix()
+	p.Stop("main")
+
+	var names []string
+	for _, result := range p.Snapshot().Anchors {
+		names = append(names, result.Name)
+	}
+	if want := []string{"main", "lib.parse", "lib.io.read"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("anchors %v, want %v", names, want)
+	}
+	if warning := captured.String(); warning != "" {
+		t.Errorf("unexpected warning %q", warning)
+	}
+
+	p.PopPrefix()
+	if warning := captured.String(); !strings.Contains(warning, "without a matching PushPrefix") {
+		t.Errorf("warning %q, want an unmatched PopPrefix", warning)
+	}
+}
+
+func TestPushPrefixNameLength(t *testing.T) {
+	useFakeClock(t)
+	captureWarnings(t)
+	var p = New()
+	p.SetMaxNameLength(8)
+	p.SetNameTooLongPolicy(RejectLongNames)
+
+	p.PushPrefix("mylib.")
+	// Short enough alone, not once prefixed
+	if err := p.StartE("abc"); !errors.Is(err, ErrNameTooLong) {
+		t.Errorf("StartE of mylib.abc = %v, want %v", err, ErrNameTooLong)
+	}
+	if err := p.StartE("ab"); err != nil {
+		t.Errorf("StartE of mylib.ab: %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package timer
+
+/*
+Profile resets the profiler, runs fn and displays the report once it returns.
+If fn panics, the partial report is displayed before the panic carries on,
+showing where time went before the crash.
+*/
+func (p *Profiler) Profile(fn func()) {
+	p.Reset()
+	defer p.Output()
+
+	fn()
+}
+
+// Profile runs fn on a fresh default profiler and displays its report.
+func Profile(fn func()) {
+	defaultProfiler.Profile(fn)
+}
@@ -0,0 +1,47 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	var tests = []struct {
+		name   string
+		panics bool
+	}{
+		{"returning", false},
+		{"panicking", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			p.Start("before")
+			p.Stop("before")
+
+			var recovered interface{}
+			var printed = stdout(t, func() {
+				defer func() { recovered = recover() }()
+				p.Profile(func() {
+					p.Start("work")
+					clock.advance(100)
+					if test.panics {
+						panic("crash")
+					}
+					p.Stop("work")
+				})
+			})
+
+			if (recovered != nil) != test.panics {
+				t.Errorf("recovered %v, want the panic to carry on: %v", recovered, test.panics)
+			}
+			// Reset first, the partial report printed on panic
+			if !strings.Contains(printed, " work:") || strings.Contains(printed, "before") {
+				t.Errorf("report doesn't show work alone:\n%s", printed)
+			}
+		})
+	}
+}
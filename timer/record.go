@@ -0,0 +1,69 @@
+package timer
+
+import "time"
+
+/*
+RecordDuration adds an externally measured duration to the named anchor as a
+single hit, without reading the CPU timer. This allows folding existing
+time.Since based measurements into the report.
+
+A new anchor is registered as a child of the currently open anchor, if any.
+The duration is not subtracted from that parent, whose own time keeps running.
+*/
+func (p *Profiler) RecordDuration(anchorName string, d time.Duration) {
+	p.RecordEvent(anchorName, d, 0)
+}
+
+/*
+RecordEvent is RecordDuration also adding processedBytes to the anchor, for
+externally measured work such as a query time reported by a database. Neither
+the clock nor the open anchors are affected, so it can be interleaved freely
+with Start and Stop.
+*/
+func (p *Profiler) RecordEvent(anchorName string, d time.Duration, processedBytes int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if profilingDisabled() {
+		return
+	}
+
+	Calibrate()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		warnError(&AnchorError{Op: "record", Anchor: anchorName, Err: err})
+		return
+	}
+
+	recorded, err := p.register(key)
+	if err != nil {
+		return
+	}
+
+	recorded.hits = recorded.hits + 1
+	recorded.bytes = recorded.bytes + processedBytes
+	if recorded.firstHit == 0 {
+		recorded.firstHit = readCPUTimer()
+	}
+	recorded.tscount = recorded.tscount + durationToTicks(d)
+	p.externalTSCount = p.externalTSCount + durationToTicks(d)
+	recorded.inclusive = recorded.inclusive + durationToTicks(d)
+	recorded.variation.add(float64(durationToTicks(d)))
+	p.addHit(recorded, durationToTicks(d))
+	recorded.payload.add(processedBytes)
+	recorded.payload.addRate(processedBytes, p.milliseconds(durationToTicks(d))/1000)
+	recorded.elapsed = ticksToMilliseconds(recorded.tscount)
+}
+
+// RecordDuration adds a measured duration to an anchor of the default
+// profiler.
+func RecordDuration(anchorName string, d time.Duration) {
+	defaultProfiler.RecordDuration(anchorName, d)
+}
+
+// RecordEvent adds a measured duration and byte count to an anchor of the
+// default profiler.
+func RecordEvent(anchorName string, d time.Duration, processedBytes int64) {
+	defaultProfiler.RecordEvent(anchorName, d, processedBytes)
+}
@@ -0,0 +1,68 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordEvent(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.Start("handler")
+	clock.advance(1000)
+	p.RecordEvent("query", 3*time.Microsecond, 2048)
+	p.RecordEvent("query", time.Microsecond, 1024)
+	clock.advance(1000)
+	p.Stop("handler")
+
+	var report = p.Snapshot()
+	var query = resultOf(t, report, "query")
+	if query.ParentName != "handler" || query.Hits != 2 || query.TSCount != 4000 || query.Bytes != 3072 {
+		t.Errorf("query %+v, want 2 hits of 4000 units and 3072 bytes under handler", query)
+	}
+	if query.MinHit != 0.001 || query.MaxHit != 0.003 {
+		t.Errorf("query hits from %vms to %vms, want 0.001ms to 0.003ms", query.MinHit, query.MaxHit)
+	}
+
+	// Neither paused nor shortened by the recorded events
+	if handler := resultOf(t, report, "handler"); handler.TSCount != 2000 || handler.Open {
+		t.Errorf("handler %+v, want 2000 units, stopped", handler)
+	}
+	if err := p.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestRecordDuration(t *testing.T) {
+	var tests = []struct {
+		name      string
+		frequency int64
+		want      int64
+	}{
+		{"1GHz", testFrequency, 2500},
+		{"2GHz", 2 * testFrequency, 5000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			freqFn = func() int64 { return test.frequency }
+			var p = New()
+
+			// Measured both ways under the same anchor
+			p.Start("a")
+			clock.advance(test.frequency / 2000000)
+			p.Stop("a")
+			p.RecordDuration("a", 2*time.Microsecond)
+
+			var result = resultOf(t, p.Snapshot(), "a")
+			if result.Hits != 2 || result.TSCount != test.want {
+				t.Errorf("%d hits of %d units, want 2 of %d", result.Hits, result.TSCount, test.want)
+			}
+			if result.Elapsed != 0.0025 {
+				t.Errorf("elapsed %vms, want 0.0025ms", result.Elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package timer
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReferenceDuration(t *testing.T) {
+	var tests = []struct {
+		name      string
+		reference time.Duration
+		percent   float64
+		column    string
+	}{
+		{"unset", 0, 0, ""},
+		{"budget", 200 * time.Millisecond, 12, "of reference: 12.00%"},
+		{"exceeded", 12 * time.Millisecond, 200, "of reference: 200.00%"},
+		{"negative", -time.Second, 0, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			p.SetReferenceDuration(test.reference)
+			p.Start("stage")
+			clock.advance(24000000)
+			p.Stop("stage")
+
+			var result = resultOf(t, p.Snapshot(), "stage")
+			if math.Abs(result.PercentOfReference-test.percent) > 1e-9 {
+				t.Errorf("%v%% of reference, want %v%%", result.PercentOfReference, test.percent)
+			}
+
+			var text = output(p)
+			if test.column == "" && strings.Contains(text, "of reference") {
+				t.Errorf("unexpected reference column:\n%s", text)
+			}
+			if !strings.Contains(text, test.column) {
+				t.Errorf("report doesn't contain %q:\n%s", test.column, text)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+/*
+ReportMeta is the header shared by the formats a report is written in: when
+it was generated, the total time, the CPU timer frequency relating the
+tscount_ticks fields to times, and the number of anchors. The text formats
+render it on a line of their own, the JSON ones as a "meta" object and CSV as
+a leading comment line, see WriteCSV. The folded stacks of WriteFolded leave
+it out, every line of that format being a stack.
+*/
+type ReportMeta struct {
+	Generated    time.Time `json:"generated"`
+	Elapsed      float64   `json:"elapsed_ms"`
+	CPUFrequency int64     `json:"cpu_frequency_hz"`
+	Anchors      int       `json:"anchors"`
+}
+
+// reportMeta returns the header of the current profile, generated now with
+// the CPU timer frequency read for the report.
+func (p *Profiler) reportMeta(frequency int64) ReportMeta {
+	return ReportMeta{
+		Generated:    time.Now(),
+		Elapsed:      p.milliseconds(p.totalAnchor.tscount),
+		CPUFrequency: frequency,
+		Anchors:      p.index,
+	}
+}
+
+// updateMeta recomputes the figures of the header from the report, e.g. once
+// reports are merged, keeping its generation time.
+func (r *Report) updateMeta() {
+	r.Meta.Elapsed = r.Total.Elapsed
+	r.Meta.CPUFrequency = r.CPUFrequency
+	r.Meta.Anchors = len(r.Anchors)
+}
+
+// String formats the header on a single line.
+func (m ReportMeta) String() string {
+	var frequency = "unknown"
+	if m.CPUFrequency != 0 {
+		frequency = fmt.Sprint(m.CPUFrequency, "Hz")
+	}
+
+	// Left out of the reports meant to be compared, see OutputOrdered
+	var generated string
+	if !m.Generated.IsZero() {
+		generated = "generated " + m.Generated.Format(time.RFC3339) + " -- "
+	}
+
+	return fmt.Sprintf("%stotal: %.3fms, anchors: %d, CPU freq: %s", generated, m.Elapsed, m.Anchors,
+		frequency)
+}
+
+// writeReportMeta writes the header line of the text formats.
+func writeReportMeta(w io.Writer, padding int, meta ReportMeta) {
+	fmt.Fprintf(w, "%*s: %s\n", padding, "report", meta)
+}
@@ -0,0 +1,68 @@
+package timer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var metaLine = regexp.MustCompile(`generated (\S+) -- total: 5\.000ms, anchors: 3, CPU freq: 1000000000Hz`)
+
+func TestReportMetaInEveryFormat(t *testing.T) {
+	// The header of the text formats, whose time is only to the second
+	var fromLine = func(output string) (time.Time, error) {
+		var match = metaLine.FindStringSubmatch(output)
+		if match == nil {
+			return time.Time{}, fmt.Errorf("no header line in:\n%s", output)
+		}
+		return time.Parse(time.RFC3339, match[1])
+	}
+	var fromJSON = func(output string) (time.Time, error) {
+		var decoded struct {
+			Meta *ReportMeta `json:"meta"`
+		}
+		if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+			return time.Time{}, err
+		}
+		if decoded.Meta == nil {
+			return time.Time{}, fmt.Errorf("no meta object in:\n%s", output)
+		}
+		var want = ReportMeta{Generated: decoded.Meta.Generated, Elapsed: 5, CPUFrequency: testFrequency, Anchors: 3}
+		if *decoded.Meta != want {
+			return time.Time{}, fmt.Errorf("meta %+v, want %+v", *decoded.Meta, want)
+		}
+		return decoded.Meta.Generated, nil
+	}
+
+	var tests = []struct {
+		format string
+		header func(output string) (time.Time, error)
+	}{
+		{"text", fromLine},
+		{"csv", fromLine},
+		{"markdown", fromLine},
+		{"json", fromJSON},
+		{"json-tree", fromJSON},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			t.Setenv(TIMER_FORMAT_ENV_VAR, test.format)
+			var p = New()
+			recordFormats(p, clock)
+
+			var before = time.Now().Truncate(time.Second)
+			var generated, err = test.header(output(p))
+			var after = time.Now()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if generated.Before(before) || generated.After(after) {
+				t.Errorf("generated %v, want between %v and %v", generated, before, after)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+ReadReport decodes a report written by WriteJSON or WriteBinary, the format
+being detected, e.g. to Merge the profiles emitted by the stages of a
+pipeline.
+*/
+func ReadReport(r io.Reader) (Report, error) {
+	return readProfile(r)
+}
+
+/*
+WriteReport writes report to w in the text layout of Output, e.g. a report
+combined with Merge or Diff. Only the figures a Report holds are shown: the
+total, the throughput and, for every anchor indented under its parent, its
+time, percentage, calls and average.
+*/
+func WriteReport(w io.Writer, report Report) error {
+	var formatter = &Profiler{precision: defaultPrecision}
+	var padding = anchorNameMaxLength
+
+	fmt.Fprintln(w)
+	if report.Name != "" {
+		fmt.Fprintf(w, "%*s: %s\n", padding, "profile", report.Name)
+	}
+	writeReportMeta(w, padding, report.Meta)
+
+	var frequency = "unknown"
+	if report.CPUFrequency != 0 {
+		frequency = fmt.Sprint(report.CPUFrequency)
+	}
+	fmt.Fprintf(w, "%*s: %s (CPU freq: %s) -- calls: %d\n", padding, report.Total.Name,
+		formatter.formatElapsed(report.Total.Elapsed), frequency, report.Hits)
+
+	if report.Bytes > 0 {
+		fmt.Fprintf(w, "%*s: %s at %s (total)\n", padding, "throughput",
+			formatter.byteUnits.megabytes(report.Bytes), formatter.byteUnits.gigabytesPerSecond(report.Throughput))
+	}
+
+	for _, result := range report.Anchors {
+		var average = "n/a"
+		if result.Hits > 0 {
+			average = strings.TrimSpace(formatter.formatElapsed(result.Mean))
+		}
+
+		fmt.Fprintf(w, "%*s: %s (%5.2f%%) -- calls: %d, avg: %s\n", padding+2*int(result.Depth), result.Name,
+			formatter.formatElapsed(result.Elapsed), result.Percent, result.Hits, average)
+	}
+
+	var _, err = fmt.Fprintln(w)
+	return err
+}
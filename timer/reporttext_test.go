@@ -0,0 +1,54 @@
+package timer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeReadReports(t *testing.T) {
+	var clock = useFakeClock(t)
+
+	// Two processes of a sharded workload, writing different formats
+	var files []*bytes.Buffer
+	for _, write := range []func(p *Profiler, w *bytes.Buffer) error{
+		func(p *Profiler, w *bytes.Buffer) error { return p.WriteJSON(w) },
+		func(p *Profiler, w *bytes.Buffer) error { return WriteBinary(w, p.Snapshot()) },
+	} {
+		var p = New()
+		recordFormats(p, clock)
+
+		var file bytes.Buffer
+		if err := write(p, &file); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, &file)
+	}
+
+	var reports []Report
+	for _, file := range files {
+		var report, err = ReadReport(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, report)
+	}
+
+	var merged = Merge(reports...)
+	merged.Meta.Generated = time.Time{}
+
+	var buffer bytes.Buffer
+	if err := WriteReport(&buffer, merged); err != nil {
+		t.Fatal(err)
+	}
+	checkGoldenFile(t, "testdata/merged.txt", buffer.Bytes())
+}
+
+func TestReadReportInvalid(t *testing.T) {
+	for _, data := range []string{"", "not a report", "GTP"} {
+		if _, err := ReadReport(strings.NewReader(data)); err == nil {
+			t.Errorf("ReadReport(%q) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package timer
+
+import "strings"
+
+// resetCounters zeroes the accumulated statistics of the anchor, keeping its
+// place in the hierarchy and its open state.
+func (a *anchor) resetCounters() {
+	a.hits = 0
+	a.counted = 0
+	a.sampleRate = 0
+	a.tscount = 0
+	a.bytes = 0
+	a.ops = 0
+	a.elapsed = 0
+	a.wall = 0
+	a.inclusive = 0
+	a.childInclusive = 0
+	a.allocs = 0
+	a.allocBytes = 0
+	a.maxRecursion = a.open
+	a.series = nil
+	a.firstHit = 0
+	a.variation = variation{}
+	a.hitRange = hitRange{}
+	a.distribution = nil
+	a.payload = payload{}
+	a.samples = 0
+	a.blocked = 0
+	a.overBudget = 0
+	a.goroutines = nil
+}
+
+// orphanOpenAnchors records the anchors still open before the session is
+// discarded, by a reset or the pop of a nested session, so that their Stop
+// calls are ignored instead of landing in the next session. The Stop calls
+// still pending from earlier sessions are kept.
+func (p *Profiler) orphanOpenAnchors(op string) {
+	if p.anchors == nil {
+		// Never reset yet
+		return
+	}
+
+	var open []string
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		if anchor.open == 0 {
+			continue
+		}
+
+		if p.orphans == nil {
+			p.orphans = make(map[string]int64)
+		}
+		p.orphans[anchor.name] = anchor.open
+		open = append(open, anchor.name)
+	}
+
+	if len(open) > 0 {
+		warn("timer: %s with %d anchors still open, dropped: %s", op, len(open), strings.Join(open, ", "))
+	}
+}
+
+func (p *Profiler) resetCounters() {
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		anchor.resetCounters()
+	}
+
+	p.totalAnchor.resetCounters()
+	p.externalTSCount = 0
+	p.maxDepth = p.openDepth
+	for _, ph := range p.phases {
+		ph.tscount = 0
+		ph.anchors = nil
+		ph.counters = make(map[*anchor]*phaseCounters)
+	}
+
+	if p.totalTiming.start == 0 {
+		return
+	}
+
+	// Open anchors and the total start over from now, the time before the
+	// reset having been reported already
+	var now = readCPUTimer()
+	var wallNow = readOSTimer()
+	var allocsNow allocCounters
+	if p.allocStats {
+		allocsNow = readAllocCounters()
+	}
+
+	for open := p.currentTiming; open != nil; open = open.previous {
+		open.start = now
+		open.inclusiveStart = now
+		if open.wallStart != 0 {
+			open.wallStart = wallNow
+		}
+		if open.allocsStart.mallocs != 0 {
+			open.allocsStart = allocsNow
+		}
+	}
+
+	p.totalTiming.start = now
+	if p.currentPhase != nil {
+		p.currentPhase.start = now
+	}
+	if p.gcEnabled {
+		p.gcStart = readGCStats()
+	}
+}
+
+/*
+ResetCounters zeroes the statistics of every anchor while keeping them
+registered, so that the next report keeps the same layout. Anchors still open
+keep running, only their time after the reset being counted.
+*/
+func (p *Profiler) ResetCounters() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.resetCounters()
+}
+
+/*
+ResetAnchor zeroes the statistics of the named anchor only, e.g. between two
+phases of a loop, the other anchors and the total accumulating on, so that its
+time before the reset shows as unaccounted. The anchor keeps its place in the
+hierarchy, its children included, and when open keeps running, only its time
+after the reset being counted. It is a no-op for an unknown anchor.
+*/
+func (p *Profiler) ResetAnchor(anchorName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return
+	}
+
+	var anchor, exists = p.anchorsByName[key]
+	if !exists {
+		return
+	}
+
+	anchor.resetCounters()
+	if anchor.open == 0 {
+		return
+	}
+
+	var now = readCPUTimer()
+	for open := p.currentTiming; open != nil; open = open.previous {
+		if open.anchor != anchor {
+			continue
+		}
+
+		open.start = now
+		open.inclusiveStart = now
+		open.own = 0
+		open.bytes = 0
+		if open.wallStart != 0 {
+			open.wallStart = readOSTimer()
+		}
+		if open.allocsStart.mallocs != 0 {
+			open.allocsStart = readAllocCounters()
+		}
+	}
+}
+
+/*
+OutputAndReset displays the report then resets the counters, as a single
+operation: timings recorded concurrently are either reported or kept for the
+next report, never lost in between.
+*/
+func (p *Profiler) OutputAndReset() {
+	if profilingDisabled() {
+		return
+	}
+
+	var destination, closeDestination = outputDestination()
+	defer closeDestination()
+	var report = reportBuffer{destination: destination}
+
+	p.mu.Lock()
+	p.outputFormat(&report)
+	p.resetCounters()
+	p.mu.Unlock()
+
+	destination.Write(report.Bytes())
+}
+
+// SetAutoReset makes every Output reset the counters afterwards, as
+// OutputAndReset does.
+func (p *Profiler) SetAutoReset(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.autoReset = enabled
+}
+
+// ResetCounters zeroes the statistics of the default profiler anchors.
+func ResetCounters() {
+	defaultProfiler.ResetCounters()
+}
+
+// ResetAnchor zeroes the statistics of an anchor of the default profiler.
+func ResetAnchor(anchorName string) {
+	defaultProfiler.ResetAnchor(anchorName)
+}
+
+// OutputAndReset displays then resets the default profiler counters.
+func OutputAndReset() {
+	defaultProfiler.OutputAndReset()
+}
+
+// SetAutoReset makes Output reset the default profiler counters.
+func SetAutoReset(enabled bool) {
+	defaultProfiler.SetAutoReset(enabled)
+}
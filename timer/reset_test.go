@@ -0,0 +1,208 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResetBetweenStartAndStop(t *testing.T) {
+	var tests = []struct {
+		name string
+		run  func(p *Profiler, clock *fakeClock)
+		// TSCount of the anchors expected in the report after the reset
+		want map[string]int64
+	}{
+		{"single anchor", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(10)
+			p.Reset()
+			clock.advance(10)
+			p.Stop("a")
+		}, map[string]int64{}},
+		{"nested anchors", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			p.Start("b")
+			p.Reset()
+			p.Stop("b")
+			p.Stop("a")
+			p.Start("c")
+			clock.advance(30)
+			p.Stop("c")
+		}, map[string]int64{"c": 30}},
+		{"started again", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			p.Reset()
+			p.Start("a")
+			clock.advance(20)
+			p.Stop("a")
+			// Pending since before the reset
+			p.Stop("a")
+		}, map[string]int64{"a": 20}},
+		{"counters reset", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(10)
+			p.ResetCounters()
+			clock.advance(40)
+			p.Stop("a")
+		}, map[string]int64{"a": 40}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			test.run(p, clock)
+
+			var report = p.Snapshot()
+			if len(report.Anchors) != len(test.want) {
+				t.Fatalf("got %d anchors, want %d", len(report.Anchors), len(test.want))
+			}
+			for name, tscount := range test.want {
+				var result = resultOf(t, report, name)
+				if result.TSCount != tscount {
+					t.Errorf("%s: tscount = %d, want %d", name, result.TSCount, tscount)
+				}
+				if result.Open {
+					t.Errorf("%s: still open", name)
+				}
+			}
+			if report.UnmatchedStops != 0 {
+				t.Errorf("%d unmatched stops, the pending ones should be ignored", report.UnmatchedStops)
+			}
+			if err := p.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestResetAnchor(t *testing.T) {
+	type state struct {
+		parent  string
+		depth   int64
+		hits    int64
+		tscount int64
+	}
+
+	var tests = []struct {
+		name string
+		run  func(p *Profiler, clock *fakeClock)
+		want map[string]state
+	}{
+		{"closed anchor", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(100)
+			p.Stop("a")
+			p.Start("b")
+			clock.advance(200)
+			p.Stop("b")
+			p.ResetAnchor("a")
+		}, map[string]state{"a": {"", 0, 0, 0}, "b": {"", 0, 1, 200}}},
+		{"open anchor", func(p *Profiler, clock *fakeClock) {
+			p.Start("parent")
+			clock.advance(100)
+			p.Start("child")
+			clock.advance(200)
+			p.Stop("child")
+			clock.advance(50)
+			p.ResetAnchor("parent")
+			clock.advance(30)
+			p.Start("other")
+			clock.advance(10)
+			p.Stop("other")
+			clock.advance(20)
+			p.Stop("parent")
+		}, map[string]state{
+			"parent": {"", 0, 0, 50},
+			"child":  {"parent", 1, 1, 200},
+			"other":  {"parent", 1, 1, 10},
+		}},
+		{"open child", func(p *Profiler, clock *fakeClock) {
+			p.Start("parent")
+			clock.advance(100)
+			p.Start("child")
+			clock.advance(200)
+			p.ResetAnchor("child")
+			clock.advance(40)
+			p.Stop("child")
+			clock.advance(10)
+			p.Stop("parent")
+		}, map[string]state{"parent": {"", 0, 1, 110}, "child": {"parent", 1, 0, 40}}},
+		{"unknown anchor", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(100)
+			p.ResetAnchor("b")
+			p.Stop("a")
+		}, map[string]state{"a": {"", 0, 1, 100}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			test.run(p, clock)
+
+			var report = p.Snapshot()
+			if len(report.Anchors) != len(test.want) {
+				t.Fatalf("got %d anchors, want %d", len(report.Anchors), len(test.want))
+			}
+			for name, want := range test.want {
+				var result = resultOf(t, report, name)
+				var got = state{result.ParentName, result.Depth, result.Hits, result.TSCount}
+				if got != want {
+					t.Errorf("%s: got %+v, want %+v", name, got, want)
+				}
+			}
+
+			// The time before the reset is left unaccounted
+			if err := p.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestOutputAndReset(t *testing.T) {
+	var tests = []struct {
+		name   string
+		output func(p *Profiler) string
+	}{
+		{"OutputAndReset", func(p *Profiler) string {
+			return stdout(t, p.OutputAndReset)
+		}},
+		{"auto reset", func(p *Profiler) string {
+			p.SetAutoReset(true)
+			return output(p)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+
+			p.Start("a")
+			clock.advance(100)
+			p.Stop("a")
+			p.Start("b")
+			clock.advance(50)
+
+			if text := test.output(p); !strings.Contains(text, "-- calls: 1, avg") {
+				t.Errorf("the report before the reset lost the hits:\n%s", text)
+			}
+
+			clock.advance(10)
+			p.Stop("b")
+
+			// Kept registered, b counting its time after the reset only, its
+			// hit counted at Start before the reset
+			var report = p.Snapshot()
+			var a, b = resultOf(t, report, "a"), resultOf(t, report, "b")
+			if a.Hits != 0 || a.TSCount != 0 || b.Hits != 0 || b.TSCount != 10 {
+				t.Errorf("after the reset: a %+v, b %+v, want a zeroed and b at 10 units", a, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package timer
+
+import "sync"
+
+// Default multiple of the clock resolution under which anchors are flagged
+const defaultResolutionMultiple = 10
+
+var (
+	clockResolution     int64
+	clockResolutionOnce sync.Once
+)
+
+/*
+readClockResolution returns the smallest duration the CPU timer can tell
+apart, in its units: the smallest step between back to back readings, or the
+cost of a reading if larger, as nothing shorter than a reading can be
+measured. It is measured on first use.
+*/
+func readClockResolution() int64 {
+	clockResolutionOnce.Do(func() {
+		var smallest int64
+		var previous = readCPUTimer()
+		for i := 0; i < timerOverheadIterations; i++ {
+			var current = readCPUTimer()
+			if step := current - previous; step > 0 && (smallest == 0 || step < smallest) {
+				smallest = step
+			}
+			previous = current
+		}
+
+		if overhead := ReadTimerOverhead(); overhead > smallest {
+			smallest = overhead
+		}
+
+		clockResolution = smallest
+	})
+
+	return clockResolution
+}
+
+/*
+SetResolutionWarning flags in Output, with "[below resolution]", the anchors
+whose time per hit is less than multiple times the resolution of the CPU
+timer, whose measurements mostly reflect the clock rather than the measured
+code. The default multiple is 10, zero disables the flag. With a coarse clock,
+such as the OS timer fallback, many more anchors are flagged.
+*/
+func (p *Profiler) SetResolutionWarning(multiple int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if multiple < 0 {
+		multiple = 0
+	}
+
+	p.resolutionMultiple = int64(multiple)
+}
+
+// belowResolution reports whether the time per hit of the anchor is too
+// close to the clock resolution to be trusted.
+func (p *Profiler) belowResolution(anchor *anchor) bool {
+	if p.resolutionMultiple == 0 || anchor.hits == 0 || anchor.tscount == 0 {
+		return false
+	}
+
+	return anchor.tscount/anchor.hits < p.resolutionMultiple*readClockResolution()
+}
+
+// SetResolutionWarning sets the multiple of the clock resolution under which
+// anchors of the default profiler are flagged.
+func SetResolutionWarning(multiple int) {
+	defaultProfiler.SetResolutionWarning(multiple)
+}
@@ -0,0 +1,63 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+// useClockResolution makes the clock resolution ticks for the test.
+func useClockResolution(tb testing.TB, ticks int64) {
+	tb.Helper()
+
+	var measured = readClockResolution()
+	clockResolution = ticks
+	tb.Cleanup(func() { clockResolution = measured })
+}
+
+func TestBelowResolution(t *testing.T) {
+	var tests = []struct {
+		name     string
+		multiple int
+		ticks    int64
+		flagged  bool
+	}{
+		{"far above", 10, 10000, false},
+		{"at the threshold", 10, 1000, false},
+		{"below", 10, 999, true},
+		{"lower multiple", 2, 999, false},
+		{"disabled", 0, 1, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useClockResolution(t, 100)
+			var clock = useFakeClock(t)
+			var p = New()
+			p.SetResolutionWarning(test.multiple)
+
+			for i := 0; i < 2; i++ {
+				p.Start("a")
+				clock.advance(test.ticks)
+				p.Stop("a")
+			}
+
+			if flagged := resultOf(t, p.Snapshot(), "a").BelowResolution; flagged != test.flagged {
+				t.Errorf("below resolution %v, want %v", flagged, test.flagged)
+			}
+			if flagged := strings.Contains(output(p), "[below resolution]"); flagged != test.flagged {
+				t.Errorf("flagged in Output %v, want %v", flagged, test.flagged)
+			}
+		})
+	}
+}
+
+func TestBelowResolutionOnlyCounted(t *testing.T) {
+	useClockResolution(t, 100)
+	useFakeClock(t)
+	var p = New()
+	p.Count("a")
+
+	if resultOf(t, p.Snapshot(), "a").BelowResolution {
+		t.Error("anchor without time flagged below resolution")
+	}
+}
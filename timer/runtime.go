@@ -0,0 +1,33 @@
+package timer
+
+import (
+	"fmt"
+	"runtime"
+)
+
+/*
+RuntimeInfo describes the environment a profile was taken in, so that reports
+coming from different machines can be told apart.
+*/
+type RuntimeInfo struct {
+	GoVersion  string `json:"go_version"`
+	GOOS       string `json:"goos"`
+	GOARCH     string `json:"goarch"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	NumCPU     int    `json:"num_cpu"`
+}
+
+func readRuntimeInfo() RuntimeInfo {
+	return RuntimeInfo{
+		GoVersion:  runtime.Version(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		NumCPU:     runtime.NumCPU(),
+	}
+}
+
+func (info RuntimeInfo) String() string {
+	return fmt.Sprintf("%s %s/%s -- GOMAXPROCS: %d, CPUs: %d", info.GoVersion,
+		info.GOOS, info.GOARCH, info.GOMAXPROCS, info.NumCPU)
+}
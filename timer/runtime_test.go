@@ -0,0 +1,54 @@
+package timer
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRuntimeInfo(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.Start("a")
+	clock.advance(100)
+	p.Stop("a")
+
+	var want = RuntimeInfo{
+		GoVersion:  runtime.Version(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		NumCPU:     runtime.NumCPU(),
+	}
+	var report = p.Snapshot()
+	if report.Runtime != want {
+		t.Errorf("runtime %+v, want %+v", report.Runtime, want)
+	}
+
+	if text := output(p); !strings.Contains(text, "runtime: "+want.String()+"\n") {
+		t.Errorf("report doesn't describe the runtime:\n%s", text)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Runtime RuntimeInfo `json:"runtime"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Runtime != want {
+		t.Errorf("JSON runtime %+v (%v), want %+v", decoded.Runtime, err, want)
+	}
+
+	// Merged reports keep the runtime only when they share it
+	var other = report
+	other.Runtime.GOMAXPROCS = want.GOMAXPROCS + 1
+	if merged := Merge(report, report); merged.Runtime != want {
+		t.Errorf("merged runtime %+v, want %+v", merged.Runtime, want)
+	}
+	if merged := Merge(report, other); merged.Runtime != (RuntimeInfo{}) {
+		t.Errorf("merged runtime %+v from different environments, want none", merged.Runtime)
+	}
+}
@@ -0,0 +1,147 @@
+package timer
+
+/*
+StartSampled is Start only timing one call of the named anchor out of rate:
+the other calls merely count, their Stop being ignored, and each timed call
+is recorded as rate hits lasting rate times its time. The report then
+estimates the numbers of an anchor hit far too often to time every call, and
+Output flags it as "[sampled 1/rate]". Bytes are not scaled, AddBytes adding
+the bytes of every call.
+
+The skipped calls still take the lock, but read no clock, and StopSampled
+avoids the clock read of Stop for them. Their time, which ran in the parent
+anchor, is moved from the parent to the sampled anchor along with the
+estimate. Sampling only applies under the default exclusive accounting; a
+rate below 2 times every call.
+*/
+func (p *Profiler) StartSampled(anchorName string, rate int) {
+	if profilingDisabled() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	warnError(p.startSampled(anchorName, int64(rate)))
+}
+
+// startSampled is StartSampled with the lock held.
+func (p *Profiler) startSampled(anchorName string, rate int64) error {
+	if rate < 2 || p.accounting != AccountingExclusive {
+		return p.start(anchorName, 0)
+	}
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return &AnchorError{Op: "start", Anchor: anchorName, Err: err}
+	}
+
+	if sampled, exists := p.anchorsByName[key]; exists {
+		sampled.sampleCalls = sampled.sampleCalls + 1
+		if sampled.sampleCalls%rate != 1 {
+			// Counted by the timed call, matching Stop ignored
+			sampled.skipped = sampled.skipped + 1
+			return nil
+		}
+	}
+
+	var previous *timing
+	if sampled, exists := p.anchorsByName[key]; exists {
+		previous = sampled.latest
+	}
+
+	if err := p.start(anchorName, 0); err != nil {
+		return err
+	}
+
+	var sampled, exists = p.anchorsByName[key]
+	if !exists || sampled.latest == previous || sampled.latest.warmup {
+		// Disabled, beyond the nesting limit, or a warm-up hit
+		return nil
+	}
+
+	if sampled.sampleCalls == 0 {
+		sampled.sampleCalls = 1
+	}
+	sampled.sampleRate = rate
+	sampled.hits = sampled.hits + rate - 1
+	sampled.latest.sampleRate = rate
+
+	return nil
+}
+
+/*
+StopSampled is Stop for an anchor started with StartSampled, returning at
+once for the calls that were not timed.
+*/
+func (p *Profiler) StopSampled(anchorName string) {
+	if profilingDisabled() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		warnError(&AnchorError{Op: "stop", Anchor: anchorName, Err: err})
+		return
+	}
+
+	if sampled, exists := p.anchorsByName[key]; exists && sampled.skipped > 0 {
+		sampled.skipped = sampled.skipped - 1
+		return
+	}
+
+	warnError(p.countUnmatched(p.stopKey(anchorName, key, readCPUTimer())))
+}
+
+// scaleSampled records a timed hit of tscount CPU timer units of a sampled
+// call as the rate calls it stands for.
+func (p *Profiler) scaleSampled(anchor *anchor, closing *timing, tscount int64) {
+	if closing.sampleRate < 2 {
+		return
+	}
+
+	var extra = tscount * (closing.sampleRate - 1)
+	anchor.tscount = anchor.tscount + extra
+	anchor.elapsed = ticksToMilliseconds(anchor.tscount)
+	p.externalTSCount = p.externalTSCount + extra
+
+	if closing.previous == nil {
+		return
+	}
+
+	// The calls that were not timed run in the parent, which counts them as
+	// its own time once it settles it, mostly after this hit: owed until then
+	closing.previous.sampledDebt = closing.previous.sampledDebt + extra
+}
+
+// repaySampled returns the tscount CPU timer units run by the timing less the
+// time it owes for the sampled calls that were not timed within it.
+func (p *Profiler) repaySampled(running *timing, tscount int64) int64 {
+	if running.sampledDebt == 0 || tscount <= 0 {
+		return tscount
+	}
+
+	var repaid = running.sampledDebt
+	if repaid > tscount {
+		repaid = tscount
+	}
+	running.sampledDebt = running.sampledDebt - repaid
+	p.externalTSCount = p.externalTSCount - repaid
+
+	return tscount - repaid
+}
+
+// StartSampled times one call out of rate of an anchor of the default
+// profiler.
+func StartSampled(anchorName string, rate int) {
+	defaultProfiler.StartSampled(anchorName, rate)
+}
+
+// StopSampled stops an anchor of the default profiler started with
+// StartSampled.
+func StopSampled(anchorName string) {
+	defaultProfiler.StopSampled(anchorName)
+}
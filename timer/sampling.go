@@ -0,0 +1,87 @@
+package timer
+
+import "time"
+
+/*
+StartSampling starts a goroutine that, every interval, adds a sample to the
+anchor running at that time, i.e. the innermost open one, until StopSampling
+or Reset is called. Samples are reported alongside the measured times, giving
+a second, statistical view of where the time goes: a sample counts where the
+profiled code is between its Start and Stop calls, whatever they measure.
+Starting the sampler again replaces the previous one.
+*/
+func (p *Profiler) StartSampling(interval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopSampling()
+	if interval <= 0 {
+		return
+	}
+
+	var done = make(chan struct{})
+	p.samplerDone = done
+
+	go func() {
+		var ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				p.sample(done)
+			}
+		}
+	}()
+}
+
+func (p *Profiler) sample(done chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	select {
+	case <-done:
+		// Stopped while waiting for the lock
+		return
+	default:
+	}
+
+	if p.totalTiming.start == 0 {
+		return
+	}
+
+	p.totalAnchor.samples = p.totalAnchor.samples + 1
+	if p.currentTiming != nil {
+		p.currentTiming.anchor.samples = p.currentTiming.anchor.samples + 1
+	}
+}
+
+// StopSampling stops the sampler started by StartSampling, if any.
+func (p *Profiler) StopSampling() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopSampling()
+}
+
+// stopSampling is StopSampling with the lock held. The goroutine is not
+// waited for, it exits on its next tick at the latest.
+func (p *Profiler) stopSampling() {
+	if p.samplerDone != nil {
+		close(p.samplerDone)
+		p.samplerDone = nil
+	}
+}
+
+// StartSampling samples the running anchor of the default profiler every
+// interval.
+func StartSampling(interval time.Duration) {
+	defaultProfiler.StartSampling(interval)
+}
+
+// StopSampling stops the default profiler sampler.
+func StopSampling() {
+	defaultProfiler.StopSampling()
+}
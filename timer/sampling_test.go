@@ -0,0 +1,116 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSample(t *testing.T) {
+	useFakeClock(t)
+	var p = New()
+	var done = make(chan struct{})
+
+	// Nothing started yet
+	p.sample(done)
+	p.Start("outer")
+	p.sample(done)
+	p.Start("inner")
+	p.sample(done)
+	p.sample(done)
+	p.Stop("inner")
+	p.Stop("outer")
+	p.sample(done)
+	close(done)
+	p.sample(done)
+
+	var report = p.Snapshot()
+	var outer, inner = resultOf(t, report, "outer"), resultOf(t, report, "inner")
+	if report.Total.Samples != 4 || outer.Samples != 1 || inner.Samples != 2 {
+		t.Errorf("samples: total %d, outer %d, inner %d, want 4, 1 and 2", report.Total.Samples, outer.Samples,
+			inner.Samples)
+	}
+	if text := output(p); !strings.Contains(text, "samples: 2 (50.00%)") {
+		t.Errorf("report doesn't show the samples:\n%s", text)
+	}
+}
+
+func TestStartSampling(t *testing.T) {
+	var p = New()
+	p.StartSampling(time.Millisecond)
+	defer p.StopSampling()
+
+	p.Start("sampled")
+	var deadline = time.Now().Add(5 * time.Second)
+	for p.Snapshot().Anchors[0].Samples < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	p.Stop("sampled")
+	p.StopSampling()
+
+	var samples = resultOf(t, p.Snapshot(), "sampled").Samples
+	if samples < 3 {
+		t.Fatalf("%d samples after 5s of 1ms intervals", samples)
+	}
+	p.Start("sampled")
+	time.Sleep(20 * time.Millisecond)
+	p.Stop("sampled")
+	if after := resultOf(t, p.Snapshot(), "sampled").Samples; after != samples {
+		t.Errorf("%d samples taken after StopSampling", after-samples)
+	}
+}
+
+func TestStartSampled(t *testing.T) {
+	var clock = useFakeClock(t)
+	captureWarnings(t)
+	var p = New()
+
+	p.Start("loop")
+	for i := 0; i < 20; i++ {
+		p.StartSampled("hot", 10)
+		clock.advance(100)
+		p.StopSampled("hot")
+		clock.advance(10)
+	}
+	p.Stop("loop")
+
+	var report = p.Snapshot()
+	var hot, loop = resultOf(t, report, "hot"), resultOf(t, report, "loop")
+	if hot.Hits != 20 || hot.TSCount != 2000 || hot.SampleRate != 10 {
+		t.Errorf("hot: %d hits of %d ticks at 1/%d, want 20 hits of 2000 ticks at 1/10", hot.Hits, hot.TSCount,
+			hot.SampleRate)
+	}
+	// The calls that were not timed left the loop's time, even those run after
+	// the timed call
+	if loop.TSCount != 200 || report.Total.TSCount != 2200 {
+		t.Errorf("loop %d and total %d ticks, want 200 and 2200", loop.TSCount, report.Total.TSCount)
+	}
+	if loop.PercentOfParent != 100 || hot.PercentOfParent > 100 {
+		t.Errorf("loop %.2f%% and hot %.2f%% of their parent", loop.PercentOfParent, hot.PercentOfParent)
+	}
+
+	var text = output(p)
+	if !strings.Contains(text, "[sampled 1/10]") || strings.Contains(text, "clamped") {
+		t.Errorf("report doesn't flag the sampling alone:\n%s", text)
+	}
+}
+
+func TestStartSampledSkipsTheClock(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.Start("loop")
+
+	var reads int
+	clockFn = func() int64 {
+		reads = reads + 1
+		return clock.read()
+	}
+	for i := 0; i < 20; i++ {
+		p.StartSampled("hot", 10)
+		p.StopSampled("hot")
+	}
+	// Only the 2 timed calls read the clock, at Start and Stop
+	if reads != 4 {
+		t.Errorf("%d clock reads for 20 calls sampled 1/10, want 4", reads)
+	}
+}
@@ -0,0 +1,66 @@
+package timer
+
+import "errors"
+
+/*
+Scope starts the named anchor and returns a function stopping it, so that the
+name is only written once:
+
+	defer timer.Scope("parse")()
+
+The returned function stops the anchor resolved at Start, whatever prefix is
+pushed by then, and only does so the first time it is called. It is a no-op
+when the anchor could not be started, e.g. with profiling disabled.
+*/
+func (p *Profiler) Scope(anchorName string) func() {
+	return p.ScopeThroughput(anchorName, 0)
+}
+
+// ScopeThroughput is Scope also adding processedBytes to the anchor, as
+// StartThroughput does.
+func (p *Profiler) ScopeThroughput(anchorName string, processedBytes int64) func() {
+	if profilingDisabled() {
+		return func() {}
+	}
+
+	p.mu.Lock()
+	var key, err = p.anchorKey(anchorName)
+	if err == nil {
+		err = p.start(anchorName, processedBytes)
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		warnError(err)
+		if !errors.Is(err, ErrParentMismatch) {
+			return func() {}
+		}
+	}
+
+	var stopped bool
+	return func() {
+		if stopped || profilingDisabled() {
+			return
+		}
+		stopped = true
+
+		var end = readCPUTimer()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		warnError(p.countUnmatched(p.stopKey(anchorName, key, end)))
+	}
+}
+
+// Scope starts an anchor of the default profiler and returns the function
+// stopping it.
+func Scope(anchorName string) func() {
+	return defaultProfiler.Scope(anchorName)
+}
+
+// ScopeThroughput starts an anchor of the default profiler with processed
+// bytes and returns the function stopping it.
+func ScopeThroughput(anchorName string, processedBytes int64) func() {
+	return defaultProfiler.ScopeThroughput(anchorName, processedBytes)
+}
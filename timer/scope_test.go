@@ -0,0 +1,72 @@
+package timer
+
+import "testing"
+
+func TestScope(t *testing.T) {
+	var clock = useFakeClock(t)
+	var captured = captureWarnings(t)
+	var p = New()
+
+	func() {
+		defer p.ScopeThroughput("read", 1024)()
+		clock.advance(100)
+		func() {
+			defer p.Scope("parse")()
+			clock.advance(50)
+		}()
+	}()
+
+	var report = p.Snapshot()
+	if read := resultOf(t, report, "read"); read.Hits != 1 || read.TSCount != 100 || read.Bytes != 1024 || read.Open {
+		t.Errorf("read: %d hits of %d ticks, %d bytes, open %v, want 1 of 100, 1024 and closed",
+			read.Hits, read.TSCount, read.Bytes, read.Open)
+	}
+	if parse := resultOf(t, report, "parse"); parse.TSCount != 50 || parse.ParentName != "read" {
+		t.Errorf("parse: %d ticks under %q, want 50 under read", parse.TSCount, parse.ParentName)
+	}
+
+	// Stops once
+	var stop = p.Scope("write")
+	clock.advance(10)
+	stop()
+	stop()
+	if unmatched := p.Snapshot().UnmatchedStops; unmatched != 0 {
+		t.Errorf("%d unmatched stops after calling the stop function twice", unmatched)
+	}
+	if warning := captured.String(); warning != "" {
+		t.Errorf("unexpected warning %q", warning)
+	}
+}
+
+func TestScopeKeepsTheStartedName(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	var stop = p.Scope("read")
+	p.PushPrefix("lib.")
+	clock.advance(10)
+	stop()
+	p.PopPrefix()
+
+	if result := resultOf(t, p.Snapshot(), "read"); result.Open || result.TSCount != 10 {
+		t.Errorf("read: open %v with %d ticks, want closed with 10", result.Open, result.TSCount)
+	}
+}
+
+func TestScopeDisabled(t *testing.T) {
+	useFakeClock(t)
+	var p = New()
+
+	Disable()
+	t.Cleanup(Enable)
+	var stop = p.Scope("a")
+	Enable()
+	stop()
+
+	if count := p.AnchorCount(); count != 0 {
+		t.Errorf("%d anchors recorded while disabled", count)
+	}
+	if unmatched := p.Snapshot().UnmatchedStops; unmatched != 0 {
+		t.Errorf("%d unmatched stops", unmatched)
+	}
+}
@@ -0,0 +1,57 @@
+package timer
+
+/*
+PushSession saves the current profile and starts a fresh one, for instance to
+isolate the profile of a subsystem. PopSession ends it and restores the saved
+profile. Sessions nest like a stack.
+
+Sessions belong to the profiler, not to a goroutine: they must be pushed and
+popped by the same code path, and anchors opened before the push can't be
+stopped until the matching pop. Time spent in a nested session is counted by
+the anchor that was running when it was pushed, as uninstrumented time.
+*/
+func (p *Profiler) PushSession() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sessions = append(p.sessions, p.session)
+	p.session = newSession()
+	p.allocateTimings()
+}
+
+/*
+PopSession ends the session started by the latest PushSession and returns its
+report, restoring the previous profile. Without a pushed session it returns
+the report of the current profile and resets it.
+*/
+func (p *Profiler) PopSession() Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var report = p.snapshot()
+
+	if len(p.sessions) == 0 {
+		p.orphanOpenAnchors("resetting")
+		p.session = newSession()
+		p.allocateTimings()
+		return report
+	}
+
+	p.orphanOpenAnchors("popping the session")
+	var last = len(p.sessions) - 1
+	p.session = p.sessions[last]
+	p.sessions[last] = session{}
+	p.sessions = p.sessions[:last]
+
+	return report
+}
+
+// PushSession starts a nested session on the default profiler.
+func PushSession() {
+	defaultProfiler.PushSession()
+}
+
+// PopSession ends the nested session of the default profiler.
+func PopSession() Report {
+	return defaultProfiler.PopSession()
+}
@@ -0,0 +1,88 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNestedSessions(t *testing.T) {
+	var clock = useFakeClock(t)
+	var captured = captureWarnings(t)
+	var p = New()
+
+	p.Start("outer")
+	clock.advance(10)
+
+	p.PushSession()
+	p.Start("plugin")
+	clock.advance(100)
+	p.Stop("plugin")
+
+	p.PushSession()
+	p.Start("nested")
+	clock.advance(1000)
+	p.Stop("nested")
+	var nested = p.PopSession()
+
+	var plugin = p.PopSession()
+	clock.advance(10)
+	p.Stop("outer")
+
+	if len(nested.Anchors) != 1 || resultOf(t, nested, "nested").TSCount != 1000 {
+		t.Errorf("nested session %+v, want nested only", nested.Anchors)
+	}
+	if len(plugin.Anchors) != 1 || resultOf(t, plugin, "plugin").TSCount != 100 {
+		t.Errorf("plugin session %+v, want plugin only", plugin.Anchors)
+	}
+
+	// The nested sessions are counted by the anchor running meanwhile
+	var report = p.Snapshot()
+	if len(report.Anchors) != 1 || resultOf(t, report, "outer").TSCount != 1120 {
+		t.Errorf("restored session %+v, want outer at 1120 units", report.Anchors)
+	}
+	if warning := captured.String(); warning != "" {
+		t.Errorf("unexpected warning %q", warning)
+	}
+}
+
+func TestPopSessionWithOpenAnchors(t *testing.T) {
+	var clock = useFakeClock(t)
+	var captured = captureWarnings(t)
+	var p = New()
+
+	p.Start("a")
+	p.PushSession()
+	p.Start("a")
+	clock.advance(100)
+
+	if report := p.PopSession(); !resultOf(t, report, "a").Open {
+		t.Errorf("the popped session doesn't report a open")
+	}
+	if warning := captured.String(); !strings.Contains(warning, "popping the session with 1 anchors still open, dropped: a") {
+		t.Errorf("warning %q doesn't name the dropped anchor", warning)
+	}
+
+	// The Stop of the popped session is ignored, then the outer a stops
+	p.Stop("a")
+	p.Stop("a")
+	var report = p.Snapshot()
+	if result := resultOf(t, report, "a"); result.Open || result.Hits != 1 || report.UnmatchedStops != 0 {
+		t.Errorf("a %+v with %d unmatched stops, want a single stopped hit", result, report.UnmatchedStops)
+	}
+}
+
+func TestPopSessionWithoutPush(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.Start("a")
+	clock.advance(100)
+	p.Stop("a")
+
+	if report := p.PopSession(); resultOf(t, report, "a").TSCount != 100 {
+		t.Errorf("popped report %+v, want a at 100 units", report.Anchors)
+	}
+	if report := p.Snapshot(); len(report.Anchors) != 0 {
+		t.Errorf("%d anchors left, want the profile reset", len(report.Anchors))
+	}
+}
@@ -0,0 +1,56 @@
+package timer
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+/*
+InstallSignalHandler writes the report to w every time the process receives
+sig, e.g. syscall.SIGUSR1, so that a long running process can be profiled on
+demand, in the format selected by TIMER_FORMAT_ENV_VAR. When reset is set,
+counters are reset after each report, as FlushTo does. Reports are formatted
+under the profiler lock and written once it is released, as by OutputTo, so
+profiling can go on concurrently.
+
+The returned function uninstalls the handler.
+*/
+func (p *Profiler) InstallSignalHandler(sig os.Signal, w io.Writer, reset bool) func() {
+	var signals = make(chan os.Signal, 1)
+	var done = make(chan struct{})
+	signal.Notify(signals, sig)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+				p.writeOnSignal(w, reset)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+func (p *Profiler) writeOnSignal(w io.Writer, reset bool) {
+	if reset {
+		p.FlushTo(w)
+	} else {
+		p.OutputTo(w)
+	}
+}
+
+// InstallSignalHandler writes the default profiler report to w on sig.
+func InstallSignalHandler(sig os.Signal, w io.Writer, reset bool) func() {
+	return defaultProfiler.InstallSignalHandler(sig, w, reset)
+}
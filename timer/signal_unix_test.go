@@ -0,0 +1,40 @@
+//go:build linux || darwin
+
+package timer
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInstallSignalHandler(t *testing.T) {
+	for _, reset := range []bool{false, true} {
+		var clock = useFakeClock(t)
+		var p = New()
+		p.Start("a")
+		clock.advance(100)
+		p.Stop("a")
+
+		var written lockedBuffer
+		var uninstall = p.InstallSignalHandler(syscall.SIGUSR1, &written, reset)
+		if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatal(err)
+		}
+
+		var deadline = time.Now().Add(5 * time.Second)
+		for !strings.Contains(written.String(), " a: ") && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		uninstall()
+		uninstall()
+
+		if text := written.String(); !strings.Contains(text, "calls: 1") {
+			t.Fatalf("reset %v: no report written on the signal:\n%s", reset, text)
+		}
+		if hits := resultOf(t, p.Snapshot(), "a").Hits; (hits == 0) != reset {
+			t.Errorf("reset %v: %d hits left after the report", reset, hits)
+		}
+	}
+}
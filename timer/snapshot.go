@@ -0,0 +1,445 @@
+package timer
+
+import "time"
+
+/*
+AnchorResult holds the computed state of an anchor. Elapsed is in
+milliseconds, TSCount in CPU timer units and Percent relative to the total.
+*/
+type AnchorResult struct {
+	Name  string `json:"name"`
+	Depth int64  `json:"depth"`
+	// ParentName is the name of the anchor this one was first started in,
+	// empty for top-level anchors. Names being unique within a report, Name
+	// and ParentName are enough to rebuild the hierarchy.
+	ParentName string `json:"parent_name"`
+	// Category is the label set by SetCategory, empty if none.
+	Category string `json:"category"`
+	// ExcludedFromTotal is set by SetExcludedFromTotal.
+	ExcludedFromTotal bool `json:"excluded_from_total"`
+
+	Hits    int64 `json:"hits"`
+	TSCount int64 `json:"tscount_ticks"`
+	Bytes   int64 `json:"bytes"`
+	// Counted is the part of Hits added by Count, without any timing.
+	Counted int64 `json:"counted"`
+	// SampleRate is the latest rate of StartSampled, Hits and TSCount then
+	// being estimates, zero for an anchor timed on every call.
+	SampleRate int64 `json:"sample_rate"`
+	// BytesPerHit is Bytes divided by the stopped hits, warm-up and Count
+	// hits left out, and MinBytes and MaxBytes the least and most bytes
+	// processed by a single stopped hit.
+	BytesPerHit float64 `json:"bytes_per_hit"`
+	MinBytes    int64   `json:"min_bytes"`
+	MaxBytes    int64   `json:"max_bytes"`
+	// HitThroughput is the mean of the throughputs of the hits, each hit's
+	// bytes over its own time, while Bytes over Elapsed lumps all hits.
+	HitThroughput float64 `json:"hit_throughput_bytes_per_second"`
+	// Ops counts the items added by StartOps and AddOps, and OpsPerSecond
+	// divides it by Elapsed.
+	Ops          int64   `json:"ops"`
+	OpsPerSecond float64 `json:"ops_per_second"`
+
+	Elapsed float64 `json:"elapsed_ms"`
+	Percent float64 `json:"percent"`
+	// PercentOfParent is the time of the anchor including its children
+	// relative to the same time of its parent, or to the total for a
+	// top-level anchor.
+	PercentOfParent float64 `json:"percent_of_parent"`
+	// PercentOfReference is Elapsed relative to the duration set by
+	// SetReferenceDuration, zero if none is set.
+	PercentOfReference float64 `json:"percent_of_reference"`
+
+	// Mean is Elapsed divided by Hits, zero for an anchor not hit. MinHit and
+	// MaxHit are the shortest and longest single hit, in milliseconds, a
+	// recursive call being left out of the hit it was nested in.
+	Mean   float64 `json:"mean_ms"`
+	MinHit float64 `json:"min_hit_ms"`
+	MaxHit float64 `json:"max_hit_ms"`
+
+	// CyclesPerHit is TSCount divided by Hits, independent of the estimated
+	// CPU frequency.
+	CyclesPerHit float64 `json:"cycles_per_hit"`
+
+	// Allocs and AllocBytes count the heap allocations made while the anchor
+	// was open, only set when SetAllocStats is enabled.
+	Allocs     int64 `json:"allocs"`
+	AllocBytes int64 `json:"alloc_bytes"`
+
+	// OverBudget counts the hits longer than the budget set by SetBudget,
+	// Budget being zero when none is set.
+	OverBudget int64         `json:"over_budget"`
+	Budget     time.Duration `json:"budget_ns"`
+
+	// Open is set when the anchor was started but not stopped yet, the time
+	// since its latest Start not being counted.
+	Open bool `json:"open"`
+
+	// BelowResolution is set when the time per hit is too close to the
+	// resolution of the clock to be trusted, see SetResolutionWarning.
+	BelowResolution bool `json:"below_resolution"`
+
+	// StdDev is the standard deviation of the time of each hit, in
+	// milliseconds, and CV its ratio to the mean time per hit. Both are zero
+	// until two hits were stopped.
+	StdDev float64 `json:"std_dev_ms"`
+	CV     float64 `json:"cv"`
+
+	// P50, P90 and P99 are the median, 90th and 99th percentiles of the time
+	// per hit, in milliseconds, only set for anchors tracked by
+	// TrackDistribution.
+	P50 float64 `json:"p50_ms"`
+	P90 float64 `json:"p90_ms"`
+	P99 float64 `json:"p99_ms"`
+
+	// Wall is the OS timer time between the outermost Start and Stop,
+	// children included, only set when SetWallTime is enabled.
+	Wall time.Duration `json:"wall_ns"`
+
+	// Samples is the number of samples taken by StartSampling while the
+	// anchor was running, the total counting every sample.
+	Samples int64 `json:"samples"`
+
+	// Blocked is the time spent between BlockStart and BlockEnd, in
+	// milliseconds, not included in Elapsed.
+	Blocked float64 `json:"blocked_ms"`
+
+	// Goroutines is the number of distinct goroutines that started the
+	// anchor, only set when SetGoroutineStats is enabled.
+	Goroutines int `json:"goroutines"`
+
+	// MaxRecursion is the deepest the anchor was started within itself, 1
+	// for an anchor that was never started recursively.
+	MaxRecursion int64 `json:"max_recursion"`
+}
+
+/*
+AnchorStats is the name under which Results returns the anchor results, an
+alias of AnchorResult.
+*/
+type AnchorStats = AnchorResult
+
+/*
+Report is a copy of a profile at a given time, as returned by Snapshot. It is
+safe to keep while profiling continues. Anchors are in the order they were
+first started.
+*/
+type Report struct {
+	Name         string      `json:"name"`
+	CPUFrequency int64       `json:"cpu_frequency_hz"`
+	Runtime      RuntimeInfo `json:"runtime"`
+	// NormalizedFrequency is the reference frequency times were converted
+	// with, as set by NormalizeTo, zero when converted with CPUFrequency.
+	NormalizedFrequency int64 `json:"normalized_frequency_hz"`
+	// Metadata is the run metadata set by SetMetadata, nil if none.
+	Metadata map[string]string `json:"metadata"`
+	// Meta is the header every format renders, see ReportMeta.
+	Meta ReportMeta `json:"meta"`
+
+	Total   AnchorResult   `json:"total"`
+	Anchors []AnchorResult `json:"anchors"`
+
+	// Hits sums the hits of every anchor.
+	Hits int64 `json:"hits"`
+
+	// Bytes sums the bytes of every anchor, and Throughput divides it by the
+	// total elapsed time, in bytes per second.
+	Bytes      int64   `json:"bytes"`
+	Throughput float64 `json:"throughput_bytes_per_second"`
+
+	// Ops sums the items of every anchor, and OpsPerSecond divides it by the
+	// total elapsed time.
+	Ops          int64   `json:"ops"`
+	OpsPerSecond float64 `json:"ops_per_second"`
+
+	// GCCount and GCPause are the garbage collections since the first Start
+	// and their total pause time, only set when SetGCStats is enabled.
+	GCCount int64         `json:"gc_count"`
+	GCPause time.Duration `json:"gc_pause_ns"`
+
+	// ClockAnomalies counts the durations clamped to zero because the CPU
+	// timer went backwards between two readings.
+	ClockAnomalies int64 `json:"clock_anomalies"`
+
+	// UnmatchedStops counts the Stop calls ignored because the anchor was
+	// unknown or not open, e.g. a misspelled name or a double Stop.
+	UnmatchedStops int64 `json:"unmatched_stops"`
+
+	// Phases holds the sub-totals of the phases begun by BeginPhase.
+	Phases []PhaseResult `json:"phases"`
+
+	// MaxDepth is the largest number of anchors open at once, recursive
+	// calls included.
+	MaxDepth int64 `json:"max_depth"`
+
+	// LimitReached is set when an anchor was dropped because
+	// the limit of distinct anchors, see SetMaxAnchors, was reached.
+	LimitReached bool `json:"limit_reached"`
+}
+
+func (p *Profiler) result(anchor *anchor) AnchorResult {
+	var percent float64
+	if p.totalAnchor.tscount != 0 {
+		percent = 100 * float64(anchor.tscount) / float64(p.totalAnchor.tscount)
+	}
+
+	var cyclesPerHit, mean, bytesPerHit float64
+	if anchor.hits != 0 {
+		cyclesPerHit = float64(anchor.tscount) / float64(anchor.hits)
+		mean = p.milliseconds(anchor.tscount) / float64(anchor.hits)
+	}
+	if anchor.payload.count != 0 {
+		// Warm-up and Count hits carry no bytes
+		bytesPerHit = float64(anchor.bytes) / float64(anchor.payload.count)
+	}
+
+	var parentName string
+	if anchor.parent != nil {
+		parentName = anchor.parent.name
+	}
+
+	var p50, p90, p99 = p.percentiles(anchor)
+
+	return AnchorResult{
+		Name:       anchor.name,
+		Depth:      anchor.depth,
+		ParentName: parentName,
+		Category:   p.categories[anchor.name],
+
+		ExcludedFromTotal: p.excludedFromTotal[anchor.name],
+		Hits:              anchor.hits,
+		Counted:           anchor.counted,
+		TSCount:           anchor.tscount,
+		Bytes:             anchor.bytes,
+		SampleRate:        anchor.sampleRate,
+		Elapsed:           p.milliseconds(anchor.tscount),
+		Percent:           percent,
+
+		BytesPerHit: bytesPerHit,
+		MinBytes:    anchor.payload.min,
+		MaxBytes:    anchor.payload.max,
+
+		HitThroughput: anchor.payload.hitThroughput(),
+
+		Ops:          anchor.ops,
+		OpsPerSecond: opsPerSecond(anchor.ops, p.milliseconds(anchor.tscount)),
+
+		PercentOfParent:    p.percentOfParent(anchor),
+		PercentOfReference: p.percentOfReference(anchor),
+
+		Mean:         mean,
+		MinHit:       p.milliseconds(anchor.hitRange.min),
+		MaxHit:       p.milliseconds(anchor.hitRange.max),
+		CyclesPerHit: cyclesPerHit,
+		MaxRecursion: anchor.maxRecursion,
+		Open:         anchor.open > 0,
+
+		OverBudget: anchor.overBudget,
+		Budget:     p.budgets[anchor.name],
+
+		BelowResolution: p.belowResolution(anchor),
+
+		Allocs:     anchor.allocs,
+		AllocBytes: anchor.allocBytes,
+
+		Wall: time.Duration(mulDiv(anchor.wall, int64(time.Second), getOSTimerFreq())),
+
+		StdDev: p.milliseconds(1) * anchor.variation.stdDev(),
+		CV:     anchor.variation.cv(),
+
+		P50: p50,
+		P90: p90,
+		P99: p99,
+
+		Samples:    anchor.samples,
+		Blocked:    p.milliseconds(anchor.blocked),
+		Goroutines: len(anchor.goroutines),
+	}
+}
+
+// Snapshot returns a copy of the current state of the profile.
+func (p *Profiler) Snapshot() Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.snapshot()
+}
+
+// Report returns the same copy of the profile as Snapshot. It has no
+// package-level counterpart, whose name would clash with the Report type.
+func (p *Profiler) Report() Report {
+	return p.Snapshot()
+}
+
+func (p *Profiler) snapshot() Report {
+	p.sumSubtrees()
+
+	// Read once, background calibration may change it meanwhile
+	var frequency = GetCPUFrequency()
+
+	var snapshot = Report{
+		Name:         p.name,
+		CPUFrequency: frequency,
+		Runtime:      readRuntimeInfo(),
+		Metadata:     p.copyMetadata(),
+		Meta:         p.reportMeta(frequency),
+		Total:        p.result(p.totalAnchor),
+		Anchors:      make([]AnchorResult, 0, p.index),
+		LimitReached: p.limitReached,
+
+		ClockAnomalies: p.clockAnomalies,
+		UnmatchedStops: p.unmatchedStops,
+		MaxDepth:       p.maxDepth,
+
+		NormalizedFrequency: p.normalizedFrequency,
+	}
+
+	snapshot.GCCount, snapshot.GCPause, _ = p.gcDelta()
+
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		snapshot.Anchors = append(snapshot.Anchors, p.result(anchor))
+	}
+
+	snapshot.Hits = p.totalHits()
+	snapshot.Bytes, snapshot.Throughput = p.totalThroughput()
+	snapshot.Ops = p.totalOps()
+	snapshot.OpsPerSecond = opsPerSecond(snapshot.Ops, snapshot.Total.Elapsed)
+	snapshot.Phases = p.phaseResults()
+
+	return snapshot
+}
+
+/*
+ResultsInto appends the results of the anchors to dst[:0] and returns the
+resulting slice, in the order of Snapshot.Anchors, leaving out the anchors
+under SetMinHits. Reusing the returned slice across calls avoids any
+allocation once it is large enough.
+*/
+func (p *Profiler) ResultsInto(dst []AnchorResult) []AnchorResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sumSubtrees()
+
+	dst = dst[:0]
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		if !p.belowMinHits(anchor) {
+			dst = append(dst, p.result(anchor))
+		}
+	}
+
+	return dst
+}
+
+/*
+Results returns the results of every anchor, in the order they were first
+started, for assertions or dashboards that would otherwise scrape the text of
+Output, which formats the same data. It is ResultsInto with a new slice, and
+leaves out the same anchors; use Snapshot for the total and the rest of the
+report.
+*/
+func (p *Profiler) Results() []AnchorStats {
+	return p.ResultsInto(nil)
+}
+
+/*
+AnchorCount returns the number of distinct anchors registered. The profiler
+holds up to maxHandledAnchors of them, about a million, unless SetMaxAnchors
+sets another limit.
+*/
+func (p *Profiler) AnchorCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.index
+}
+
+/*
+IsActive reports whether the named anchor is open, i.e. started and not
+stopped yet, whether or not it is paused by a child. The name goes through the
+same prefixing and truncation as in Start. It is false for an unknown anchor.
+*/
+func (p *Profiler) IsActive(anchorName string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return false
+	}
+
+	var anchor, exists = p.anchorsByName[key]
+	return exists && anchor.open > 0
+}
+
+/*
+GetTSCount returns the CPU timer units accumulated by the named anchor, its
+raw count free of any frequency estimation error, and false if the anchor is
+unknown. Convert it with GetCPUFrequency.
+*/
+func (p *Profiler) GetTSCount(anchorName string) (int64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return 0, false
+	}
+
+	var anchor, exists = p.anchorsByName[key]
+	if !exists {
+		return 0, false
+	}
+
+	return anchor.tscount, true
+}
+
+/*
+GetElapsed returns the time of the named anchor, children excluded, converted
+from its CPU timer units with the estimated frequency, and false if the anchor
+was never started. The name goes through the same prefixing and truncation as
+in Stop.
+*/
+func (p *Profiler) GetElapsed(anchorName string) (time.Duration, bool) {
+	var tscount, exists = p.GetTSCount(anchorName)
+	if !exists {
+		return 0, false
+	}
+
+	return ticksToDuration(tscount), true
+}
+
+// Snapshot returns a copy of the current state of the default profiler.
+func Snapshot() Report {
+	return defaultProfiler.Snapshot()
+}
+
+// Results returns the anchor results of the default profiler.
+func Results() []AnchorStats {
+	return defaultProfiler.Results()
+}
+
+// ResultsInto fills dst with the anchor results of the default profiler.
+func ResultsInto(dst []AnchorResult) []AnchorResult {
+	return defaultProfiler.ResultsInto(dst)
+}
+
+// AnchorCount returns the number of anchors of the default profiler.
+func AnchorCount() int {
+	return defaultProfiler.AnchorCount()
+}
+
+// GetTSCount returns the CPU timer units of an anchor of the default profiler.
+func GetTSCount(anchorName string) (int64, bool) {
+	return defaultProfiler.GetTSCount(anchorName)
+}
+
+// GetElapsed returns the time of an anchor of the default profiler.
+func GetElapsed(anchorName string) (time.Duration, bool) {
+	return defaultProfiler.GetElapsed(anchorName)
+}
+
+// IsActive reports whether the named anchor of the default profiler is open.
+func IsActive(anchorName string) bool {
+	return defaultProfiler.IsActive(anchorName)
+}
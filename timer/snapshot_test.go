@@ -0,0 +1,229 @@
+package timer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetElapsed(t *testing.T) {
+	var tests = []struct {
+		name    string
+		started string
+		lookup  string
+		want    time.Duration
+		exists  bool
+	}{
+		{"never started", "a", "b", 0, false},
+		{"started", "a", "a", 2500 * time.Microsecond, true},
+		// Truncated the same way as in Start and Stop
+		{"long name", "a_rather_long_anchor_name", "a_rather_long_anchor_name", 2500 * time.Microsecond, true},
+		{"long name by its truncation", "a_rather_long_anchor_name", "a_rather_long_anch", 2500 * time.Microsecond,
+			true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+
+			p.Start(test.started)
+			clock.advance(2500000)
+			p.Stop(test.started)
+
+			var elapsed, exists = p.GetElapsed(test.lookup)
+			if elapsed != test.want || exists != test.exists {
+				t.Errorf("GetElapsed(%q) = %v, %v, want %v, %v", test.lookup, elapsed, exists, test.want, test.exists)
+			}
+		})
+	}
+}
+
+func TestGetElapsedExcludesChildren(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.PushPrefix("lib.")
+	p.Start("parent")
+	clock.advance(1000)
+	p.Start("child")
+	clock.advance(3000)
+	p.Stop("child")
+	p.Stop("parent")
+
+	if elapsed, _ := p.GetElapsed("parent"); elapsed != time.Microsecond {
+		t.Errorf("parent elapsed %v, want its own 1µs", elapsed)
+	}
+	p.PopPrefix()
+
+	// Outside of the prefix, only the full name is known
+	if _, exists := p.GetElapsed("parent"); exists {
+		t.Error("GetElapsed found parent without its prefix")
+	}
+	if elapsed, _ := p.GetElapsed("lib.child"); elapsed != 3*time.Microsecond {
+		t.Errorf("lib.child elapsed %v, want 3µs", elapsed)
+	}
+}
+
+func TestAnchorCount(t *testing.T) {
+	var clock = useFakeClock(t)
+	captureWarnings(t)
+	var p = New()
+	p.SetMaxAnchors(2)
+
+	for _, name := range []string{"a", "b", "a", "c"} {
+		p.Start(name)
+		clock.advance(10)
+		p.Stop(name)
+	}
+	if count, limit := p.AnchorCount(), p.Snapshot().LimitReached; count != 2 || !limit {
+		t.Errorf("%d anchors, limit reached %v, want 2 and reached", count, limit)
+	}
+
+	p.Reset()
+	if count, limit := p.AnchorCount(), p.Snapshot().LimitReached; count != 0 || limit {
+		t.Errorf("after Reset: %d anchors, limit reached %v, want none", count, limit)
+	}
+}
+
+func TestGetTSCount(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	if _, ok := p.GetTSCount("a"); ok {
+		t.Error("unknown anchor found")
+	}
+	p.Start("a")
+	clock.advance(100)
+	p.Start("b")
+	clock.advance(50)
+	p.Stop("b")
+	p.Stop("a")
+
+	// Children excluded, in CPU timer units
+	if tscount, ok := p.GetTSCount("a"); !ok || tscount != 100 {
+		t.Errorf("a: %d ticks, found %v, want 100", tscount, ok)
+	}
+	if frequency := GetCPUFrequency(); frequency != testFrequency {
+		t.Errorf("frequency %d, want %d", frequency, int64(testFrequency))
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	useFakeClock(t)
+	captureWarnings(t)
+	var p = New()
+	var long = strings.Repeat("x", anchorNameMaxLength+4)
+
+	if p.IsActive("a") {
+		t.Error("unknown anchor active")
+	}
+	p.Start("a")
+	p.Start("b")
+	p.Start(long)
+	if !p.IsActive("a") || !p.IsActive("b") {
+		t.Error("open anchors not active, paused parent included")
+	}
+	if !p.IsActive(long) {
+		t.Error("truncated anchor not active under its full name")
+	}
+
+	p.Stop(long)
+	p.Stop("b")
+	if p.IsActive("b") || !p.IsActive("a") {
+		t.Errorf("active a %v, b %v after stopping b, want true and false", p.IsActive("a"), p.IsActive("b"))
+	}
+	p.Stop("a")
+	if p.IsActive("a") {
+		t.Error("stopped anchor active")
+	}
+}
+
+func TestResults(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	if results := p.Results(); len(results) != 0 {
+		t.Errorf("%d results before any Start", len(results))
+	}
+
+	p.StartThroughput("read", 1024)
+	clock.advance(3000000)
+	p.Stop("read")
+	p.Start("parse")
+	clock.advance(1000000)
+	p.Stop("parse")
+
+	var results = p.Results()
+	if len(results) != 2 {
+		t.Fatalf("%d results, want 2", len(results))
+	}
+	var read, parse = results[0], results[1]
+	if read.Name != "read" || read.Hits != 1 || read.Elapsed != 3 || read.Percent != 75 || read.Bytes != 1024 {
+		t.Errorf("read: %+v", read)
+	}
+	if parse.Name != "parse" || parse.Hits != 1 || parse.Elapsed != 1 || parse.Percent != 25 || parse.Bytes != 0 {
+		t.Errorf("parse: %+v", parse)
+	}
+	if anchors := p.Snapshot().Anchors; !reflect.DeepEqual(results, anchors) {
+		t.Errorf("Results %+v, Snapshot anchors %+v", results, anchors)
+	}
+}
+
+func TestResultsInto(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	for _, name := range []string{"a", "b", "c"} {
+		p.Start(name)
+		clock.advance(10)
+		p.Stop(name)
+	}
+
+	var results = p.ResultsInto(make([]AnchorResult, 5))
+	if len(results) != 3 || results[0].Name != "a" || results[2].Name != "c" {
+		t.Fatalf("results %+v, want a, b and c", results)
+	}
+	if !reflect.DeepEqual(results, p.Results()) {
+		t.Errorf("ResultsInto %+v, Results %+v", results, p.Results())
+	}
+
+	// Reusing the buffer
+	if allocs := testing.AllocsPerRun(100, func() {
+		results = p.ResultsInto(results)
+	}); allocs != 0 {
+		t.Errorf("%v allocations per ResultsInto on a large enough buffer", allocs)
+	}
+}
+
+func TestMean(t *testing.T) {
+	const ms = testFrequency / 1000
+
+	var clock = useFakeClock(t)
+	captureWarnings(t)
+	var p = New()
+	for _, hit := range []int64{1, 2, 6} {
+		p.Start("a")
+		clock.advance(hit * ms)
+		p.Stop("a")
+	}
+	p.Start("b")
+	p.ResetAnchor("b")
+
+	var report = p.Snapshot()
+	if a, b := resultOf(t, report, "a"), resultOf(t, report, "b"); a.Mean != 3 || b.Mean != 0 {
+		t.Errorf("means %vms and %vms, want 3ms and none", a.Mean, b.Mean)
+	}
+	var text = output(p)
+	if !strings.Contains(text, "-- calls: 3, avg: 3.000ms") || !strings.Contains(text, "-- calls: 0, avg: n/a") {
+		t.Errorf("report doesn't show the means:\n%s", text)
+	}
+
+	// Recomputed from the merged hits
+	var other = report
+	other.Anchors = []AnchorResult{{Name: "a", Hits: 1, Elapsed: 1, Mean: 1}}
+	if merged := Merge(report, other); resultOf(t, merged, "a").Mean != 2.5 {
+		t.Errorf("merged mean %vms, want 2.5ms", resultOf(t, merged, "a").Mean)
+	}
+}
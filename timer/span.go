@@ -0,0 +1,115 @@
+package timer
+
+// recordedSpan is a span of RecordSpan that may still enclose later spans.
+type recordedSpan struct {
+	anchor *anchor
+	start  int64
+	end    int64
+}
+
+/*
+RecordSpan adds a hit spanning from startTS to endTS, in CPU timer units, to
+the named anchor without reading the clock, e.g. to rebuild a profile from the
+timestamps of a trace log, or to feed deterministic inputs to the aggregation.
+
+Spans recorded in nested order, each span before the spans it encloses, form a
+hierarchy: a span within the previous spans still enclosing it is registered
+as a child of the innermost one, whose own time it is subtracted from, as if
+the anchors had been started and stopped at those times. Other spans are
+registered under the currently open anchor, if any, like RecordDuration. Until
+the first Start, the total covers the recorded spans.
+*/
+func (p *Profiler) RecordSpan(anchorName string, startTS int64, endTS int64) {
+	if profilingDisabled() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	Calibrate()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		warnError(&AnchorError{Op: "record", Anchor: anchorName, Err: err})
+		return
+	}
+
+	var tscount = endTS - startTS
+	if tscount < 0 {
+		p.clockAnomalies = p.clockAnomalies + 1
+		tscount = 0
+		endTS = startTS
+	}
+
+	// Drop the spans ended before this one starts
+	for len(p.spans) > 0 {
+		var top = p.spans[len(p.spans)-1]
+		if startTS >= top.start && endTS <= top.end {
+			break
+		}
+		p.spans = p.spans[:len(p.spans)-1]
+	}
+
+	var parent = p.currentAnchor
+	if len(p.spans) > 0 {
+		parent = p.spans[len(p.spans)-1].anchor
+	}
+
+	// Registered under the enclosing span rather than the open anchor
+	var current = p.currentAnchor
+	p.currentAnchor = parent
+	recorded, err := p.register(key)
+	p.currentAnchor = current
+	if err != nil {
+		return
+	}
+
+	recorded.hits = recorded.hits + 1
+	if recorded.firstHit == 0 {
+		recorded.firstHit = startTS
+	}
+	recorded.variation.add(float64(tscount))
+	p.addHit(recorded, tscount)
+	recorded.inclusive = recorded.inclusive + tscount
+	p.externalTSCount = p.externalTSCount + tscount
+
+	var enclosing *anchor
+	if len(p.spans) > 0 {
+		enclosing = parent
+	}
+
+	if p.accounting == AccountingInclusive {
+		if enclosing != nil {
+			enclosing.childInclusive = enclosing.childInclusive + tscount
+			p.settleOwn(enclosing)
+		}
+		p.settleOwn(recorded)
+	} else {
+		recorded.tscount = recorded.tscount + tscount
+		if enclosing != nil {
+			enclosing.tscount = enclosing.tscount - tscount
+			enclosing.elapsed = ticksToMilliseconds(enclosing.tscount)
+		}
+		recorded.elapsed = ticksToMilliseconds(recorded.tscount)
+	}
+
+	p.spans = append(p.spans, recordedSpan{anchor: recorded, start: startTS, end: endTS})
+
+	if p.totalTiming.start == 0 {
+		if p.spanStart == 0 && p.spanEnd == 0 || startTS < p.spanStart {
+			p.spanStart = startTS
+		}
+		if endTS > p.spanEnd {
+			p.spanEnd = endTS
+		}
+		p.totalAnchor.tscount = p.spanEnd - p.spanStart
+		p.totalAnchor.elapsed = ticksToMilliseconds(p.totalAnchor.tscount)
+	}
+}
+
+// RecordSpan adds a hit with the given CPU timer span to an anchor of the
+// default profiler.
+func RecordSpan(anchorName string, startTS int64, endTS int64) {
+	defaultProfiler.RecordSpan(anchorName, startTS, endTS)
+}
@@ -0,0 +1,103 @@
+package timer
+
+import "testing"
+
+// spanResult is the expected result of an anchor built by RecordSpan.
+type spanResult struct {
+	name    string
+	parent  string
+	hits    int64
+	tscount int64
+}
+
+func TestRecordSpan(t *testing.T) {
+	type span struct {
+		name       string
+		start, end int64
+	}
+
+	var tests = []struct {
+		name       string
+		accounting AccountingMode
+		spans      []span
+		want       []spanResult
+		total      int64
+	}{
+		{"nested", AccountingExclusive,
+			[]span{{"request", 0, 1000}, {"query", 100, 400}, {"row", 200, 300}},
+			[]spanResult{{"request", "", 1, 700}, {"query", "request", 1, 200}, {"row", "query", 1, 100}}, 1000},
+		{"siblings", AccountingExclusive,
+			[]span{{"request", 0, 1000}, {"query", 100, 400}, {"render", 500, 900}},
+			[]spanResult{{"request", "", 1, 300}, {"query", "request", 1, 300}, {"render", "request", 1, 400}},
+			1000},
+		{"sequential", AccountingExclusive,
+			[]span{{"a", 0, 100}, {"b", 100, 300}, {"a", 300, 400}},
+			[]spanResult{{"a", "", 2, 200}, {"b", "", 1, 200}}, 400},
+		{"nested inclusive", AccountingInclusive,
+			[]span{{"request", 0, 1000}, {"query", 100, 400}, {"row", 200, 300}},
+			[]spanResult{{"request", "", 1, 700}, {"query", "request", 1, 200}, {"row", "query", 1, 100}}, 1000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useFakeClock(t)
+			var p = New()
+			p.SetAccountingMode(test.accounting)
+
+			for _, span := range test.spans {
+				p.RecordSpan(span.name, span.start, span.end)
+			}
+
+			var report = p.Snapshot()
+			if len(report.Anchors) != len(test.want) {
+				t.Fatalf("%d anchors, want %d", len(report.Anchors), len(test.want))
+			}
+			for _, want := range test.want {
+				var result = resultOf(t, report, want.name)
+				var got = spanResult{result.Name, result.ParentName, result.Hits, result.TSCount}
+				if got != want {
+					t.Errorf("got %+v, want %+v", got, want)
+				}
+			}
+			if report.Total.TSCount != test.total {
+				t.Errorf("total tscount = %d, want %d", report.Total.TSCount, test.total)
+			}
+			if err := p.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestRecordSpanUnderOpenAnchor(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	p.Start("replay")
+	clock.advance(1000)
+	p.RecordSpan("event", 5000, 5300)
+	p.Stop("replay")
+
+	var event = resultOf(t, p.Snapshot(), "event")
+	if event.ParentName != "replay" || event.TSCount != 300 {
+		t.Errorf("event %+v, want 300 units under replay", event)
+	}
+	if err := p.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestRecordSpanBackwards(t *testing.T) {
+	useFakeClock(t)
+	var p = New()
+
+	p.RecordSpan("a", 500, 100)
+
+	var report = p.Snapshot()
+	if result := resultOf(t, report, "a"); result.Hits != 1 || result.TSCount != 0 {
+		t.Errorf("a: %d hits, tscount %d, want a hit of 0", result.Hits, result.TSCount)
+	}
+	if report.ClockAnomalies != 1 {
+		t.Errorf("%d clock anomalies, want 1", report.ClockAnomalies)
+	}
+}
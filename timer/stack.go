@@ -0,0 +1,81 @@
+package timer
+
+import "sync"
+
+// Timings not preallocated by SetTimingStackCapacity, given back at Stop
+var timingPool = sync.Pool{
+	New: func() interface{} {
+		return &timing{}
+	},
+}
+
+/*
+SetTimingStackCapacity preallocates capacity timings, the per Start records of
+the open anchors, so that nesting up to that depth doesn't allocate. Beyond
+it, and by default, timings come from a sync.Pool shared by every profiler,
+which saves most allocations of a hot anchor but may still allocate after a
+garbage collection emptied it. Zero, the default, disables the preallocation.
+
+The timings are preallocated again on every Reset, PushSession and
+PopSession.
+*/
+func (p *Profiler) SetTimingStackCapacity(capacity int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if capacity < 0 {
+		capacity = 0
+	}
+
+	p.timingStackCapacity = capacity
+	p.allocateTimings()
+}
+
+// allocateTimings fills the stack of the current session with new timings,
+// those still open keeping their own.
+func (p *Profiler) allocateTimings() {
+	if p.timingStackCapacity == 0 {
+		p.freeTimings = nil
+		return
+	}
+
+	var timings = make([]timing, p.timingStackCapacity)
+	p.freeTimings = make([]*timing, p.timingStackCapacity)
+	for i := range timings {
+		timings[i].pooled = true
+		p.freeTimings[i] = &timings[i]
+	}
+}
+
+func (p *Profiler) newTiming() *timing {
+	if count := len(p.freeTimings); count > 0 {
+		var free = p.freeTimings[count-1]
+		p.freeTimings = p.freeTimings[:count-1]
+		return free
+	}
+
+	return timingPool.Get().(*timing)
+}
+
+// releaseTiming gives a stopped timing back, cleared so that a stale previous
+// chain or anchor can't leak into its next use.
+func (p *Profiler) releaseTiming(released *timing) {
+	if !released.pooled {
+		*released = timing{}
+		timingPool.Put(released)
+		return
+	}
+
+	if len(p.freeTimings) == cap(p.freeTimings) {
+		// Preallocated for a previous session
+		return
+	}
+
+	*released = timing{pooled: true}
+	p.freeTimings = append(p.freeTimings, released)
+}
+
+// SetTimingStackCapacity preallocates the timings of the default profiler.
+func SetTimingStackCapacity(capacity int) {
+	defaultProfiler.SetTimingStackCapacity(capacity)
+}
@@ -0,0 +1,116 @@
+package timer
+
+import (
+	"strconv"
+	"testing"
+)
+
+// nestedNames returns the names of depth anchors nested in one another.
+func nestedNames(depth int) []string {
+	var names = make([]string, depth)
+	for i := range names {
+		names[i] = "level" + strconv.Itoa(i)
+	}
+
+	return names
+}
+
+// startStopNested starts the anchors in order then stops them.
+func startStopNested(p *Profiler, names []string) {
+	for _, name := range names {
+		p.Start(name)
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		p.Stop(names[i])
+	}
+}
+
+func TestTimingStackDoesNotAllocate(t *testing.T) {
+	var tests = []struct {
+		name     string
+		capacity int
+		depth    int
+	}{
+		{"single anchor", 1, 1},
+		{"nested anchors", 8, 8},
+		{"spare capacity", 64, 8},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useFakeClock(t)
+			var p = New()
+			p.SetTimingStackCapacity(test.capacity)
+
+			var names = nestedNames(test.depth)
+			// Registers the anchors
+			startStopNested(p, names)
+
+			var allocs = testing.AllocsPerRun(1000, func() {
+				startStopNested(p, names)
+			})
+			if allocs != 0 {
+				t.Errorf("%v allocations per nested Start and Stop, want none", allocs)
+			}
+		})
+	}
+}
+
+func BenchmarkTimingStack(b *testing.B) {
+	var benchmarks = []struct {
+		name     string
+		capacity int
+	}{
+		{"default", 0},
+		{"preallocated", 8},
+	}
+
+	for _, benchmark := range benchmarks {
+		b.Run(benchmark.name, func(b *testing.B) {
+			var p = New()
+			p.SetTimingStackCapacity(benchmark.capacity)
+			var names = nestedNames(8)
+			startStopNested(p, names)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				startStopNested(p, names)
+			}
+		})
+	}
+}
+
+func BenchmarkTimingPool(b *testing.B) {
+	var benchmarks = []struct {
+		name string
+		run  func(p *Profiler)
+	}{
+		{"hot anchor", func(p *Profiler) {
+			p.Start("hot")
+			p.Stop("hot")
+		}},
+		{"recursive anchor", func(p *Profiler) {
+			for i := 0; i < 4; i++ {
+				p.Start("recursive")
+			}
+			for i := 0; i < 4; i++ {
+				p.Stop("recursive")
+			}
+		}},
+	}
+
+	for _, benchmark := range benchmarks {
+		b.Run(benchmark.name, func(b *testing.B) {
+			// Without a preallocated stack, every timing comes from the pool
+			var p = New()
+			benchmark.run(p)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				benchmark.run(p)
+			}
+		})
+	}
+}
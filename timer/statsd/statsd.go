@@ -0,0 +1,193 @@
+/*
+Package statsd periodically sends the anchors of a timer.Profiler to a StatsD
+or DogStatsD server.
+
+Every flush sends, for each anchor that was hit since the previous flush, its
+mean time per hit as a timing metric, sampled at one over the hits so that
+the server counts every hit without reading the sum as one slow call, and its
+hits and bytes as counters:
+
+	prefix.parent.child:3.125|ms|@0.25
+	prefix.parent.child.hits:4|c
+	prefix.parent.child.bytes:2048|c
+
+Nested anchors are named after the anchors they were first started in, joined
+with dots.
+*/
+package statsd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+// maxPacketSize keeps the datagrams below the usual Ethernet MTU.
+const maxPacketSize = 1432
+
+/*
+Client sends the metrics of a profiler over UDP. Prefix is prepended to every
+metric name and Tags, when set, are appended in the DogStatsD format.
+*/
+type Client struct {
+	Prefix string
+	Tags   []string
+
+	mu       sync.Mutex
+	conn     net.Conn
+	previous map[string]timer.AnchorResult
+}
+
+// Dial returns a Client sending metrics to the given host:port address.
+func Dial(address string) (*Client, error) {
+	var conn, err = net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{conn: conn, previous: make(map[string]timer.AnchorResult)}, nil
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+/*
+Flush sends the metrics accumulated by the profiler since the previous flush.
+Anchors that were not hit in between are not sent. A reset of the profiler is
+detected by the counters going down, the new values being sent as is.
+*/
+func (c *Client) Flush(p *timer.Profiler) error {
+	var report = p.Snapshot()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var lines []string
+	var paths = make(map[string]string, len(report.Anchors))
+	for _, result := range report.Anchors {
+		var path = sanitize(result.Name)
+		if parent, exists := paths[result.ParentName]; exists && result.ParentName != "" {
+			path = parent + "." + path
+		}
+		paths[result.Name] = path
+
+		var delta = result
+		if previous, exists := c.previous[result.Name]; exists && previous.Hits <= result.Hits {
+			delta.Hits = result.Hits - previous.Hits
+			delta.Bytes = result.Bytes - previous.Bytes
+			delta.Elapsed = result.Elapsed - previous.Elapsed
+		}
+		c.previous[result.Name] = result
+
+		if delta.Hits == 0 {
+			continue
+		}
+
+		var mean = delta.Elapsed / float64(delta.Hits)
+		lines = append(lines, c.line(path, fmt.Sprintf("%.3f", mean), "ms", delta.Hits))
+		lines = append(lines, c.line(path+".hits", fmt.Sprint(delta.Hits), "c", 1))
+		if delta.Bytes > 0 {
+			lines = append(lines, c.line(path+".bytes", fmt.Sprint(delta.Bytes), "c", 1))
+		}
+	}
+
+	return c.send(lines)
+}
+
+/*
+Start flushes the profiler every interval until the returned function is
+called, which flushes a last time. Send errors are ignored, metrics being
+best effort.
+*/
+func (c *Client) Start(p *timer.Profiler, interval time.Duration) func() {
+	var done = make(chan struct{})
+	var finished = make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		var ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.Flush(p)
+			case <-done:
+				c.Flush(p)
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
+
+// line formats a metric standing for samples values, the server scaling its
+// counts by the sample rate of one over samples.
+func (c *Client) line(name string, value string, kind string, samples int64) string {
+	if c.Prefix != "" {
+		name = c.Prefix + "." + name
+	}
+
+	var line = name + ":" + value + "|" + kind
+	if samples > 1 {
+		line = line + "|@" + strconv.FormatFloat(1/float64(samples), 'g', 6, 64)
+	}
+	if len(c.Tags) > 0 {
+		line = line + "|#" + strings.Join(c.Tags, ",")
+	}
+
+	return line
+}
+
+// send batches the lines into as few datagrams as possible.
+func (c *Client) send(lines []string) error {
+	var packet bytes.Buffer
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxPacketSize {
+			if _, err := c.conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+
+	if packet.Len() > 0 {
+		if _, err := c.conn.Write(packet.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sanitize replaces the characters reserved by the line format.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', '.', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, name)
+}
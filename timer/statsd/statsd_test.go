@@ -0,0 +1,136 @@
+package statsd
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+// listen returns a client sending to a local server, and a function returning
+// the datagrams the server received since its previous call.
+func listen(t *testing.T) (*Client, func() []string) {
+	t.Helper()
+
+	var server, err = net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	client, err := Dial(server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	var received = func() []string {
+		var packets []string
+		var buffer = make([]byte, 65536)
+		for {
+			server.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			var n, _, err = server.ReadFrom(buffer)
+			if err != nil {
+				return packets
+			}
+			packets = append(packets, string(buffer[:n]))
+		}
+	}
+
+	return client, received
+}
+
+func TestFlush(t *testing.T) {
+	var client, received = listen(t)
+	client.Prefix = "app"
+	client.Tags = []string{"env:test"}
+
+	var p = timer.New()
+	p.Start("handler")
+	p.RecordEvent("db.query", 3*time.Millisecond, 2048)
+	p.RecordEvent("db.query", time.Millisecond, 0)
+	p.Stop("handler")
+
+	if err := client.Flush(p); err != nil {
+		t.Fatal(err)
+	}
+	var packets = received()
+	if len(packets) != 1 {
+		t.Fatalf("received %d datagrams, want 1: %q", len(packets), packets)
+	}
+
+	var lines = strings.Split(packets[0], "\n")
+	// The mean of the handler depends on the actual clock
+	var want = []string{
+		"app.handler.hits:1|c|#env:test",
+		"app.handler.db_query:2.000|ms|@0.5|#env:test",
+		"app.handler.db_query.hits:2|c|#env:test",
+		"app.handler.db_query.bytes:2048|c|#env:test",
+	}
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "app.handler:") || !reflect.DeepEqual(lines[1:], want) {
+		t.Errorf("lines\n%s\nwant app.handler then\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Only what happened since the previous flush
+	p.RecordEvent("db.query", 5*time.Millisecond, 0)
+	if err := client.Flush(p); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"app.handler.db_query:5.000|ms|#env:test\napp.handler.db_query.hits:1|c|#env:test"}
+	if packets = received(); !reflect.DeepEqual(packets, want) {
+		t.Errorf("second flush sent %q, want %q", packets, want)
+	}
+
+	if err := client.Flush(p); err != nil {
+		t.Fatal(err)
+	}
+	if packets = received(); len(packets) != 0 {
+		t.Errorf("flush without hits sent %q", packets)
+	}
+
+	// A reset sends the new counters as is
+	p.Reset()
+	p.RecordEvent("db.query", time.Millisecond, 0)
+	if err := client.Flush(p); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"app.db_query:1.000|ms|#env:test\napp.db_query.hits:1|c|#env:test"}
+	if packets = received(); !reflect.DeepEqual(packets, want) {
+		t.Errorf("flush after a reset sent %q, want %q", packets, want)
+	}
+}
+
+func TestFlushSplitsDatagrams(t *testing.T) {
+	var client, received = listen(t)
+
+	var p = timer.New()
+	for i := 0; i < 100; i++ {
+		p.RecordDuration(fmt.Sprint("anchor", i), time.Millisecond)
+	}
+
+	if err := client.Flush(p); err != nil {
+		t.Fatal(err)
+	}
+
+	var packets = received()
+	var lines int
+	for _, packet := range packets {
+		if len(packet) > maxPacketSize {
+			t.Errorf("datagram of %d bytes, over %d", len(packet), maxPacketSize)
+		}
+		lines = lines + strings.Count(packet, "\n") + 1
+	}
+	if len(packets) < 2 || lines != 200 {
+		t.Errorf("%d lines in %d datagrams, want 200 lines split", lines, len(packets))
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	if got := sanitize("a:b|c@d#e,f.g h\ni"); got != "a_b_c_d_e_f_g_h_i" {
+		t.Errorf("sanitize = %q", got)
+	}
+}
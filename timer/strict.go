@@ -0,0 +1,29 @@
+package timer
+
+/*
+SetStrictNames makes an anchor name usable under a single parent: starting an
+existing anchor under a different parent than the one it was first started in
+is reported, StartE returning ErrParentMismatch and Start warning, which
+catches the same name accidentally used in unrelated parts of the code. The
+hit is still recorded under the original anchor. Starting an anchor again
+while it is open, i.e. recursively, is allowed whatever the parent. Disabled
+by default, sharing a name across parents being often intentional.
+*/
+func (p *Profiler) SetStrictNames(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.strictNames = enabled
+}
+
+// parentMismatch reports whether starting the anchor now breaks the strict
+// names rule.
+func (p *Profiler) parentMismatch(starting *anchor) bool {
+	return p.strictNames && starting.open == 0 && starting.parent != p.currentAnchor
+}
+
+// SetStrictNames restricts each anchor name of the default profiler to a
+// single parent.
+func SetStrictNames(enabled bool) {
+	defaultProfiler.SetStrictNames(enabled)
+}
@@ -0,0 +1,86 @@
+package timer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStrictNames(t *testing.T) {
+	var tests = []struct {
+		name    string
+		strict  bool
+		parents []string
+		want    error
+	}{
+		{"shared by default", false, []string{"a", "b"}, nil},
+		{"same parent", true, []string{"a", "a"}, nil},
+		{"other parent", true, []string{"a", "b"}, ErrParentMismatch},
+		{"root then nested", true, []string{"", "a"}, ErrParentMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var captured = captureWarnings(t)
+			var p = New()
+			p.SetStrictNames(test.strict)
+
+			var err error
+			for _, parent := range test.parents {
+				if parent != "" {
+					p.Start(parent)
+				}
+				err = p.StartE("process")
+				clock.advance(10)
+				p.Stop("process")
+				if parent != "" {
+					p.Stop(parent)
+				}
+			}
+
+			if !errors.Is(err, test.want) {
+				t.Errorf("second StartE: %v, want %v", err, test.want)
+			}
+			if warning := captured.String(); warning != "" {
+				t.Errorf("unexpected warning %q", warning)
+			}
+			// Recorded under the original anchor either way
+			if result := resultOf(t, p.Snapshot(), "process"); result.Hits != 2 {
+				t.Errorf("%d hits, want 2", result.Hits)
+			}
+		})
+	}
+}
+
+func TestStrictNamesWarning(t *testing.T) {
+	useFakeClock(t)
+	var captured = captureWarnings(t)
+	var p = New()
+	p.SetStrictNames(true)
+
+	p.Start("process")
+	p.Stop("process")
+	p.Start("other")
+	p.Start("process")
+	p.Stop("process")
+	p.Stop("other")
+
+	if warning := captured.String(); !strings.Contains(warning, ErrParentMismatch.Error()) {
+		t.Errorf("warning %q doesn't report the parent mismatch", warning)
+	}
+}
+
+func TestStrictNamesRecursion(t *testing.T) {
+	useFakeClock(t)
+	captureWarnings(t)
+	var p = New()
+	p.SetStrictNames(true)
+
+	p.Start("walk")
+	if err := p.StartE("walk"); err != nil {
+		t.Errorf("recursive StartE: %v", err)
+	}
+	p.Stop("walk")
+	p.Stop("walk")
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package timer
+
+// ttyColumns can't query the terminal here, COLUMNS must be set for bars.
+func ttyColumns(fd uintptr) int {
+	return 0
+}
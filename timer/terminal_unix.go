@@ -0,0 +1,24 @@
+//go:build linux || darwin
+
+package timer
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// ttyColumns returns the width of the terminal fd refers to, zero if it
+// isn't a terminal.
+func ttyColumns(fd uintptr) int {
+	var size struct {
+		rows, columns, xpixels, ypixels uint16
+	}
+
+	var _, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ),
+		uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0
+	}
+
+	return int(size.columns)
+}
@@ -0,0 +1,98 @@
+package timer
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+SetThroughputDuration makes Output report the bytes and calls of the named
+anchor over d, on a distinct line below the anchor, in addition to its CPU or
+wall time throughput. This suits work done asynchronously to the timed
+section, whose relevant time base was measured elsewhere, e.g. the whole
+request latency. A zero duration removes it.
+*/
+func (p *Profiler) SetThroughputDuration(anchorName string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return
+	}
+
+	if d <= 0 {
+		delete(p.throughputDurations, key)
+		return
+	}
+
+	if p.throughputDurations == nil {
+		p.throughputDurations = make(map[string]time.Duration)
+	}
+
+	p.throughputDurations[key] = d
+}
+
+/*
+ThroughputOver returns the bytes and calls per second of the named anchor over
+d, whatever time was measured for the anchor itself. Both are zero for an
+unknown anchor or a non-positive duration.
+*/
+func (p *Profiler) ThroughputOver(anchorName string, d time.Duration) (bytesPerSecond float64, callsPerSecond float64) {
+	if profilingDisabled() || d <= 0 {
+		return 0, 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return 0, 0
+	}
+
+	var anchor, exists = p.anchorsByName[key]
+	if !exists {
+		return 0, 0
+	}
+
+	return float64(anchor.bytes) / d.Seconds(), float64(anchor.hits) / d.Seconds()
+}
+
+func formatThroughputOver(anchor *anchor, d time.Duration, units ByteUnits) string {
+	var ops string
+	if anchor.ops != 0 {
+		ops = fmt.Sprintf(", %.1f ops/s", float64(anchor.ops)/d.Seconds())
+	}
+
+	return fmt.Sprintf("%s at %s, %.1f calls/s%s -- over %s (supplied)", units.megabytes(anchor.bytes),
+		units.gigabytesPerSecond(float64(anchor.bytes)/d.Seconds()), float64(anchor.hits)/d.Seconds(), ops, d)
+}
+
+// totalThroughput returns the bytes of every anchor and their rate over the
+// total elapsed time, in bytes per second.
+func (p *Profiler) totalThroughput() (int64, float64) {
+	var bytes int64
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		bytes = bytes + anchor.bytes
+	}
+
+	var seconds = ticksToDuration(p.totalAnchor.tscount).Seconds()
+	if seconds <= 0 {
+		return bytes, 0
+	}
+
+	return bytes, float64(bytes) / seconds
+}
+
+// SetThroughputDuration sets an external time base for an anchor of the
+// default profiler.
+func SetThroughputDuration(anchorName string, d time.Duration) {
+	defaultProfiler.SetThroughputDuration(anchorName, d)
+}
+
+// ThroughputOver returns the rates of an anchor of the default profiler over
+// d.
+func ThroughputOver(anchorName string, d time.Duration) (bytesPerSecond float64, callsPerSecond float64) {
+	return defaultProfiler.ThroughputOver(anchorName, d)
+}
@@ -0,0 +1,76 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThroughputOver(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	for i := 0; i < 4; i++ {
+		p.StartThroughput("upload", 1<<20)
+		clock.advance(1000)
+		p.Stop("upload")
+	}
+
+	var tests = []struct {
+		name   string
+		anchor string
+		d      time.Duration
+		bytes  float64
+		calls  float64
+	}{
+		{"two seconds", "upload", 2 * time.Second, 2 << 20, 2},
+		{"zero duration", "upload", 0, 0, 0},
+		{"negative duration", "upload", -time.Second, 0, 0},
+		{"unknown anchor", "download", time.Second, 0, 0},
+	}
+	for _, test := range tests {
+		var bytes, calls = p.ThroughputOver(test.anchor, test.d)
+		if bytes != test.bytes || calls != test.calls {
+			t.Errorf("%s: %v bytes/s, %v calls/s, want %v and %v", test.name, bytes, calls, test.bytes, test.calls)
+		}
+	}
+
+	const line = "throughput:    4.00MiB at 0.002GiB/s, 2.0 calls/s -- over 2s (supplied)"
+	p.SetThroughputDuration("upload", 2*time.Second)
+	if text := output(p); !strings.Contains(text, line) {
+		t.Errorf("report doesn't contain %q:\n%s", line, text)
+	}
+	p.SetThroughputDuration("upload", 0)
+	if text := output(p); strings.Contains(text, "(supplied)") {
+		t.Errorf("removed duration still reported:\n%s", text)
+	}
+}
+
+func TestTotalThroughput(t *testing.T) {
+	const ms = testFrequency / 1000
+
+	var clock = useFakeClock(t)
+	var p = New()
+	p.StartThroughput("read", 3<<20)
+	clock.advance(100 * ms)
+	p.Stop("read")
+	p.StartThroughput("write", 1<<20)
+	clock.advance(300 * ms)
+	p.Stop("write")
+	p.Start("idle")
+	clock.advance(100 * ms)
+	p.Stop("idle")
+
+	// Over the total, idle time included
+	var report = p.Snapshot()
+	if report.Bytes != 4<<20 || report.Throughput != 8<<20 {
+		t.Errorf("%d bytes at %v bytes/s, want 4MiB at 8MiB/s", report.Bytes, report.Throughput)
+	}
+	if text := output(p); !strings.Contains(text, "throughput:    4.00MiB at 0.008GiB/s (total)") {
+		t.Errorf("report doesn't show the total throughput:\n%s", text)
+	}
+
+	if merged := Merge(report, report); merged.Bytes != 8<<20 || merged.Throughput != 8<<20 {
+		t.Errorf("merged %d bytes at %v bytes/s, want 8MiB at 8MiB/s", merged.Bytes, merged.Throughput)
+	}
+}
@@ -1,8 +1,10 @@
 #ifndef _TIMER_H
 #define _TIMER_H
 
-typedef unsigned long u64;
+// unsigned long is only 32 bits on Windows
+typedef unsigned long long u64;
 
 u64 ReadCPUTimer(void);
+int HasInvariantTSC(void);
 
 #endif
@@ -0,0 +1,34 @@
+//go:build !windows && !(cgo && (amd64 || 386))
+
+package timer
+
+import "time"
+
+// Without cgo, or on a CPU without RDTSC such as arm64, the time stamp counter
+// can't be read: the monotonic clock of the Go runtime stands in for it, in
+// nanoseconds, at a lower resolution
+var clockFn = readMonotonicClock
+var freqFn = monotonicClockFreq
+var invariantFn = monotonicClockInvariant
+
+// The frequency is known, nothing is waited for
+const calibrationWindow = 0
+
+// readMonotonicClock returns the nanoseconds elapsed since the package was
+// initialized, the OS timer being the monotonic clock so that wall clock
+// adjustments don't show up as anchor times.
+func readMonotonicClock() int64 {
+	return readOSTimer()
+}
+
+// monotonicClockFreq returns the frequency of readMonotonicClock, one tick
+// per nanosecond.
+func monotonicClockFreq() int64 {
+	return int64(time.Second)
+}
+
+// monotonicClockInvariant is always set, the monotonic clock not depending on
+// the CPU power state.
+func monotonicClockInvariant() bool {
+	return true
+}
@@ -0,0 +1,31 @@
+//go:build !windows && !(cgo && (amd64 || 386))
+
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonotonicClock(t *testing.T) {
+	InvalidateCalibration()
+	t.Cleanup(InvalidateCalibration)
+
+	// One tick per nanosecond, known without waiting
+	var start = time.Now()
+	if frequency := Calibrate(); frequency != int64(time.Second) {
+		t.Errorf("calibrated %dHz, want 1GHz", frequency)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("calibration waited %v", elapsed)
+	}
+	if !monotonicClockInvariant() {
+		t.Errorf("the monotonic clock isn't reported invariant")
+	}
+
+	var before = readMonotonicClock()
+	time.Sleep(5 * time.Millisecond)
+	if elapsed := readMonotonicClock() - before; elapsed < int64(5*time.Millisecond) || elapsed > int64(time.Second) {
+		t.Errorf("a 5ms sleep measured %dns", elapsed)
+	}
+}
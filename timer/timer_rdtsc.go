@@ -0,0 +1,35 @@
+//go:build cgo && (amd64 || 386)
+
+package timer
+
+// #cgo CFLAGS: -g -Wall
+// #include <stdlib.h>
+// #include "timer.h"
+import "C"
+
+import "time"
+
+var clockFn = readTSC
+var freqFn = estimateTSCFreq
+var invariantFn = hasInvariantTSC
+
+// The frequency is estimated by busy-waiting for the calibration window
+const calibrationWindow = 50 * time.Millisecond
+
+// readTSC reads the time stamp counter with RDTSC.
+func readTSC() int64 {
+	cvalue := C.ReadCPUTimer()
+	return int64(cvalue)
+}
+
+// estimateTSCFreq calibrates the time stamp counter against the OS timer, its
+// frequency not being reported by the CPU.
+func estimateTSCFreq() int64 {
+	return getCPUTimerFreq(calibrationWindow.Milliseconds())
+}
+
+// hasInvariantTSC reports whether the CPU advertises an invariant time stamp
+// counter, ticking at a constant rate whatever the power state.
+func hasInvariantTSC() bool {
+	return C.HasInvariantTSC() != 0
+}
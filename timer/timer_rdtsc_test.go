@@ -0,0 +1,36 @@
+//go:build cgo && (amd64 || 386)
+
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTSC runs on the time stamp counter read by timer_unix.c, or by
+// timer_windows.c through intrin.h.
+func TestTSC(t *testing.T) {
+	var previous = readTSC()
+	for i := 0; i < 1000; i++ {
+		var counter = readTSC()
+		if counter < previous {
+			t.Fatalf("counter went back from %d to %d", previous, counter)
+		}
+		previous = counter
+	}
+
+	var before = readTSC()
+	time.Sleep(10 * time.Millisecond)
+	var elapsed = readTSC() - before
+	if elapsed <= 0 {
+		t.Fatalf("counter advanced by %d over 10ms", elapsed)
+	}
+
+	var frequency = estimateTSCFreq()
+	if frequency < 100000000 || frequency > 10000000000 {
+		t.Fatalf("frequency = %dHz, want between 100MHz and 10GHz", frequency)
+	}
+	if ms := float64(elapsed) / float64(frequency) * 1000; ms < 10 || ms > 1000 {
+		t.Errorf("a 10ms sleep measured %vms", ms)
+	}
+}
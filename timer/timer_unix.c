@@ -0,0 +1,20 @@
+//go:build cgo && (amd64 || 386) && !windows
+
+#include "timer.h"
+#include <stdio.h>
+#include <x86intrin.h>
+#include <cpuid.h>
+
+u64 ReadCPUTimer(void) {
+    return __rdtsc();
+}
+
+int HasInvariantTSC(void) {
+    unsigned int eax, ebx, ecx, edx;
+    if (__get_cpuid_max(0x80000000, 0) < 0x80000007) {
+        return 0;
+    }
+
+    __cpuid(0x80000007, eax, ebx, ecx, edx);
+    return (edx >> 8) & 1;
+}
@@ -0,0 +1,19 @@
+//go:build cgo && (amd64 || 386) && windows
+
+#include "timer.h"
+#include <intrin.h>
+
+u64 ReadCPUTimer(void) {
+    return __rdtsc();
+}
+
+int HasInvariantTSC(void) {
+    int info[4];
+    __cpuid(info, 0x80000000);
+    if ((unsigned int)info[0] < 0x80000007) {
+        return 0;
+    }
+
+    __cpuid(info, 0x80000007);
+    return (info[3] >> 8) & 1;
+}
@@ -0,0 +1,48 @@
+//go:build windows && !(cgo && (amd64 || 386))
+
+package timer
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// The performance counter is the recommended high resolution timer on
+// Windows, where it has a fixed and reported frequency. It is used when cgo
+// is disabled, e.g. without a C toolchain, or on arm64, the time stamp counter
+// being read otherwise as on the other platforms.
+var (
+	kernel32                  = syscall.NewLazyDLL("kernel32.dll")
+	queryPerformanceCounter   = kernel32.NewProc("QueryPerformanceCounter")
+	queryPerformanceFrequency = kernel32.NewProc("QueryPerformanceFrequency")
+)
+
+var clockFn = readQPC
+var freqFn = reportedQPCFreq
+var invariantFn = qpcInvariant
+
+// The frequency is reported, nothing is waited for
+const calibrationWindow = 0
+
+func readQPC() int64 {
+	var counter int64
+	queryPerformanceCounter.Call(uintptr(unsafe.Pointer(&counter)))
+	return counter
+}
+
+// reportedQPCFreq returns the performance counter frequency, no calibration
+// being needed.
+func reportedQPCFreq() int64 {
+	var frequency int64
+	queryPerformanceFrequency.Call(uintptr(unsafe.Pointer(&frequency)))
+
+	verbosef("  QPC freq: %v (reported)\n", frequency)
+
+	return frequency
+}
+
+// qpcInvariant is always set, the performance counter having a constant
+// frequency by design.
+func qpcInvariant() bool {
+	return true
+}
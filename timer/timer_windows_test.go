@@ -0,0 +1,39 @@
+//go:build windows && !(cgo && (amd64 || 386))
+
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQPC(t *testing.T) {
+	InvalidateCalibration()
+	t.Cleanup(InvalidateCalibration)
+
+	var reported = reportedQPCFreq()
+	if reported <= 0 || reportedQPCFreq() != reported {
+		t.Fatalf("reported frequency %dHz, want a positive constant", reported)
+	}
+	if !qpcInvariant() {
+		t.Errorf("the performance counter isn't reported invariant")
+	}
+
+	// Reported, not estimated over a calibration window
+	var start = time.Now()
+	if frequency := Calibrate(); frequency != reported {
+		t.Errorf("calibrated %dHz, want the reported %dHz", frequency, reported)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("calibration waited %v", elapsed)
+	}
+
+	var previous = readQPC()
+	for i := 0; i < 1000; i++ {
+		var counter = readQPC()
+		if counter < previous {
+			t.Fatalf("counter went back from %d to %d", previous, counter)
+		}
+		previous = counter
+	}
+}
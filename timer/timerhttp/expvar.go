@@ -0,0 +1,29 @@
+package timerhttp
+
+import (
+	"expvar"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+/*
+PublishExpvar publishes a fresh snapshot of the default profiler under name on
+the expvar endpoint, /debug/vars, in the JSON form of timer.Report. Like
+expvar.Publish, it panics when name is already published, so call it once at
+startup.
+
+It is part of this package rather than of timer so that the core package
+doesn't register the expvar handler on http.DefaultServeMux.
+*/
+func PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return timer.Snapshot()
+	}))
+}
+
+// PublishExpvarFor is PublishExpvar publishing the profiler p.
+func PublishExpvarFor(name string, p *timer.Profiler) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return p.Snapshot()
+	}))
+}
@@ -0,0 +1,35 @@
+package timerhttp
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+func TestPublishExpvarFor(t *testing.T) {
+	var p = timer.New()
+	PublishExpvarFor("timerhttp_test", p)
+
+	var published = func() timer.Report {
+		t.Helper()
+
+		var report timer.Report
+		if err := json.Unmarshal([]byte(expvar.Get("timerhttp_test").String()), &report); err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	if report := published(); len(report.Anchors) != 0 {
+		t.Fatalf("%d anchors published before any was recorded", len(report.Anchors))
+	}
+
+	// Each read takes a fresh snapshot
+	p.Start("a")
+	p.Stop("a")
+	if report := published(); len(report.Anchors) != 1 || report.Anchors[0].Name != "a" {
+		t.Errorf("published anchors %+v, want a", report.Anchors)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Package timerhttp profiles HTTP handlers with a timer.Profiler per request, and
+publishes profilers on the expvar endpoint.
+
+The middleware starts an anchor named after the request when it enters the
+handler and stops it when the handler returns, panics included. The handler
+can open nested anchors on the request profiler:
+
+	var p = timerhttp.FromContext(r.Context())
+	p.Start("query")
+	...
+	p.Stop("query")
+*/
+package timerhttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+/*
+Options configures the middleware. Name returns the anchor name of a request,
+its method and path by default, which a route template can replace to keep
+the anchors of parameterized paths together. Report receives the profiler of
+each request once served. Without it the reports are discarded: printing one
+per request with Output would flood the standard output of a busy server.
+*/
+type Options struct {
+	Name   func(r *http.Request) string
+	Report func(r *http.Request, p *timer.Profiler)
+}
+
+// Middleware profiles next with the default Options.
+func Middleware(next http.Handler) http.Handler {
+	return Options{}.Middleware(next)
+}
+
+/*
+Middleware returns a handler serving requests with next, each request having
+its own profiler available through FromContext. When next panics, the anchor
+is stopped and the report delivered before the panic resumes unwinding.
+*/
+func (o Options) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var name = r.Method + " " + r.URL.Path
+		if o.Name != nil {
+			name = o.Name(r)
+		}
+
+		var p = timer.NewProfiler(name)
+		r = r.WithContext(NewContext(r.Context(), p))
+
+		defer func() {
+			p.Stop(name)
+
+			if o.Report != nil {
+				o.Report(r, p)
+			}
+		}()
+
+		p.Start(name)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewContext returns a copy of ctx carrying the profiler p, as
+// timer.WithProfiler does.
+func NewContext(ctx context.Context, p *timer.Profiler) context.Context {
+	return timer.WithProfiler(ctx, p)
+}
+
+// FromContext returns the profiler carried by ctx, nil if none. Handlers can
+// equally call timer.FromContext.
+func FromContext(ctx context.Context) *timer.Profiler {
+	return timer.FromContext(ctx)
+}
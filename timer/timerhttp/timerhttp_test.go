@@ -0,0 +1,117 @@
+package timerhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+// served holds what the Report option received for a request.
+type served struct {
+	path   string
+	report timer.Report
+}
+
+func TestMiddleware(t *testing.T) {
+	var handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p = FromContext(r.Context())
+		if p == nil {
+			t.Error("no profiler in the request context")
+			return
+		}
+
+		p.Start("query")
+		p.Stop("query")
+	})
+
+	var tests = []struct {
+		name    string
+		options Options
+		anchor  string
+	}{
+		{"default name", Options{}, "GET /users/42"},
+		{"route name", Options{Name: func(r *http.Request) string { return "GET /users/{id}" }}, "GET /users/{id}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []served
+			test.options.Report = func(r *http.Request, p *timer.Profiler) {
+				got = append(got, served{r.URL.Path, p.Report()})
+			}
+
+			var server = test.options.Middleware(handler)
+			for i := 0; i < 2; i++ {
+				server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+			}
+
+			if len(got) != 2 {
+				t.Fatalf("%d reports, want one per request", len(got))
+			}
+			for _, request := range got {
+				var anchors = request.report.Anchors
+				if len(anchors) != 2 || anchors[0].Name != test.anchor || anchors[1].Name != "query" {
+					t.Fatalf("anchors %+v, want %q holding query", anchors, test.anchor)
+				}
+				// Each request has its own profiler
+				if anchors[0].Hits != 1 || anchors[0].Open || anchors[1].Depth != 1 {
+					t.Errorf("request anchor %+v, want a single closed hit holding query", anchors[0])
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewarePanics(t *testing.T) {
+	var reported *timer.Report
+	var options = Options{Report: func(r *http.Request, p *timer.Profiler) {
+		var report = p.Report()
+		reported = &report
+	}}
+
+	var server = options.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Start("query")
+		panic("handler failed")
+	}))
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "handler failed" {
+				t.Errorf("recovered %v, want the panic of the handler", recovered)
+			}
+		}()
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+
+	if reported == nil {
+		t.Fatal("no report delivered for the panicking request")
+	}
+	if len(reported.Anchors) == 0 || reported.Anchors[0].Name != "GET /" {
+		t.Fatalf("anchors %+v, want the request anchor first", reported.Anchors)
+	}
+	if result := reported.Anchors[0]; result.Hits != 1 || result.Open {
+		t.Errorf("request anchor %+v, want it stopped", result)
+	}
+}
+
+func TestMiddlewareDefaultIsSilent(t *testing.T) {
+	var read, write, err = os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer read.Close()
+
+	var stdout = os.Stdout
+	os.Stdout = write
+	Middleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	os.Stdout = stdout
+	write.Close()
+
+	var buffer [512]byte
+	if n, _ := read.Read(buffer[:]); n != 0 {
+		t.Errorf("the default middleware wrote %q", buffer[:n])
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/fcassin/gotimer/timer/timerotel
+
+go 1.18
+
+require (
+	github.com/fcassin/gotimer v0.0.0
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/trace v1.14.0
+)
+
+replace github.com/fcassin/gotimer => ../..
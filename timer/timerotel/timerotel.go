@@ -0,0 +1,87 @@
+/*
+Package timerotel exports the anchors of a timer.Report as OpenTelemetry
+spans, bridging ad-hoc profiling into an existing tracing backend.
+
+The total becomes a span under the span of the given context, and every
+anchor a span under that of the anchor it was first started in. A report
+only holds the time accumulated by each anchor, not when each hit happened:
+like in the Chrome trace of timer.WriteChromeTrace, the spans of the children
+of an anchor are laid out one after the other from its start, each lasting
+the time of the child and of its own children.
+
+It lives in its own module so that the core package doesn't depend on
+OpenTelemetry.
+*/
+package timerotel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+/*
+Export emits the spans of report with tracer, under the span of ctx if any.
+The total span starts at start; a zero start makes it end now, for a report
+just completed. Every span carries the hits, bytes and CPU timer units of its
+anchor as the timer.hits, timer.bytes and timer.tscount attributes.
+*/
+func Export(ctx context.Context, tracer trace.Tracer, report timer.Report, start time.Time) {
+	if start.IsZero() {
+		start = time.Now().Add(-milliseconds(report.Total.Elapsed))
+	}
+
+	// Time including children of each anchor
+	var inclusive = make(map[string]time.Duration, len(report.Anchors))
+	for i := len(report.Anchors) - 1; i >= 0; i-- {
+		var result = report.Anchors[i]
+		inclusive[result.Name] = inclusive[result.Name] + milliseconds(result.Elapsed)
+		if result.ParentName != "" {
+			inclusive[result.ParentName] = inclusive[result.ParentName] + inclusive[result.Name]
+		}
+	}
+
+	var totalContext = emit(ctx, tracer, report.Total, start, milliseconds(report.Total.Elapsed))
+
+	// Contexts of the anchor spans, and start of the next child of each anchor
+	var contexts = make(map[string]context.Context, len(report.Anchors))
+	var next = make(map[string]time.Time, len(report.Anchors))
+	for _, result := range report.Anchors {
+		var parentContext, exists = contexts[result.ParentName]
+		if !exists {
+			parentContext = totalContext
+		}
+
+		var spanStart, started = next[result.ParentName]
+		if !started {
+			spanStart = start
+		}
+		next[result.ParentName] = spanStart.Add(inclusive[result.Name])
+		next[result.Name] = spanStart
+
+		contexts[result.Name] = emit(parentContext, tracer, result, spanStart, inclusive[result.Name])
+	}
+}
+
+// emit ends a span of the anchor lasting d from start, and returns its
+// context for the spans of the children.
+func emit(ctx context.Context, tracer trace.Tracer, result timer.AnchorResult, start time.Time,
+	d time.Duration) context.Context {
+	var spanContext, span = tracer.Start(ctx, result.Name, trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attribute.Int64("timer.hits", result.Hits),
+			attribute.Int64("timer.bytes", result.Bytes),
+			attribute.Int64("timer.tscount", result.TSCount),
+		))
+	span.End(trace.WithTimestamp(start.Add(d)))
+
+	return spanContext
+}
+
+func milliseconds(elapsed float64) time.Duration {
+	return time.Duration(elapsed * float64(time.Millisecond))
+}
@@ -0,0 +1,107 @@
+package timerotel
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+// recordedSpan is a span of recorder, only supporting End.
+type recordedSpan struct {
+	trace.Span
+
+	name       string
+	parent     string
+	start, end time.Time
+	attributes []attribute.KeyValue
+}
+
+func (s *recordedSpan) End(options ...trace.SpanEndOption) {
+	var config = trace.NewSpanEndConfig(options...)
+	s.end = config.Timestamp()
+}
+
+// recorder is a tracer keeping the spans it starts.
+type recorder struct {
+	spans []*recordedSpan
+}
+
+func (r *recorder) Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context,
+	trace.Span) {
+	var config = trace.NewSpanStartConfig(options...)
+	var span = &recordedSpan{name: name, start: config.Timestamp(), attributes: config.Attributes()}
+	if parent, ok := trace.SpanFromContext(ctx).(*recordedSpan); ok {
+		span.parent = parent.name
+	}
+
+	r.spans = append(r.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func TestExport(t *testing.T) {
+	var report = timer.Report{
+		Total: timer.AnchorResult{Name: "total", TSCount: 5000, Elapsed: 5},
+		Anchors: []timer.AnchorResult{
+			{Name: "parse", Hits: 2, Bytes: 4096, TSCount: 2000, Elapsed: 2},
+			{Name: "read", Depth: 1, ParentName: "parse", Hits: 2, TSCount: 1000, Elapsed: 1},
+			{Name: "render", Hits: 1, TSCount: 2000, Elapsed: 2},
+		},
+	}
+
+	var tracer recorder
+	var start = time.Unix(1700000000, 0)
+	Export(context.Background(), &tracer, report, start)
+
+	type span struct {
+		name, parent string
+		start, end   time.Duration
+	}
+	// Children laid out one after the other from the start of their parent
+	var want = []span{
+		{"total", "", 0, 5 * time.Millisecond},
+		{"parse", "total", 0, 3 * time.Millisecond},
+		{"read", "parse", 0, time.Millisecond},
+		{"render", "total", 3 * time.Millisecond, 5 * time.Millisecond},
+	}
+
+	var got []span
+	for _, recorded := range tracer.spans {
+		got = append(got, span{recorded.name, recorded.parent, recorded.start.Sub(start), recorded.end.Sub(start)})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("spans\n%+v\nwant\n%+v", got, want)
+	}
+
+	var attributes = []attribute.KeyValue{
+		attribute.Int64("timer.hits", 2),
+		attribute.Int64("timer.bytes", 4096),
+		attribute.Int64("timer.tscount", 2000),
+	}
+	if len(tracer.spans) > 1 && !reflect.DeepEqual(tracer.spans[1].attributes, attributes) {
+		t.Errorf("parse attributes %v, want %v", tracer.spans[1].attributes, attributes)
+	}
+}
+
+func TestExportEndingNow(t *testing.T) {
+	var report = timer.Report{Total: timer.AnchorResult{Name: "total", Elapsed: 1000}}
+
+	var tracer recorder
+	var before = time.Now()
+	Export(context.Background(), &tracer, report, time.Time{})
+	var after = time.Now()
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("%d spans, want the total only", len(tracer.spans))
+	}
+	var total = tracer.spans[0]
+	if total.end.Before(before) || total.end.After(after) || total.end.Sub(total.start) != time.Second {
+		t.Errorf("total from %v to %v, want a second ending between %v and %v", total.start, total.end, before,
+			after)
+	}
+}
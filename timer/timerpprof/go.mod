@@ -0,0 +1,10 @@
+module github.com/fcassin/gotimer/timer/timerpprof
+
+go 1.18
+
+require (
+	github.com/fcassin/gotimer v0.0.0
+	github.com/google/pprof v0.0.0-20221118152302-e6195bd50e26
+)
+
+replace github.com/fcassin/gotimer => ../..
@@ -0,0 +1,102 @@
+/*
+Package timerpprof writes the anchors of a timer.Report as a pprof profile,
+viewed with the usual tooling:
+
+	go tool pprof -http=:8080 profile.pb.gz
+
+Every anchor becomes a function, and the stack of its sample the chain of the
+anchors it was first started in, up to the total. Samples carry the time of
+the anchor excluding its children, in nanoseconds, which is the default
+sample type, and its calls. The time outside any anchor is counted on the
+total itself, so that the flame graph adds up to the total.
+
+It lives in its own module so that the core package doesn't depend on the
+pprof profile package.
+*/
+package timerpprof
+
+import (
+	"io"
+	"time"
+
+	"github.com/google/pprof/profile"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+// Write writes the report of p to w as a gzipped pprof profile.
+func Write(w io.Writer, p *timer.Profiler) error {
+	return WriteReport(w, p.Snapshot())
+}
+
+// WriteReport writes report to w as a gzipped pprof profile, see the package
+// documentation for its layout.
+func WriteReport(w io.Writer, report timer.Report) error {
+	var prof = &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "calls", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		DefaultSampleType: "cpu",
+		PeriodType:        &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:            1,
+		DurationNanos:     int64(nanoseconds(report.Total.Elapsed)),
+	}
+
+	var locations = make(map[string]*profile.Location, len(report.Anchors)+1)
+	var location = func(name string) *profile.Location {
+		if existing, exists := locations[name]; exists {
+			return existing
+		}
+
+		var id = uint64(len(prof.Function) + 1)
+		var function = &profile.Function{ID: id, Name: name, SystemName: name}
+		var created = &profile.Location{ID: id, Line: []profile.Line{{Function: function}}}
+		prof.Function = append(prof.Function, function)
+		prof.Location = append(prof.Location, created)
+		locations[name] = created
+
+		return created
+	}
+
+	var parents = make(map[string]string, len(report.Anchors))
+	for _, result := range report.Anchors {
+		parents[result.Name] = result.ParentName
+	}
+
+	var unaccounted = nanoseconds(report.Total.Elapsed)
+	for _, result := range report.Anchors {
+		var stack []*profile.Location
+		var name = result.Name
+		// Bounded by the number of anchors, should the parents loop
+		for depth := 0; name != "" && depth <= len(report.Anchors); depth++ {
+			stack = append(stack, location(name))
+			name = parents[name]
+		}
+		stack = append(stack, location(report.Total.Name))
+
+		var elapsed = nanoseconds(result.Elapsed)
+		unaccounted = unaccounted - elapsed
+		prof.Sample = append(prof.Sample, &profile.Sample{
+			Location: stack,
+			Value:    []int64{result.Hits, int64(elapsed)},
+		})
+	}
+
+	if unaccounted > 0 {
+		prof.Sample = append(prof.Sample, &profile.Sample{
+			Location: []*profile.Location{location(report.Total.Name)},
+			Value:    []int64{0, int64(unaccounted)},
+		})
+	}
+
+	if err := prof.CheckValid(); err != nil {
+		return err
+	}
+
+	return prof.Write(w)
+}
+
+func nanoseconds(elapsed float64) time.Duration {
+	return time.Duration(elapsed * float64(time.Millisecond))
+}
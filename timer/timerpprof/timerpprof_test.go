@@ -0,0 +1,98 @@
+package timerpprof
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/pprof/profile"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+func TestWriteReport(t *testing.T) {
+	var report = timer.Report{
+		Total: timer.AnchorResult{Name: "total", Elapsed: 6},
+		Anchors: []timer.AnchorResult{
+			{Name: "parse", Hits: 2, Elapsed: 2},
+			{Name: "read", Depth: 1, ParentName: "parse", Hits: 4, Elapsed: 1},
+			{Name: "render", Hits: 1, Elapsed: 2},
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteReport(&buffer, report); err != nil {
+		t.Fatal(err)
+	}
+
+	var prof, err = profile.Parse(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prof.DefaultSampleType != "cpu" || prof.DurationNanos != 6000000 {
+		t.Errorf("default sample type %q over %dns, want cpu over 6ms", prof.DefaultSampleType,
+			prof.DurationNanos)
+	}
+
+	// Stacks from the root, with the calls and nanoseconds of their samples
+	var samples []string
+	for _, sample := range prof.Sample {
+		var frames []string
+		for i := len(sample.Location) - 1; i >= 0; i-- {
+			frames = append(frames, sample.Location[i].Line[0].Function.Name)
+		}
+		samples = append(samples, fmt.Sprintf("%s %d %d", strings.Join(frames, ";"), sample.Value[0], sample.Value[1]))
+	}
+	sort.Strings(samples)
+
+	// The 1ms outside any anchor on the total
+	var want = []string{
+		"total 0 1000000",
+		"total;parse 2 2000000",
+		"total;parse;read 4 1000000",
+		"total;render 1 2000000",
+	}
+	if !reflect.DeepEqual(samples, want) {
+		t.Errorf("samples\n%s\nwant\n%s", strings.Join(samples, "\n"), strings.Join(want, "\n"))
+	}
+
+	// A function per anchor, shared by the stacks
+	if len(prof.Function) != 4 {
+		t.Errorf("%d functions, want one per anchor and the total", len(prof.Function))
+	}
+}
+
+func TestWriteReportLoopingParents(t *testing.T) {
+	var report = timer.Report{
+		Total: timer.AnchorResult{Name: "total", Elapsed: 2},
+		Anchors: []timer.AnchorResult{
+			{Name: "a", ParentName: "b", Hits: 1, Elapsed: 1},
+			{Name: "b", ParentName: "a", Hits: 1, Elapsed: 1},
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteReport(&buffer, report); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := profile.Parse(&buffer); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var p = timer.New()
+	p.Start("a")
+	p.Stop("a")
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := profile.Parse(&buffer); err != nil {
+		t.Error(err)
+	}
+}
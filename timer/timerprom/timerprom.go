@@ -0,0 +1,75 @@
+/*
+Package timerprom exposes the anchors of a timer.Profiler to Prometheus.
+
+On every scrape, the collector takes a snapshot of the profiler and reports
+for each anchor, labeled by its name:
+
+	timer_anchor_elapsed_milliseconds{anchor="parse"} 12.5
+	timer_anchor_hits_total{anchor="parse"} 4
+	timer_anchor_percent{anchor="parse"} 37.5
+
+It lives in its own module so that the core package doesn't depend on the
+Prometheus client.
+*/
+package timerprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+/*
+Collector implements prometheus.Collector over a profiler. Namespace, timer by
+default, prefixes the metric names.
+*/
+type Collector struct {
+	profiler *timer.Profiler
+
+	elapsed *prometheus.Desc
+	hits    *prometheus.Desc
+	percent *prometheus.Desc
+}
+
+// NewCollector returns a Collector of the default namespace over p.
+func NewCollector(p *timer.Profiler) *Collector {
+	return NewCollectorNamespace(p, "timer")
+}
+
+// NewCollectorNamespace returns a Collector prefixing the metric names with
+// namespace.
+func NewCollectorNamespace(p *timer.Profiler, namespace string) *Collector {
+	var labels = []string{"anchor"}
+
+	return &Collector{
+		profiler: p,
+		elapsed: prometheus.NewDesc(prometheus.BuildFQName(namespace, "anchor", "elapsed_milliseconds"),
+			"Time spent in the anchor since the latest reset, children excluded.", labels, nil),
+		hits: prometheus.NewDesc(prometheus.BuildFQName(namespace, "anchor", "hits_total"),
+			"Number of Start calls of the anchor since the latest reset.", labels, nil),
+		percent: prometheus.NewDesc(prometheus.BuildFQName(namespace, "anchor", "percent"),
+			"Time spent in the anchor relative to the total profiled time.", labels, nil),
+	}
+}
+
+// Describe sends the descriptors of the anchor metrics.
+func (c *Collector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.elapsed
+	descs <- c.hits
+	descs <- c.percent
+}
+
+/*
+Collect sends the metrics of every anchor of a fresh snapshot. The hits are a
+counter, which Prometheus sees as restarted when the profiler is reset.
+*/
+func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
+	var report = c.profiler.Snapshot()
+
+	for _, result := range report.Anchors {
+		metrics <- prometheus.MustNewConstMetric(c.elapsed, prometheus.GaugeValue, result.Elapsed, result.Name)
+		metrics <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(result.Hits),
+			result.Name)
+		metrics <- prometheus.MustNewConstMetric(c.percent, prometheus.GaugeValue, result.Percent, result.Name)
+	}
+}
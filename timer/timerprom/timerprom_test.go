@@ -0,0 +1,72 @@
+package timerprom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/fcassin/gotimer/timer"
+)
+
+// profile returns a profiler whose anchors took 2ms and 6ms of a supplied
+// frequency, so that the figures don't depend on the clock.
+func profile(t *testing.T) *timer.Profiler {
+	timer.SetCPUFrequency(1000000000)
+	t.Cleanup(func() { timer.SetCPUFrequency(0) })
+
+	var p = timer.New()
+	p.RecordSpan("parse", 0, 2000000)
+	p.RecordSpan("render", 2000000, 8000000)
+	return p
+}
+
+func TestCollector(t *testing.T) {
+	var tests = []struct {
+		name      string
+		collector *Collector
+		namespace string
+	}{
+		{"default namespace", NewCollector(nil), "timer"},
+		{"namespace", NewCollectorNamespace(nil, "app"), "app"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.collector.profiler = profile(t)
+
+			var want = strings.ReplaceAll(`
+# HELP NS_anchor_elapsed_milliseconds Time spent in the anchor since the latest reset, children excluded.
+# TYPE NS_anchor_elapsed_milliseconds gauge
+NS_anchor_elapsed_milliseconds{anchor="parse"} 2
+NS_anchor_elapsed_milliseconds{anchor="render"} 6
+# HELP NS_anchor_hits_total Number of Start calls of the anchor since the latest reset.
+# TYPE NS_anchor_hits_total counter
+NS_anchor_hits_total{anchor="parse"} 1
+NS_anchor_hits_total{anchor="render"} 1
+# HELP NS_anchor_percent Time spent in the anchor relative to the total profiled time.
+# TYPE NS_anchor_percent gauge
+NS_anchor_percent{anchor="parse"} 25
+NS_anchor_percent{anchor="render"} 75
+`, "NS", test.namespace)
+
+			if err := testutil.CollectAndCompare(test.collector, strings.NewReader(want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCollectorTakesFreshSnapshots(t *testing.T) {
+	var p = profile(t)
+	var collector = NewCollector(p)
+
+	if count := testutil.CollectAndCount(collector); count != 6 {
+		t.Errorf("collected %d metrics, want 3 per anchor", count)
+	}
+
+	p.RecordSpan("write", 8000000, 9000000)
+	if count := testutil.CollectAndCount(collector); count != 9 {
+		t.Errorf("collected %d metrics after a new anchor, want 9", count)
+	}
+}
@@ -0,0 +1,185 @@
+package timer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const defaultSeriesMaxBuckets = 60
+
+// timeSeries keeps the latest buckets of CPU timer units spent in an anchor,
+// first being the index of buckets[0] since the start of the profile.
+type timeSeries struct {
+	first   int64
+	buckets []int64
+}
+
+// add spreads the tscount CPU timer units of a period ending at end, counted
+// from the start of the profile, over the intervals of interval units it
+// spans.
+func (s *timeSeries) add(end int64, tscount int64, interval int64, maxBuckets int) {
+	var start = end - tscount
+	var last = end / interval
+	if tscount > 0 && end%interval == 0 {
+		// Ending right at the start of an interval
+		last = last - 1
+	}
+	s.advance(last, maxBuckets)
+
+	var bucket = start / interval
+	if bucket < s.first {
+		// Older part already dropped from the window
+		bucket = s.first
+	}
+
+	for ; bucket <= last; bucket++ {
+		var from, to = bucket * interval, (bucket + 1) * interval
+		if from < start {
+			from = start
+		}
+		if to > end {
+			to = end
+		}
+		s.buckets[bucket-s.first] += to - from
+	}
+}
+
+// advance extends the window up to bucket, keeping at most maxBuckets of the
+// latest buckets. Past a gap longer than the window, it starts over from the
+// new window rather than filling the gap.
+func (s *timeSeries) advance(bucket int64, maxBuckets int) {
+	var end = s.first + int64(len(s.buckets))
+	if bucket < end {
+		return
+	}
+
+	var first = bucket - int64(maxBuckets) + 1
+	if first < s.first {
+		first = s.first
+	}
+
+	if first >= end {
+		s.buckets = s.buckets[:0]
+	} else {
+		s.buckets = append(s.buckets[:0], s.buckets[first-s.first:]...)
+	}
+	s.first = first
+
+	for s.first+int64(len(s.buckets)) <= bucket {
+		s.buckets = append(s.buckets, 0)
+	}
+}
+
+// accumulate adds tscount CPU timer units to anchor, now being the CPU timer
+// reading closing the measured period.
+func (p *Profiler) accumulate(anchor *anchor, tscount int64, now int64) {
+	if tscount < 0 {
+		// The clock went backwards, e.g. on a counter reset
+		p.clockAnomalies = p.clockAnomalies + 1
+		tscount = 0
+	}
+
+	anchor.tscount = anchor.tscount + tscount
+	anchor.elapsed = ticksToMilliseconds(anchor.tscount)
+
+	if p.currentPhase != nil {
+		var counters = p.currentPhase.countersOf(anchor)
+		counters.tscount = counters.tscount + tscount
+	}
+
+	if p.seriesInterval <= 0 {
+		return
+	}
+
+	var intervalTicks = int64(p.seriesInterval.Seconds() * float64(atomic.LoadInt64(&cpuFrequency)))
+	if intervalTicks <= 0 {
+		return
+	}
+
+	if anchor.series == nil {
+		anchor.series = &timeSeries{}
+	}
+
+	anchor.series.add(now-p.totalTiming.start, tscount, intervalTicks, p.seriesMaxBuckets)
+}
+
+func ticksToDuration(tscount int64) time.Duration {
+	var frequency = atomic.LoadInt64(&cpuFrequency)
+	if frequency == 0 {
+		return 0
+	}
+
+	return time.Duration(float64(tscount) / float64(frequency) * float64(time.Second))
+}
+
+// ticksToMilliseconds converts CPU timer units to milliseconds, zero before
+// calibration.
+func ticksToMilliseconds(tscount int64) float64 {
+	var frequency = atomic.LoadInt64(&cpuFrequency)
+	if frequency/1000 == 0 {
+		return 0
+	}
+
+	return float64(tscount) / float64(frequency/1000)
+}
+
+func durationToTicks(d time.Duration) int64 {
+	return int64(d.Seconds() * float64(atomic.LoadInt64(&cpuFrequency)))
+}
+
+/*
+SetTimeSeries enables the bucketing of each anchor's time into fixed intervals
+measured from the first Start, keeping at most maxBuckets of the latest
+intervals per anchor. A zero interval disables it, which is the default.
+
+Time spent in an anchor is split across the intervals each measured period
+spans, so that a long hit shows in every interval it ran in.
+*/
+func (p *Profiler) SetTimeSeries(interval time.Duration, maxBuckets int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if maxBuckets <= 0 {
+		maxBuckets = defaultSeriesMaxBuckets
+	}
+
+	p.seriesInterval = interval
+	p.seriesMaxBuckets = maxBuckets
+}
+
+/*
+TimeSeries returns the time spent in the named anchor for each of the latest
+recorded intervals, oldest first. It returns nil when the anchor is unknown or
+time series are disabled.
+*/
+func (p *Profiler) TimeSeries(anchorName string) []time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return nil
+	}
+
+	var anchor, exists = p.anchorsByName[key]
+	if !exists || anchor.series == nil {
+		return nil
+	}
+
+	var durations = make([]time.Duration, len(anchor.series.buckets))
+	for i, tscount := range anchor.series.buckets {
+		durations[i] = ticksToDuration(tscount)
+	}
+
+	return durations
+}
+
+// SetTimeSeries configures time series on the default profiler.
+func SetTimeSeries(interval time.Duration, maxBuckets int) {
+	defaultProfiler.SetTimeSeries(interval, maxBuckets)
+}
+
+// TimeSeries returns the named anchor's time series from the default profiler.
+func TimeSeries(anchorName string) []time.Duration {
+	return defaultProfiler.TimeSeries(anchorName)
+}
@@ -0,0 +1,59 @@
+package timer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimeSeriesAdd(t *testing.T) {
+	type period struct {
+		end     int64
+		tscount int64
+	}
+
+	var tests = []struct {
+		name       string
+		maxBuckets int
+		periods    []period
+		first      int64
+		want       []int64
+	}{
+		{"single interval", 4, []period{{5, 3}}, 0, []int64{3}},
+		{"spanning intervals", 4, []period{{25, 20}}, 0, []int64{5, 10, 5}},
+		{"ending on a boundary", 4, []period{{20, 10}}, 0, []int64{0, 10}},
+		{"window sliding", 3, []period{{5, 5}, {35, 10}}, 1, []int64{0, 5, 5}},
+		{"long idle gap", 3, []period{{5, 5}, {1000000005, 5}}, 99999998, []int64{0, 0, 5}},
+		{"older than the window", 2, []period{{45, 5}, {5, 5}}, 3, []int64{0, 5}},
+		{"partly older than the window", 2, []period{{45, 5}, {35, 20}}, 3, []int64{5, 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var series timeSeries
+			for _, period := range test.periods {
+				series.add(period.end, period.tscount, 10, test.maxBuckets)
+			}
+
+			if series.first != test.first || !reflect.DeepEqual(series.buckets, test.want) {
+				t.Errorf("buckets %v from %d, want %v from %d", series.buckets, series.first, test.want,
+					test.first)
+			}
+		})
+	}
+}
+
+func TestTimeSeriesSplitsLongHits(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.SetTimeSeries(time.Millisecond, 10)
+
+	p.Start("long")
+	clock.advance(2500000)
+	p.Stop("long")
+
+	var want = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond / 2}
+	if got := p.TimeSeries("long"); !reflect.DeepEqual(got, want) {
+		t.Errorf("time series %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package timer
+
+/*
+DisableAnchor stops recording the named anchor: its Start and Stop calls
+become no-ops, neither allocating timings nor accumulating statistics, while
+an already registered anchor stays in the report. The time it would have
+measured is counted by its parent instead.
+*/
+func (p *Profiler) DisableAnchor(anchorName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return
+	}
+
+	if p.disabledAnchors == nil {
+		p.disabledAnchors = make(map[string]bool)
+	}
+
+	p.disabledAnchors[key] = true
+}
+
+// EnableAnchor resumes recording an anchor disabled by DisableAnchor.
+func (p *Profiler) EnableAnchor(anchorName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return
+	}
+
+	delete(p.disabledAnchors, key)
+}
+
+// DisableAnchor stops recording an anchor of the default profiler.
+func DisableAnchor(anchorName string) {
+	defaultProfiler.DisableAnchor(anchorName)
+}
+
+// EnableAnchor resumes recording an anchor of the default profiler.
+func EnableAnchor(anchorName string) {
+	defaultProfiler.EnableAnchor(anchorName)
+}
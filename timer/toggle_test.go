@@ -0,0 +1,45 @@
+package timer
+
+import "testing"
+
+func TestDisableAnchor(t *testing.T) {
+	var clock = useFakeClock(t)
+	var captured = captureWarnings(t)
+	var p = New()
+
+	var run = func() {
+		p.Start("parent")
+		clock.advance(10)
+		p.Start("child")
+		clock.advance(100)
+		p.Stop("child")
+		p.Stop("parent")
+	}
+
+	run()
+	p.DisableAnchor("child")
+	run()
+
+	// Kept in the report, its time counted by the parent
+	var report = p.Snapshot()
+	var parent, child = resultOf(t, report, "parent"), resultOf(t, report, "child")
+	if child.Hits != 1 || child.TSCount != 100 || parent.Hits != 2 || parent.TSCount != 120 {
+		t.Errorf("child %d hits of %d units, parent %d of %d, want 1 of 100 and 2 of 120",
+			child.Hits, child.TSCount, parent.Hits, parent.TSCount)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		p.Start("child")
+		p.Stop("child")
+	}); allocs != 0 {
+		t.Errorf("%v allocations per disabled Start and Stop", allocs)
+	}
+
+	p.EnableAnchor("child")
+	run()
+	if child := resultOf(t, p.Snapshot(), "child"); child.Hits != 2 || child.TSCount != 200 {
+		t.Errorf("child %d hits of %d units once enabled, want 2 of 200", child.Hits, child.TSCount)
+	}
+	if warning := captured.String(); warning != "" {
+		t.Errorf("unexpected warning %q", warning)
+	}
+}
@@ -0,0 +1,39 @@
+package timer
+
+// totalHits sums the hits of every anchor.
+func (p *Profiler) totalHits() int64 {
+	var hits int64
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		hits = hits + anchor.hits
+	}
+
+	return hits
+}
+
+// TotalHits returns the number of Start calls recorded across all anchors,
+// e.g. to check that the instrumentation fired as often as expected.
+func (p *Profiler) TotalHits() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.totalHits()
+}
+
+// TotalBytes returns the bytes processed across all anchors.
+func (p *Profiler) TotalBytes() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var bytes, _ = p.totalThroughput()
+	return bytes
+}
+
+// TotalHits returns the Start calls recorded by the default profiler.
+func TotalHits() int64 {
+	return defaultProfiler.TotalHits()
+}
+
+// TotalBytes returns the bytes processed across the default profiler anchors.
+func TotalBytes() int64 {
+	return defaultProfiler.TotalBytes()
+}
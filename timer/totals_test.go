@@ -0,0 +1,39 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTotalHitsAndBytes(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+
+	if hits, bytes := p.TotalHits(), p.TotalBytes(); hits != 0 || bytes != 0 {
+		t.Errorf("%d hits and %d bytes before any Start", hits, bytes)
+	}
+
+	p.StartThroughput("read", 1024)
+	p.Start("parse")
+	clock.advance(10)
+	p.Stop("parse")
+	p.Stop("read")
+	p.StartThroughput("read", 2048)
+	clock.advance(10)
+	p.Stop("read")
+
+	if hits, bytes := p.TotalHits(), p.TotalBytes(); hits != 3 || bytes != 3072 {
+		t.Errorf("%d hits and %d bytes, want 3 and 3072", hits, bytes)
+	}
+
+	var report = p.Snapshot()
+	if report.Hits != 3 || report.Bytes != 3072 {
+		t.Errorf("report: %d hits and %d bytes, want 3 and 3072", report.Hits, report.Bytes)
+	}
+	if merged := Merge(report, report); merged.Hits != 6 {
+		t.Errorf("merged: %d hits, want 6", merged.Hits)
+	}
+	if text := output(p); !strings.Contains(text, "-- calls: 3\n") {
+		t.Errorf("report header doesn't show the total calls:\n%s", text)
+	}
+}
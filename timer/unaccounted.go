@@ -0,0 +1,77 @@
+package timer
+
+import "time"
+
+// sumSubtrees sets the subtree time of every anchor, its time including its
+// children, without allocating. Parents being registered before their
+// children, a single pass from the latest anchor is enough.
+func (p *Profiler) sumSubtrees() {
+	var anchors = p.anchors[1 : p.index+1]
+	for _, anchor := range anchors {
+		anchor.subtree = 0
+	}
+
+	for i := len(anchors) - 1; i >= 0; i-- {
+		var anchor = anchors[i]
+		anchor.subtree = anchor.subtree + anchor.tscount
+		if anchor.parent != nil {
+			anchor.parent.subtree = anchor.parent.subtree + anchor.subtree
+		}
+	}
+}
+
+// percentOfParent returns the subtree time of the anchor relative to the
+// subtree time of its parent, or to the total for a top-level anchor, as set
+// by the latest sumSubtrees.
+func (p *Profiler) percentOfParent(anchor *anchor) float64 {
+	var whole = p.totalAnchor.tscount
+	if anchor.parent != nil {
+		whole = anchor.parent.subtree
+	}
+
+	if whole <= 0 || anchor == p.totalAnchor {
+		return 0
+	}
+
+	return 100 * float64(anchor.subtree) / float64(whole)
+}
+
+// unaccountedTSCount returns the part of the total outside any top-level
+// anchor not excluded from the total, and whether it had to be clamped from a
+// negative value.
+func (p *Profiler) unaccountedTSCount() (int64, bool) {
+	var unaccounted = p.totalAnchor.tscount
+	p.sumSubtrees()
+
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		if anchor.parent == nil && !p.excludedFromTotal[anchor.name] {
+			unaccounted = unaccounted - anchor.subtree
+		}
+	}
+
+	// Overhead and externally recorded durations may slightly exceed the
+	// total
+	if unaccounted < 0 {
+		return 0, true
+	}
+
+	return unaccounted, false
+}
+
+/*
+Unaccounted returns the part of the total elapsed time spent outside of any
+top-level anchor, i.e. in code not instrumented yet. It is clamped to zero
+when the anchors slightly exceed the total because of the profiler overhead.
+*/
+func (p *Profiler) Unaccounted() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var unaccounted, _ = p.unaccountedTSCount()
+	return ticksToDuration(unaccounted)
+}
+
+// Unaccounted returns the uninstrumented time of the default profiler.
+func Unaccounted() time.Duration {
+	return defaultProfiler.Unaccounted()
+}
@@ -0,0 +1,60 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnaccounted(t *testing.T) {
+	var tests = []struct {
+		name string
+		run  func(p *Profiler, clock *fakeClock)
+		want time.Duration
+		note bool
+	}{
+		{"fully instrumented", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			p.Start("b")
+			clock.advance(100)
+			p.Stop("b")
+			p.Stop("a")
+		}, 0, false},
+		{"between anchors", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(100)
+			p.Stop("a")
+			clock.advance(300)
+			p.Start("b")
+			clock.advance(100)
+			p.Stop("b")
+		}, 300, false},
+		// Recorded durations aren't part of the measured total
+		{"exceeding the total", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(100)
+			p.Stop("a")
+			p.RecordDuration("b", time.Microsecond)
+		}, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			test.run(p, clock)
+
+			if got := p.Unaccounted(); got != test.want {
+				t.Errorf("unaccounted %v, want %v", got, test.want)
+			}
+			var text = output(p)
+			if !strings.Contains(text, "unaccounted:") {
+				t.Errorf("report doesn't show the unaccounted time:\n%s", text)
+			}
+			if strings.Contains(text, "clamped") != test.note {
+				t.Errorf("clamped = %v, want %v:\n%s", !test.note, test.note, text)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package timer
+
+import "fmt"
+
+/*
+ByteUnits selects the units of the byte counts and throughputs of Output.
+
+BinaryUnits, the default, divides by powers of 1024 and labels them KiB, MiB
+and GiB. DecimalUnits divides by powers of 1000 and labels them KB, MB and GB,
+as the MB/s of Go benchmarks and most network and disk tools do.
+*/
+type ByteUnits int
+
+const (
+	BinaryUnits ByteUnits = iota
+	DecimalUnits
+)
+
+// SetByteUnits changes the units of the byte counts and throughputs of Output.
+func (p *Profiler) SetByteUnits(units ByteUnits) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.byteUnits = units
+}
+
+// unit returns the size of the unit of the given power, 1 for kilo, 2 for
+// mega and 3 for giga, and its label.
+func (u ByteUnits) unit(power int) (float64, string) {
+	var base, prefixes, suffix = 1024.0, "KMG", "iB"
+	if u == DecimalUnits {
+		base, suffix = 1000, "B"
+	}
+
+	var size = 1.0
+	for i := 0; i < power; i++ {
+		size = size * base
+	}
+
+	return size, prefixes[power-1:power] + suffix
+}
+
+// megabytes formats bytes in mega units, padded for the Output columns.
+func (u ByteUnits) megabytes(bytes int64) string {
+	var size, label = u.unit(2)
+	return fmt.Sprintf("%7.2f%s", float64(bytes)/size, label)
+}
+
+// gigabytesPerSecond formats a throughput in bytes per second in giga units.
+func (u ByteUnits) gigabytesPerSecond(bytesPerSecond float64) string {
+	var size, label = u.unit(3)
+	return fmt.Sprintf("%5.3f%s/s", bytesPerSecond/size, label)
+}
+
+// formatSize formats a number of bytes with the largest unit it reaches.
+func (u ByteUnits) formatSize(bytes float64) string {
+	for power := 3; power > 0; power-- {
+		if size, label := u.unit(power); bytes >= size {
+			return fmt.Sprintf("%.2f%s", bytes/size, label)
+		}
+	}
+
+	return fmt.Sprintf("%.0fB", bytes)
+}
+
+// SetByteUnits changes the units of the default profiler Output.
+func SetByteUnits(units ByteUnits) {
+	defaultProfiler.SetByteUnits(units)
+}
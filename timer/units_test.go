@@ -0,0 +1,48 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestByteUnits(t *testing.T) {
+	var tests = []struct {
+		units      ByteUnits
+		megabytes  string
+		throughput string
+		sizes      []string
+	}{
+		{BinaryUnits, "   2.00MiB", "0.002GiB/s", []string{"512B", "1.00KiB", "1.50MiB", "2.00GiB"}},
+		{DecimalUnits, "   2.10MB", "0.002GB/s", []string{"512B", "1.02KB", "1.57MB", "2.15GB"}},
+	}
+
+	for _, test := range tests {
+		if got := test.units.megabytes(2 * 1024 * 1024); got != test.megabytes {
+			t.Errorf("units %d: megabytes %q, want %q", test.units, got, test.megabytes)
+		}
+		if got := test.units.gigabytesPerSecond(2 * 1024 * 1024); got != test.throughput {
+			t.Errorf("units %d: throughput %q, want %q", test.units, got, test.throughput)
+		}
+		for i, bytes := range []float64{512, 1024, 1.5 * 1024 * 1024, 2 * 1024 * 1024 * 1024} {
+			if got := test.units.formatSize(bytes); got != test.sizes[i] {
+				t.Errorf("units %d: size of %v %q, want %q", test.units, bytes, got, test.sizes[i])
+			}
+		}
+	}
+}
+
+func TestSetByteUnits(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.StartThroughput("read", 4000000)
+	clock.advance(1000000000)
+	p.Stop("read")
+
+	if text := output(p); !strings.Contains(text, "3.81MiB at 0.004GiB/s") {
+		t.Errorf("report doesn't use binary units by default:\n%s", text)
+	}
+	p.SetByteUnits(DecimalUnits)
+	if text := output(p); !strings.Contains(text, "4.00MB at 0.004GB/s") {
+		t.Errorf("report doesn't use decimal units:\n%s", text)
+	}
+}
@@ -0,0 +1,185 @@
+package timer
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Validate checks the consistency of the internal state of the profiler, for
+use in tests and while debugging the recursion and hierarchy bookkeeping:
+every registered anchor is found by its name, its parent was registered before
+it and its depth is that of its parent plus one, the parent chains and the
+chain of open timings have no cycle, and the open timings match the open
+count of their anchor. It returns nil when the state is consistent, or an
+*AnchorError wrapping ErrInconsistentState describing the first violation
+found. The anchor times must be non-negative and add up to no more than the
+total, each CPU timer unit being counted once, leaving out the durations of
+RecordDuration, RecordEvent and RecordSpan and the calls estimated by
+StartSampled, which were not measured within the total.
+
+Once the state is found consistent, Validate also checks that every Start was
+balanced by its Stop, e.g. at the end of a run or of a test: when anchors are
+still open, it returns an *AnchorError for the innermost one wrapping
+ErrUnbalanced, listing all the open anchors. Called while anchors are
+legitimately open, errors.Is(err, ErrInconsistentState) tells the two apart.
+Validate doesn't modify the profiler.
+*/
+func (p *Profiler) Validate() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.validate()
+}
+
+func (p *Profiler) validate() error {
+	if p.anchors == nil {
+		// Never started
+		return nil
+	}
+
+	var inconsistent = func(anchorName string, format string, args ...interface{}) error {
+		return &AnchorError{Op: "validate", Anchor: anchorName,
+			Err: fmt.Errorf("%w: "+format, append([]interface{}{ErrInconsistentState}, args...)...)}
+	}
+
+	if p.index < 0 || p.index >= len(p.anchors) {
+		return inconsistent("", "index %d out of the %d anchor slots", p.index, len(p.anchors))
+	}
+
+	if len(p.anchorsByName) != p.index {
+		return inconsistent("", "%d anchors registered but %d found by name", p.index, len(p.anchorsByName))
+	}
+
+	// Position of each anchor in the registration order
+	var positions = make(map[*anchor]int, p.index)
+	var open int64
+	for i, anchor := range p.anchors[1 : p.index+1] {
+		if anchor == nil {
+			return inconsistent("", "anchor slot %d is empty", i+1)
+		}
+
+		if p.anchorsByName[anchor.name] != anchor {
+			return inconsistent(anchor.name, "not found by its name")
+		}
+
+		var expectedDepth int64
+		if anchor.parent != nil {
+			var parentPosition, registered = positions[anchor.parent]
+			if !registered {
+				// Also catches a cycle, its first anchor having a parent
+				// registered after it
+				return inconsistent(anchor.name, "parent %q not registered before it", anchor.parent.name)
+			}
+			if parentPosition >= i {
+				return inconsistent(anchor.name, "parent %q registered after it", anchor.parent.name)
+			}
+			expectedDepth = anchor.parent.depth + 1
+		}
+
+		if anchor.depth != expectedDepth {
+			return inconsistent(anchor.name, "depth %d, expected %d from its parent chain", anchor.depth, expectedDepth)
+		}
+
+		if anchor.hits < 0 || anchor.tscount < 0 || anchor.open < 0 {
+			return inconsistent(anchor.name, "negative counters: %d hits, %d tscount, %d open", anchor.hits,
+				anchor.tscount, anchor.open)
+		}
+
+		if anchor.counted > anchor.hits {
+			return inconsistent(anchor.name, "%d counted hits out of %d", anchor.counted, anchor.hits)
+		}
+
+		if anchor.open > 0 && (anchor.latest == nil || anchor.latest.anchor != anchor) {
+			return inconsistent(anchor.name, "open without a timing of its own")
+		}
+
+		positions[anchor] = i
+		open = open + anchor.open
+	}
+
+	if p.totalAnchor != nil && p.totalAnchor.tscount < 0 {
+		return inconsistent(p.totalAnchor.name, "negative tscount %d", p.totalAnchor.tscount)
+	}
+
+	// Walk the open timings, at most openDepth of them
+	var timings int64
+	for current := p.currentTiming; current != nil; current = current.previous {
+		if current.anchor == nil {
+			return inconsistent("", "open timing without an anchor")
+		}
+
+		timings = timings + 1
+		if timings > p.openDepth {
+			return inconsistent(current.anchor.name, "more open timings than the %d open anchors, or a cycle",
+				p.openDepth)
+		}
+
+		if current.anchor.open <= 0 {
+			return inconsistent(current.anchor.name, "open timing of an anchor not open")
+		}
+	}
+
+	if timings != p.openDepth || open != p.openDepth {
+		return inconsistent("", "%d open timings and %d open hits for a depth of %d", timings, open, p.openDepth)
+	}
+
+	if measured, total := p.measuredTSCount(); measured > total {
+		return inconsistent(p.totalAnchor.name, "anchors measured %d CPU timer units within a total of %d",
+			measured, total)
+	}
+
+	return p.validateBalanced()
+}
+
+// measuredTSCount returns the CPU timer units measured by the anchors and the
+// most they can add up to, the total up to now. Both are zero once the clock
+// went backwards, the clamped readings making any sum plausible.
+func (p *Profiler) measuredTSCount() (int64, int64) {
+	if p.clockAnomalies > 0 {
+		return 0, 0
+	}
+
+	var measured = -p.externalTSCount
+	for _, anchor := range p.anchors[1 : p.index+1] {
+		measured = measured + anchor.tscount
+	}
+
+	// The total is only updated by Stop, while Start adds the time of the
+	// anchor it pauses
+	var total = p.totalAnchor.tscount
+	if p.totalTiming.start != 0 {
+		if running := readCPUTimer() - p.totalTiming.start; running > total {
+			total = running
+		}
+	}
+
+	return measured, total
+}
+
+// validateBalanced returns an error listing the open anchors, innermost
+// first, nil if none.
+func (p *Profiler) validateBalanced() error {
+	if p.currentTiming == nil && p.currentAnchor == nil {
+		return nil
+	}
+
+	var names []string
+	for current := p.currentTiming; current != nil; current = current.previous {
+		names = append(names, current.anchor.name)
+	}
+
+	if len(names) == 0 {
+		return &AnchorError{Op: "validate", Anchor: p.currentAnchor.name,
+			Err: fmt.Errorf("%w: current anchor set without an open timing", ErrUnbalanced)}
+	}
+
+	return &AnchorError{Op: "validate", Anchor: names[0],
+		Err: fmt.Errorf("%w: %d open, innermost first: %s", ErrUnbalanced, len(names), strings.Join(names, ", "))}
+}
+
+// Validate checks the consistency of the internal state of the default
+// profiler.
+func Validate() error {
+	return defaultProfiler.Validate()
+}
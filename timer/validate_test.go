@@ -0,0 +1,213 @@
+package timer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validateFixture records a parent with a child, left open when open is set.
+func validateFixture(p *Profiler, clock *fakeClock, open bool) {
+	p.Start("parent")
+	clock.advance(10)
+	p.Start("child")
+	clock.advance(20)
+	if open {
+		return
+	}
+	p.Stop("child")
+	clock.advance(5)
+	p.Stop("parent")
+}
+
+func TestValidateDetectsCorruption(t *testing.T) {
+	var tests = []struct {
+		name    string
+		open    bool
+		corrupt func(p *Profiler)
+	}{
+		{"parent registered after", false, func(p *Profiler) {
+			p.anchorsByName["parent"].parent = p.anchorsByName["child"]
+		}},
+		{"depth", false, func(p *Profiler) {
+			p.anchorsByName["child"].depth = 5
+		}},
+		{"name lookup", false, func(p *Profiler) {
+			delete(p.anchorsByName, "child")
+		}},
+		{"negative counters", false, func(p *Profiler) {
+			p.anchorsByName["child"].hits = -1
+		}},
+		{"tscount beyond the total", false, func(p *Profiler) {
+			p.anchorsByName["child"].tscount += 1000
+		}},
+		{"cycle in the open timings", true, func(p *Profiler) {
+			p.currentTiming.previous.previous = p.currentTiming
+		}},
+		{"open count", true, func(p *Profiler) {
+			p.anchorsByName["parent"].open = 0
+		}},
+		{"timing of another anchor", true, func(p *Profiler) {
+			p.anchorsByName["child"].latest = p.currentTiming.previous
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			validateFixture(p, clock, test.open)
+			test.corrupt(p)
+
+			var err = p.Validate()
+			if !errors.Is(err, ErrInconsistentState) {
+				t.Fatalf("Validate = %v, want ErrInconsistentState", err)
+			}
+			var anchorErr *AnchorError
+			if !errors.As(err, &anchorErr) || anchorErr.Op != "validate" {
+				t.Errorf("Validate = %v, want an *AnchorError", err)
+			}
+		})
+	}
+}
+
+func TestValidateAcceptsUnmeasuredTime(t *testing.T) {
+	var tests = []struct {
+		name string
+		run  func(p *Profiler, clock *fakeClock)
+	}{
+		{"recorded duration", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(10)
+			p.RecordDuration("db", time.Second)
+			p.Stop("a")
+		}},
+		{"recorded span", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(10)
+			p.Stop("a")
+			p.RecordSpan("span", 0, 1000000)
+		}},
+		{"sampled calls", func(p *Profiler, clock *fakeClock) {
+			for i := 0; i < 100; i++ {
+				p.StartSampled("hot", 10)
+				clock.advance(10)
+				p.StopSampled("hot")
+			}
+		}},
+		{"clock going backwards", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(100)
+			p.Stop("a")
+			clock.set(1)
+		}},
+		{"counters reset", func(p *Profiler, clock *fakeClock) {
+			p.RecordDuration("db", time.Second)
+			p.Start("a")
+			p.ResetCounters()
+			clock.advance(10)
+			p.Stop("a")
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			test.run(p, clock)
+
+			if err := p.Validate(); err != nil {
+				t.Errorf("Validate = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestValidateBalance(t *testing.T) {
+	var tests = []struct {
+		name  string
+		run   func(p *Profiler, clock *fakeClock)
+		want  error
+		inner string
+		open  string
+	}{
+		{"never started", func(p *Profiler, clock *fakeClock) {}, nil, "", ""},
+		{"balanced", func(p *Profiler, clock *fakeClock) {
+			validateFixture(p, clock, false)
+		}, nil, "", ""},
+		{"child left open", func(p *Profiler, clock *fakeClock) {
+			validateFixture(p, clock, true)
+		}, ErrUnbalanced, "child", "child, parent"},
+		{"parent left open", func(p *Profiler, clock *fakeClock) {
+			p.Start("parent")
+			p.Start("child")
+			p.Stop("child")
+		}, ErrUnbalanced, "parent", "parent"},
+		{"recursion left open", func(p *Profiler, clock *fakeClock) {
+			p.Start("recurse")
+			p.Start("recurse")
+			p.Stop("recurse")
+		}, ErrUnbalanced, "recurse", "recurse"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			test.run(p, clock)
+
+			var err = p.Validate()
+			if test.want == nil {
+				if err != nil {
+					t.Errorf("Validate = %v, want nil", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrUnbalanced) || errors.Is(err, ErrInconsistentState) {
+				t.Fatalf("Validate = %v, want ErrUnbalanced only", err)
+			}
+			var anchorErr *AnchorError
+			if !errors.As(err, &anchorErr) || anchorErr.Anchor != test.inner {
+				t.Errorf("Validate = %v, want an *AnchorError naming %q", err, test.inner)
+			}
+			if !strings.Contains(err.Error(), "innermost first: "+test.open) {
+				t.Errorf("Validate = %v, want the open anchors %s", err, test.open)
+			}
+		})
+	}
+}
+
+func TestValidateKeepsState(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	validateFixture(p, clock, true)
+
+	var snapshot = func() Report {
+		var report = p.Snapshot()
+		report.Meta.Generated = time.Time{}
+		return report
+	}
+	var before = snapshot()
+	var current, depth = p.currentTiming, p.openDepth
+
+	for i := 0; i < 3; i++ {
+		p.Validate()
+	}
+
+	if after := snapshot(); !reflect.DeepEqual(before, after) {
+		t.Errorf("report changed by Validate:\n%+v\n%+v", before, after)
+	}
+	if p.currentTiming != current || p.openDepth != depth {
+		t.Error("open timings changed by Validate")
+	}
+
+	// The open anchors still stop normally
+	p.Stop("child")
+	p.Stop("parent")
+	if err := p.Validate(); err != nil {
+		t.Errorf("Validate = %v after stopping the open anchors", err)
+	}
+}
@@ -0,0 +1,82 @@
+package timer
+
+import "math"
+
+// variation accumulates the mean and the sum of squared deviations of the
+// per hit CPU timer units, using Welford's algorithm to stay numerically
+// stable over many hits.
+type variation struct {
+	count int64
+	mean  float64
+	m2    float64
+}
+
+func (v *variation) add(tscount float64) {
+	v.count = v.count + 1
+	var delta = tscount - v.mean
+	v.mean = v.mean + delta/float64(v.count)
+	v.m2 = v.m2 + delta*(tscount-v.mean)
+}
+
+// stdDev returns the sample standard deviation, in CPU timer units.
+func (v *variation) stdDev() float64 {
+	if v.count < 2 {
+		return 0
+	}
+
+	return math.Sqrt(v.m2 / float64(v.count-1))
+}
+
+// cv returns the coefficient of variation, the standard deviation relative
+// to the mean.
+func (v *variation) cv() float64 {
+	if v.mean == 0 {
+		return 0
+	}
+
+	return v.stdDev() / v.mean
+}
+
+// hitRange tracks the least and most CPU timer units of a single hit.
+type hitRange struct {
+	count int64
+	min   int64
+	max   int64
+}
+
+func (r *hitRange) add(tscount int64) {
+	if r.count == 0 || tscount < r.min {
+		r.min = tscount
+	}
+	if tscount > r.max {
+		r.max = tscount
+	}
+	r.count = r.count + 1
+}
+
+func nonNegative(tscount int64) int64 {
+	if tscount < 0 {
+		return 0
+	}
+
+	return tscount
+}
+
+/*
+SetDeviationStats adds the standard deviation of the time per hit and its
+coefficient of variation to each anchor line of Output. A high coefficient of
+variation flags a noisy measurement. Both are recorded whether the column is
+shown or not, and are available in AnchorResult.
+*/
+func (p *Profiler) SetDeviationStats(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.deviationStats = enabled
+}
+
+// SetDeviationStats shows the time deviation of the default profiler
+// anchors.
+func SetDeviationStats(enabled bool) {
+	defaultProfiler.SetDeviationStats(enabled)
+}
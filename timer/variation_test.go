@@ -0,0 +1,123 @@
+package timer
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestHitRange(t *testing.T) {
+	const ms = testFrequency / 1000
+
+	var tests = []struct {
+		name         string
+		run          func(p *Profiler, clock *fakeClock)
+		min, max     float64
+		mean         float64
+		rangeOfCalls string
+	}{
+		{"uneven hits", func(p *Profiler, clock *fakeClock) {
+			for _, hit := range []int64{1, 3, 2} {
+				p.Start("a")
+				clock.advance(hit * ms)
+				p.Stop("a")
+			}
+		}, 1, 3, 2, "range: 1.000ms - 3.000ms"},
+		{"single hit", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(2 * ms)
+			p.Stop("a")
+		}, 2, 2, 2, ""},
+		// The outer call only adds its own 1ms + 1ms, not a separate hit
+		{"recursion", func(p *Profiler, clock *fakeClock) {
+			p.Start("a")
+			clock.advance(ms)
+			p.Start("a")
+			clock.advance(4 * ms)
+			p.Stop("a")
+			clock.advance(ms)
+			p.Stop("a")
+		}, 4, 4, 3, ""},
+		// Own time, without the child
+		{"with a child", func(p *Profiler, clock *fakeClock) {
+			for _, hit := range []int64{1, 2} {
+				p.Start("a")
+				clock.advance(hit * ms)
+				p.Start("child")
+				clock.advance(10 * ms)
+				p.Stop("child")
+				p.Stop("a")
+			}
+		}, 1, 2, 1.5, "range: 1.000ms - 2.000ms"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			test.run(p, clock)
+
+			var result = resultOf(t, p.Snapshot(), "a")
+			if result.MinHit != test.min || result.MaxHit != test.max || result.Mean != test.mean {
+				t.Errorf("hits from %vms to %vms, mean %vms, want %vms to %vms, mean %vms", result.MinHit,
+					result.MaxHit, result.Mean, test.min, test.max, test.mean)
+			}
+
+			var line = strings.SplitAfter(output(p), "a: ")[1]
+			line = line[:strings.Index(line, "\n")]
+			if got := strings.Contains(line, "range: "); got != (test.rangeOfCalls != "") ||
+				!strings.Contains(line, test.rangeOfCalls) {
+				t.Errorf("line of a %q, want %q", line, test.rangeOfCalls)
+			}
+		})
+	}
+}
+
+func TestVariation(t *testing.T) {
+	var tests = []struct {
+		name   string
+		values []float64
+		stdDev float64
+		cv     float64
+	}{
+		{"none", nil, 0, 0},
+		{"single", []float64{5}, 0, 0},
+		{"constant", []float64{5, 5, 5}, 0, 0},
+		{"spread", []float64{4, 7, 13, 16}, math.Sqrt(30), math.Sqrt(30) / 10},
+		// Catastrophic cancellation of the naive sum of squares
+		{"large offset", []float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16}, math.Sqrt(30), math.Sqrt(30) / (1e9 + 10)},
+	}
+
+	for _, test := range tests {
+		var v variation
+		for _, value := range test.values {
+			v.add(value)
+		}
+		if math.Abs(v.stdDev()-test.stdDev) > 1e-9 || math.Abs(v.cv()-test.cv) > 1e-12 {
+			t.Errorf("%s: stddev %v, cv %v, want %v and %v", test.name, v.stdDev(), v.cv(), test.stdDev, test.cv)
+		}
+	}
+}
+
+func TestDeviationStats(t *testing.T) {
+	const ms = testFrequency / 1000
+
+	for _, enabled := range []bool{false, true} {
+		var clock = useFakeClock(t)
+		var p = New()
+		p.SetDeviationStats(enabled)
+		for _, hit := range []int64{1, 3, 2} {
+			p.Start("a")
+			clock.advance(hit * ms)
+			p.Stop("a")
+		}
+
+		// Recorded whether shown or not
+		if result := resultOf(t, p.Snapshot(), "a"); result.StdDev != 1 || result.CV != 0.5 {
+			t.Errorf("stddev %vms, cv %v, want 1ms and 0.5", result.StdDev, result.CV)
+		}
+		if text := output(p); strings.Contains(text, "stddev: 1.000ms (cv: 0.50)") != enabled {
+			t.Errorf("deviation shown = %v, want %v:\n%s", !enabled, enabled, text)
+		}
+	}
+}
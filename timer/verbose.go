@@ -0,0 +1,67 @@
+package timer
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// Non-zero when the calibration diagnostics are printed
+	verbose int32
+
+	// Destination of the diagnostics set by SetVerboseOutput, nil for that of
+	// Output
+	verboseWriter      io.Writer
+	verboseWriterMutex sync.Mutex
+)
+
+/*
+SetVerbose prints diagnostics about the CPU timer calibration: the OS and CPU
+timer readings, the estimated or reported frequency and the cost of a timer
+read. They are written where Output writes by default, the standard output
+or the file named by TIMER_OUTPUT_ENV_VAR, unless SetVerboseOutput selects
+another destination. Setting TIMER_ENV_VAR to "verbose" enables it at startup.
+*/
+func SetVerbose(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&verbose, 1)
+	} else {
+		atomic.StoreInt32(&verbose, 0)
+	}
+}
+
+// SetVerboseOutput writes the diagnostics enabled by SetVerbose to w, e.g. the
+// writer given to OutputTo, nil restoring the default destination.
+func SetVerboseOutput(w io.Writer) {
+	verboseWriterMutex.Lock()
+	defer verboseWriterMutex.Unlock()
+
+	verboseWriter = w
+}
+
+// verboseEnabled reports whether diagnostics are printed, so that callers
+// only compute them when needed.
+func verboseEnabled() bool {
+	return atomic.LoadInt32(&verbose) != 0
+}
+
+// verbosef writes a diagnostic line when SetVerbose is enabled.
+func verbosef(format string, args ...interface{}) {
+	if !verboseEnabled() {
+		return
+	}
+
+	verboseWriterMutex.Lock()
+	defer verboseWriterMutex.Unlock()
+
+	var w = verboseWriter
+	if w == nil {
+		var destination, closeDestination = outputDestination()
+		defer closeDestination()
+		w = destination
+	}
+
+	fmt.Fprintf(w, format, args...)
+}
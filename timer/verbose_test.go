@@ -0,0 +1,66 @@
+package timer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetVerbose(t *testing.T) {
+	t.Cleanup(func() {
+		SetVerbose(false)
+		SetVerboseOutput(nil)
+	})
+
+	var buffer bytes.Buffer
+	SetVerboseOutput(&buffer)
+	verbosef("line %d\n", 1)
+	if buffer.Len() != 0 {
+		t.Errorf("wrote %q while not verbose", buffer.String())
+	}
+
+	SetVerbose(true)
+	verbosef("line %d\n", 2)
+	if got := buffer.String(); got != "line 2\n" {
+		t.Errorf("wrote %q, want %q", got, "line 2\n")
+	}
+
+	// Back to the destination of Output
+	var path = filepath.Join(t.TempDir(), "report.txt")
+	t.Setenv(TIMER_OUTPUT_ENV_VAR, path)
+	SetVerboseOutput(nil)
+	verbosef("line %d\n", 3)
+	if data, err := os.ReadFile(path); err != nil || string(data) != "line 3\n" {
+		t.Errorf("TIMER_OUTPUT holds %q (%v), want %q", data, err, "line 3\n")
+	}
+}
+
+func TestVerboseCalibration(t *testing.T) {
+	// Measured on the real timer before the test clocks
+	ReadTimerOverhead()
+	t.Cleanup(func() {
+		SetVerbose(false)
+		SetVerboseOutput(nil)
+	})
+
+	var buffer bytes.Buffer
+	SetVerboseOutput(&buffer)
+	SetVerbose(true)
+
+	// The CPU timer running three times as fast as the OS timer
+	var osTicks int64
+	var frequency = measureCPUTimerFreq(func() int64 {
+		return 3 * osTicks
+	}, func() int64 {
+		osTicks = osTicks + 1000
+		return osTicks
+	}, 1000000, 10)
+	for _, line := range []string{"   OS Freq: 1000000 (reported)\n",
+		fmt.Sprintf("  CPU freq: %d (estimated)\n", frequency), "Timer read: "} {
+		if !bytes.Contains(buffer.Bytes(), []byte(line)) {
+			t.Errorf("diagnostics don't contain %q:\n%s", line, buffer.String())
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package timer
+
+/*
+SetWallTime records the OS timer alongside the CPU timer in Start and Stop,
+so that Output shows, for each anchor, the wall time between its outermost
+Start and Stop next to the CPU timer time, both including children. It costs
+an extra OS timer read per call and is disabled by default.
+
+The CPU timer keeps counting while the goroutine is blocked, like the OS
+timer: a significant gap between both columns points at an unstable CPU timer
+frequency or an inaccurate calibration rather than at time spent waiting.
+*/
+func (p *Profiler) SetWallTime(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.wallTime = enabled
+}
+
+// SetWallTime records wall time for the default profiler anchors.
+func SetWallTime(enabled bool) {
+	defaultProfiler.SetWallTime(enabled)
+}
@@ -0,0 +1,69 @@
+package timer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWallThroughput(t *testing.T) {
+	var tests = []struct {
+		name    string
+		enabled bool
+		base    string
+	}{
+		{"cpu", false, "(cpu"},
+		{"wall", true, "(wall)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			captureWarnings(t)
+			var p = New()
+			p.SetWallThroughput(test.enabled)
+
+			p.StartThroughput("read", 1<<20)
+			// Blocked, the CPU timer barely moves
+			time.Sleep(5 * time.Millisecond)
+			clock.advance(1000)
+			p.Stop("read")
+
+			var text = output(p)
+			if !strings.Contains(text, test.base) {
+				t.Errorf("throughput not computed over %s time:\n%s", test.name, text)
+			}
+
+			var wall = resultOf(t, p.Snapshot(), "read").Wall
+			if test.enabled && wall < 5*time.Millisecond {
+				t.Errorf("wall time %v, want at least the 5ms slept", wall)
+			}
+			if !test.enabled && wall != 0 {
+				t.Errorf("wall time %v recorded while disabled", wall)
+			}
+		})
+	}
+}
+
+func TestWallTime(t *testing.T) {
+	var clock = useFakeClock(t)
+	var p = New()
+	p.SetWallTime(true)
+
+	p.Start("parent")
+	p.Start("child")
+	time.Sleep(5 * time.Millisecond)
+	clock.advance(5000000)
+	p.Stop("child")
+	p.Stop("parent")
+
+	// Both including the children
+	for _, name := range []string{"parent", "child"} {
+		if wall := resultOf(t, p.Snapshot(), name).Wall; wall < 5*time.Millisecond {
+			t.Errorf("%s: wall time %v, want at least the 5ms slept", name, wall)
+		}
+	}
+	if text := output(p); strings.Count(text, " / cpu:      5.000ms (incl.)") != 2 {
+		t.Errorf("CPU time not shown next to the wall time of both anchors:\n%s", text)
+	}
+}
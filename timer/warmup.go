@@ -0,0 +1,38 @@
+package timer
+
+/*
+SetWarmup makes the first n hits of the named anchor warm-up hits: they are
+counted in its hits, but neither their time, bytes, wall time nor allocations
+are recorded, so that cold caches and lazy initializations don't skew its
+statistics. The warm-up hits are counted once over the life of the profiler,
+across resets and including hits made before the call.
+
+The time of the warm-up hits is still part of the total, and is not given back
+to the enclosing anchor either: it shows as unaccounted.
+*/
+func (p *Profiler) SetWarmup(anchorName string, n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key, err = p.anchorKey(anchorName)
+	if err != nil {
+		return
+	}
+
+	if n <= 0 {
+		delete(p.warmups, key)
+		return
+	}
+
+	if p.warmups == nil {
+		p.warmups = make(map[string]int64)
+	}
+
+	p.warmups[key] = int64(n)
+}
+
+// SetWarmup excludes the first hits of an anchor of the default profiler
+// from its statistics.
+func SetWarmup(anchorName string, n int) {
+	defaultProfiler.SetWarmup(anchorName, n)
+}
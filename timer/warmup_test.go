@@ -0,0 +1,50 @@
+package timer
+
+import "testing"
+
+func TestWarmupLeftOutOfElapsed(t *testing.T) {
+	var tests = []struct {
+		name   string
+		warmup int
+		hits   int
+	}{
+		{"no warm-up", 0, 5},
+		{"warm-up", 2, 5},
+		{"only warm-up", 5, 5},
+		{"longer warm-up", 8, 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = useFakeClock(t)
+			var p = New()
+			p.SetWarmup("a", test.warmup)
+
+			var steady int64
+			for i := 0; i < test.hits; i++ {
+				// Cold hits much slower than the steady ones
+				var ticks int64 = 10
+				if i < test.warmup {
+					ticks = 100000
+				} else {
+					steady = steady + ticks
+				}
+
+				p.Start("a")
+				clock.advance(ticks)
+				p.Stop("a")
+			}
+
+			var result = resultOf(t, p.Snapshot(), "a")
+			if result.Hits != int64(test.hits) {
+				t.Errorf("hits = %d, want %d", result.Hits, test.hits)
+			}
+			if result.TSCount != steady {
+				t.Errorf("tscount = %d, want %d", result.TSCount, steady)
+			}
+			if result.MaxHit > ticksToMilliseconds(10) {
+				t.Errorf("max hit = %vms, a warm-up hit was recorded", result.MaxHit)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package timer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+var warningHandler atomic.Value
+
+func init() {
+	SetWarningHandler(nil)
+}
+
+func writeWarningToStderr(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+}
+
+/*
+SetWarningHandler routes the warnings about profiler misuses, like stopping an
+unknown anchor or reaching the anchor limit, to handler instead of the default
+standard error output. Pass func(string) {} to ignore them, or nil to restore
+the default.
+*/
+func SetWarningHandler(handler func(msg string)) {
+	if handler == nil {
+		handler = writeWarningToStderr
+	}
+
+	warningHandler.Store(handler)
+}
+
+func warn(format string, args ...interface{}) {
+	var handler, set = warningHandler.Load().(func(string))
+	if !set {
+		// Warning from a package init running before the handler's
+		handler = writeWarningToStderr
+	}
+	handler(fmt.Sprintf(format, args...))
+}
+
+// warnError reports misuses detected by the error-returning variants on
+// behalf of their silent counterparts.
+func warnError(err error) {
+	if err == nil || errors.Is(err, ErrTooManyAnchors) {
+		// Warned once when the limit was reached
+		return
+	}
+
+	warn("%s", err)
+}
@@ -0,0 +1,73 @@
+package timer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWarningHandler(t *testing.T) {
+	var tests = []struct {
+		name string
+		run  func(p *Profiler)
+		want []string
+	}{
+		{"balanced", func(p *Profiler) {
+			p.Start("a")
+			p.Stop("a")
+		}, nil},
+		{"unknown anchor", func(p *Profiler) {
+			p.Stop("a")
+		}, []string{`timer: stop "a": unknown anchor`}},
+		// Warned once, not on every dropped anchor
+		{"anchor limit", func(p *Profiler) {
+			p.SetMaxAnchors(1)
+			for _, name := range []string{"a", "b", "c", "b"} {
+				p.Start(name)
+				p.Stop(name)
+			}
+		}, []string{"timer: 1 anchors reached, new anchors are no longer recorded"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useFakeClock(t)
+			var captured = captureWarnings(t)
+			test.run(New())
+
+			var messages = captured.messages
+			if len(messages) != len(test.want) {
+				t.Fatalf("warnings %q, want %d", messages, len(test.want))
+			}
+			for i, message := range messages {
+				if !strings.Contains(message, test.want[i]) {
+					t.Errorf("warning %q, want %q", message, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWarningHandlerDefault(t *testing.T) {
+	useFakeClock(t)
+	var file, err = os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var saved = os.Stderr
+	os.Stderr = file
+	defer func() { os.Stderr = saved }()
+
+	SetWarningHandler(func(string) {})
+	New().Stop("ignored")
+	SetWarningHandler(nil)
+	New().Stop("printed")
+
+	var data, _ = os.ReadFile(file.Name())
+	if got, want := string(data), "timer: stop \"printed\": unknown anchor\n"; got != want {
+		t.Errorf("standard error %q, want %q", got, want)
+	}
+}